@@ -18,4 +18,6 @@ type Asset interface {
 	GetPullingAssetInfo(ctx context.Context) (*types.InProgressAsset, error) //perm:write
 	// GetAssetProgresses retrieves the progress of assets with specified assetCIDs
 	GetAssetProgresses(ctx context.Context, assetCIDs []string) (*types.PullResult, error) //perm:write
+	// GetStorageProof returns the hashes of the data and the nonce of the random blocks of the challenged asset, key is the random index
+	GetStorageProof(ctx context.Context, challenge *types.StorageChallenge) (map[int]string, error) //perm:write
 }
@@ -17,6 +17,10 @@ type Edge interface {
 	// ExternalServiceAddress check service address with different scheduler server
 	// if behind nat, service address maybe different
 	ExternalServiceAddress(ctx context.Context, schedulerURL string) (string, error) //perm:write
+	// ProbeNATType discovers the NAT type of the edge with the NAT probe service at the address
+	ProbeNATType(ctx context.Context, probeAddr string) (types.NatType, error) //perm:write
 	// UserNATTravel build connection for user
 	UserNATPunch(ctx context.Context, userServiceAddress string, req *types.NatPunchReq) error //perm:write
+	// EdgeNATPunch punches a hole to the user of the session from the service socket, and confirms it with a HTTP/3 ping to the user
+	EdgeNATPunch(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error) //perm:write
 }
@@ -19,12 +19,30 @@ type Scheduler interface {
 	RegisterNode(ctx context.Context, publicKey string, nodeType types.NodeType) (string, error) //perm:admin
 	// UnregisterNode removes a node from the scheduler with the specified node ID
 	UnregisterNode(ctx context.Context, nodeID string) error //perm:admin
+	// DrainNode stops assigning new replicas and validations to the node, and re-creates its replicas on the other nodes
+	DrainNode(ctx context.Context, nodeID string) error //perm:admin
+	// GetNodeDrainStatus returns the drain progress of the node, the node can be unregistered safely once it is drained
+	GetNodeDrainStatus(ctx context.Context, nodeID string) (*types.NodeDrainStatus, error) //perm:read
+	// SetNodeMaintenance puts the node in or out of maintenance, the node receives no new replicas or validations in maintenance
+	SetNodeMaintenance(ctx context.Context, nodeID string, enable bool) error //perm:admin
+	// BanNode bans the node until the expiration, the node is disconnected and can not login or connect again
+	BanNode(ctx context.Context, nodeID, reason string, expiration time.Time) error //perm:admin
+	// UnbanNode removes the ban of the node
+	UnbanNode(ctx context.Context, nodeID string) error //perm:admin
+	// QuarantineNode quarantines the node until the expiration, the node stays online but receives no new replicas and serves no downloads
+	QuarantineNode(ctx context.Context, nodeID, reason string, expiration time.Time) error //perm:admin
+	// LiftNodeQuarantine removes the quarantine of the node
+	LiftNodeQuarantine(ctx context.Context, nodeID string) error //perm:admin
+	// GetNodeBlacklist retrieves the unexpired bans and quarantines of the nodes, including the automatic quarantines
+	GetNodeBlacklist(ctx context.Context) ([]*types.NodeBlacklistEntry, error) //perm:read
 	// UpdateNodePort updates the port for the node with the specified node
 	UpdateNodePort(ctx context.Context, nodeID, port string) error //perm:admin
 	// EdgeConnect edge node login to the scheduler
 	EdgeConnect(ctx context.Context, opts *types.ConnectOptions) error //perm:write
 	// NodeValidationResult processes the validation result for a node
 	NodeValidationResult(ctx context.Context, vr ValidationResult) error //perm:write
+	// GetValidatableNodePublicKey returns the public key of the node which is validated by the caller in the round, it returns an error if the node is not scheduled
+	GetValidatableNodePublicKey(ctx context.Context, nodeID, roundID string) (string, error) //perm:write
 	// CandidateConnect candidate node login to the scheduler
 	CandidateConnect(ctx context.Context, opts *types.ConnectOptions) error //perm:write
 	// NodeRemoveAssetResult the result of an asset removal operation
@@ -35,18 +53,51 @@ type Scheduler interface {
 	VerifyNodeAuthToken(ctx context.Context, token string) ([]auth.Permission, error) //perm:read
 	// NodeLogin generates an authentication token for a node with the specified node ID and signature
 	NodeLogin(ctx context.Context, nodeID, sign string) (string, error) //perm:read
+	// ReportNodeStats reports the resource usage of the caller, the reports are stored downsampled
+	ReportNodeStats(ctx context.Context, stats *types.NodeStats) error //perm:write
+	// GetNodeStatsHistory retrieves the resource usage history of the node in the time range averaged in every step,
+	// the step is at least 5 minutes and at least an hour for the history older than 7 days
+	GetNodeStatsHistory(ctx context.Context, nodeID string, from, to time.Time, step time.Duration) ([]*types.NodeStats, error) //perm:read
 	// GetNodeInfo get information for node
 	GetNodeInfo(ctx context.Context, nodeID string) (types.NodeInfo, error) //perm:read
 	// GetNodeList retrieves a list of nodes with pagination using the specified cursor and count
 	GetNodeList(ctx context.Context, cursor int, count int) (*types.ListNodesRsp, error) //perm:read
-	// GetAssetListForBucket retrieves a list of asset CIDs for a bucket associated with the specified bucket ID (bucketID is 'nodeID + bucketNumber')
-	GetAssetListForBucket(ctx context.Context, bucketID string) ([]string, error) //perm:write
+	// GetNodeListBySelector retrieves a list of nodes matching the label selector with pagination using the specified cursor and count,
+	// the selector is in the form of "rack=sz-3,isp!=ct", an empty selector selects every node
+	GetNodeListBySelector(ctx context.Context, cursor int, count int, selector string) (*types.ListNodesRsp, error) //perm:read
+	// SetNodeLabels adds or updates the labels of the node
+	SetNodeLabels(ctx context.Context, nodeID string, labels map[string]string) error //perm:admin
+	// RemoveNodeLabels removes the labels of the node with the specified keys
+	RemoveNodeLabels(ctx context.Context, nodeID string, keys []string) error //perm:admin
+	// GetNodeLabels retrieves the labels of the node
+	GetNodeLabels(ctx context.Context, nodeID string) (map[string]string, error) //perm:read
+	// GetAssetListForBucket retrieves a list of asset CIDs in the bucket of the caller's asset view with the specified bucket number
+	GetAssetListForBucket(ctx context.Context, bucketNumber uint32) ([]string, error) //perm:write
+	// SubmitSyncResult reports the extra and lost assets found by the asset view sync of the caller, the replica records and the asset view are updated accordingly
+	SubmitSyncResult(ctx context.Context, result *types.SyncResult) error //perm:write
+	// GetAssetRangeHashes retrieves the hashes of the non-empty sub-ranges of the range in the caller's asset view, the key is the index of the sub-range
+	GetAssetRangeHashes(ctx context.Context, r types.AssetRange) (map[uint32]string, error) //perm:write
+	// GetAssetListForRange retrieves a list of asset CIDs in the range of the caller's asset view
+	GetAssetListForRange(ctx context.Context, r types.AssetRange) ([]string, error) //perm:write
+	// SyncNodeAssetView syncs the assets of the node with its asset view immediately and returns the extra and lost assets,
+	// the result is not applied if dryRun is true
+	SyncNodeAssetView(ctx context.Context, nodeID string, dryRun bool) (*types.SyncResult, error) //perm:admin
+	// GetAssetViewSyncStatus returns the status of the last asset view sync of the node
+	GetAssetViewSyncStatus(ctx context.Context, nodeID string) (*types.NodeSyncStatus, error) //perm:read
 	// GetEdgeExternalServiceAddress nat travel, get edge external addr with different scheduler
 	GetEdgeExternalServiceAddress(ctx context.Context, nodeID, schedulerURL string) (string, error) //perm:write
-	// GetNodeNATType returns the NAT type for a node with the specified node
+	// GetNodeNATType re-detects and returns the NAT type for a node with the specified node
 	GetNodeNATType(ctx context.Context, nodeID string) (types.NatType, error) //perm:write
+	// GetNodeNATHistory retrieves the NAT types detected for the node, a record is added when the NAT type or the address changes, the latest first
+	GetNodeNATHistory(ctx context.Context, nodeID string, limit int) ([]*types.NodeNATRecord, error) //perm:read
 	// NatPunch between user and node
 	NatPunch(ctx context.Context, target *types.NatPunchReq) error //perm:read
+	// StartNatPunch starts a hole punch between the user and the edge node, the user sends the punch packets to the edge address of the session
+	StartNatPunch(ctx context.Context, target *types.NatPunchReq) (*types.NatPunchSession, error) //perm:read
+	// GetNatPunchResult waits until the hole punch of the session ends, and returns its result
+	GetNatPunchResult(ctx context.Context, sessionID string) (*types.NatPunchResult, error) //perm:read
+	// GetNatPunchStats returns the hole punch statistics of the NAT types of the edges
+	GetNatPunchStats(ctx context.Context) ([]*types.NatPunchStats, error) //perm:read
 	// CheckNetworkConnectivity check tcp or udp network connectivity , network is "tcp" or "udp"
 	CheckNetworkConnectivity(ctx context.Context, network, targetURL string) error //perm:read
 	// GetEdgeDownloadInfos retrieves download information for the edge with the asset with the specified CID.
@@ -75,16 +126,37 @@ type Scheduler interface {
 	GetAssetReplicaInfos(ctx context.Context, req types.ListReplicaInfosReq) (*types.ListReplicaInfosRsp, error) //perm:read
 	// GetValidationResults retrieves a list of validation results with pagination using the specified time range, page number, and page size
 	GetValidationResults(ctx context.Context, startTime, endTime time.Time, pageNumber, pageSize int) (*types.ListValidationResultRsp, error) //perm:read
+	// ValidateNode validates the node immediately, it returns the id of the validation round
+	ValidateNode(ctx context.Context, nodeID string) (string, error) //perm:admin
+	// ListValidationResults retrieves a list of validation results filtered by node, validator, round, status and time range
+	ListValidationResults(ctx context.Context, req types.ListValidationResultsReq) (*types.ListValidationResultRsp, error) //perm:read
+	// GetValidationSummaries retrieves the validation statistics of the nodes in a time range, the nodes with the lowest success ratio first
+	GetValidationSummaries(ctx context.Context, req types.ValidationSummaryReq) (*types.ListValidationSummaryRsp, error) //perm:read
+	// GetValidationChallenge re-derives the seed of the validation from the randomness beacon, and the blocks the node is expected to send
+	GetValidationChallenge(ctx context.Context, roundID, nodeID string) (*types.ValidationChallenge, error) //perm:read
 	// SubmitUserProofsOfWork submits Proof of Work for User Asset Download
 	SubmitUserProofsOfWork(ctx context.Context, proofs []*types.UserProofOfWork) error //perm:read
+	// GetServedTrafficStats retrieves the traffic served by nodes, aggregated by node from the confirmed user proofs of work
+	GetServedTrafficStats(ctx context.Context, req types.ListServedTrafficReq) (*types.ListServedTrafficRsp, error) //perm:read
+	// SubmitDownloadRecords submits the download records of the gateway responses served by the node
+	SubmitDownloadRecords(ctx context.Context, records []*types.DownloadHistory) error //perm:write
+	// GetDownloadRecords retrieves a list of download records filtered by node, asset and time range with pagination
+	GetDownloadRecords(ctx context.Context, req types.ListDownloadRecordsReq) (*types.ListDownloadRecordRsp, error) //perm:read
 
 	// Server-related methods
+	// SubscribeEvents streams the node online/offline, asset state, validation result and replica change events matching the filter,
+	// the channel is closed when the context is done, events are dropped if the subscriber does not keep up
+	SubscribeEvents(ctx context.Context, filter types.EventFilter) (<-chan types.Event, error) //perm:read
 	// GetSchedulerPublicKey retrieves the scheduler's public key in PEM format
 	GetSchedulerPublicKey(ctx context.Context) (string, error) //perm:write
 	// TriggerElection starts a new election process
 	TriggerElection(ctx context.Context) error //perm:admin
+	// GetElectionRecords retrieves the audit records of the validator elections, the latest first
+	GetElectionRecords(ctx context.Context, cursor int, count int) (*types.ListElectionRecordsRsp, error) //perm:read
 	// GetEdgeUpdateConfigs retrieves edge update configurations for different node types
 	GetEdgeUpdateConfigs(ctx context.Context) (map[int]*EdgeUpdateConfig, error) //perm:read
+	// GetEdgeUpdateConfigsOfNode retrieves the edge update configurations rolled out to the node by their node selectors
+	GetEdgeUpdateConfigsOfNode(ctx context.Context, nodeID string) (map[int]*EdgeUpdateConfig, error) //perm:read
 	// SetEdgeUpdateConfig updates the edge update configuration for a specific node type with the provided information
 	SetEdgeUpdateConfig(ctx context.Context, info *EdgeUpdateConfig) error //perm:admin
 	// DeleteEdgeUpdateConfig deletes the edge update configuration for the specified node type
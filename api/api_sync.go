@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+
+	"github.com/Filecoin-Titan/titan/api/types"
 )
 
 // DataSync sync scheduler asset to node
@@ -13,4 +15,7 @@ type DataSync interface {
 	// hashes are map of bucket, key is number of bucket, value is hash
 	// return mismatch bucket number
 	CompareBucketHashes(ctx context.Context, hashes map[uint32]string) ([]uint32, error) //perm:write
+	// SyncAssetView compares the bucket hashes and returns the extra and lost assets,
+	// they are applied asynchronously as CompareBucketHashes does, unless dryRun is true
+	SyncAssetView(ctx context.Context, hashes map[uint32]string, dryRun bool) (*types.SyncResult, error) //perm:write
 }
@@ -12,8 +12,14 @@ type Validation interface {
 type ValidateReq struct {
 	// TCPSrvAddr Candidate tcp server address
 	TCPSrvAddr string
-	RandomSeed int64
-	Duration   int
+	// TCPSrvTLS the candidate tcp server is wrapped with tls
+	TCPSrvTLS bool
+	// TCPSrvCertHash the fingerprint of the tls certificate of the candidate tcp server
+	TCPSrvCertHash string
+	RandomSeed     int64
+	Duration       int
+	// RoundID the validation round, signed by the node in the handshake
+	RoundID string
 }
 
 // TODO: new tcp package, add these to tcp package
@@ -21,7 +27,20 @@ type ValidateReq struct {
 type TCPMsgType int
 
 const (
+	// TCPMsgTypeNodeID Deprecated: the node id is not trusted, replaced by TCPMsgTypeAuth
 	TCPMsgTypeNodeID TCPMsgType = iota + 1
 	TCPMsgTypeBlock
 	TCPMsgTypeCancel
+	// TCPMsgTypeChallenge the validator sends a nonce to the node
+	TCPMsgTypeChallenge
+	// TCPMsgTypeAuth the node replies the challenge with ValidationAuth
+	TCPMsgTypeAuth
 )
+
+// ValidationAuth is the handshake of the validated node, it proves the node owns the registered private key
+type ValidationAuth struct {
+	NodeID  string
+	RoundID string
+	// Sign is the signature of the round id and the validator nonce
+	Sign []byte
+}
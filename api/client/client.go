@@ -24,6 +24,16 @@ func NewScheduler(ctx context.Context, addr string, requestHeader http.Header) (
 	return &res, closer, err
 }
 
+// NewSchedulerWithHTTPClient creates a new http jsonrpc client of the scheduler with a custom http client,
+// e.g. an HTTP/3 client which sends the requests from a given UDP socket
+func NewSchedulerWithHTTPClient(ctx context.Context, addr string, requestHeader http.Header, httpClient *http.Client) (api.Scheduler, jsonrpc.ClientCloser, error) {
+	var res api.SchedulerStruct
+	closer, err := jsonrpc.NewMergeClient(ctx, addr, "titan",
+		api.GetInternalStructs(&res), requestHeader, jsonrpc.WithHTTPClient(httpClient))
+
+	return &res, closer, err
+}
+
 func getPushURL(addr string) (string, error) {
 	pushURL, err := url.Parse(addr)
 	if err != nil {
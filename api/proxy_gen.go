@@ -25,6 +25,8 @@ type AssetStruct struct {
 
 		GetPullingAssetInfo func(p0 context.Context) (*types.InProgressAsset, error) `perm:"write"`
 
+		GetStorageProof func(p0 context.Context, p1 *types.StorageChallenge) (map[int]string, error) `perm:"write"`
+
 		PullAsset func(p0 context.Context, p1 string, p2 []*types.CandidateDownloadInfo) error `perm:"write"`
 	}
 }
@@ -94,6 +96,8 @@ type DataSyncStruct struct {
 		CompareBucketHashes func(p0 context.Context, p1 map[uint32]string) ([]uint32, error) `perm:"write"`
 
 		CompareTopHash func(p0 context.Context, p1 string) (bool, error) `perm:"write"`
+
+		SyncAssetView func(p0 context.Context, p1 map[uint32]string, p2 bool) (*types.SyncResult, error) `perm:"write"`
 	}
 }
 
@@ -123,8 +127,12 @@ type EdgeStruct struct {
 	AssetStruct
 
 	Internal struct {
+		EdgeNATPunch func(p0 context.Context, p1 *types.NatPunchSession) (*types.NatPunchResult, error) `perm:"write"`
+
 		ExternalServiceAddress func(p0 context.Context, p1 string) (string, error) `perm:"write"`
 
+		ProbeNATType func(p0 context.Context, p1 string) (types.NatType, error) `perm:"write"`
+
 		UserNATPunch func(p0 context.Context, p1 string, p2 *types.NatPunchReq) error `perm:"write"`
 
 		WaitQuiet func(p0 context.Context) error `perm:"read"`
@@ -163,15 +171,23 @@ type SchedulerStruct struct {
 	CommonStruct
 
 	Internal struct {
+		BanNode func(p0 context.Context, p1 string, p2 string, p3 time.Time) error `perm:"admin"`
+
 		CandidateConnect func(p0 context.Context, p1 *types.ConnectOptions) error `perm:"write"`
 
 		CheckNetworkConnectivity func(p0 context.Context, p1 string, p2 string) error `perm:"read"`
 
 		DeleteEdgeUpdateConfig func(p0 context.Context, p1 int) error `perm:"admin"`
 
+		DrainNode func(p0 context.Context, p1 string) error `perm:"admin"`
+
 		EdgeConnect func(p0 context.Context, p1 *types.ConnectOptions) error `perm:"write"`
 
-		GetAssetListForBucket func(p0 context.Context, p1 string) ([]string, error) `perm:"write"`
+		GetAssetListForBucket func(p0 context.Context, p1 uint32) ([]string, error) `perm:"write"`
+
+		GetAssetListForRange func(p0 context.Context, p1 types.AssetRange) ([]string, error) `perm:"write"`
+
+		GetAssetRangeHashes func(p0 context.Context, p1 types.AssetRange) (map[uint32]string, error) `perm:"write"`
 
 		GetAssetRecord func(p0 context.Context, p1 string) (*types.AssetRecord, error) `perm:"read"`
 
@@ -179,28 +195,64 @@ type SchedulerStruct struct {
 
 		GetAssetReplicaInfos func(p0 context.Context, p1 types.ListReplicaInfosReq) (*types.ListReplicaInfosRsp, error) `perm:"read"`
 
+		GetAssetViewSyncStatus func(p0 context.Context, p1 string) (*types.NodeSyncStatus, error) `perm:"read"`
+
 		GetCandidateDownloadInfos func(p0 context.Context, p1 string) ([]*types.CandidateDownloadInfo, error) `perm:"read"`
 
+		GetDownloadRecords func(p0 context.Context, p1 types.ListDownloadRecordsReq) (*types.ListDownloadRecordRsp, error) `perm:"read"`
+
 		GetEdgeDownloadInfos func(p0 context.Context, p1 string) (*types.EdgeDownloadInfoList, error) `perm:"read"`
 
 		GetEdgeExternalServiceAddress func(p0 context.Context, p1 string, p2 string) (string, error) `perm:"write"`
 
 		GetEdgeUpdateConfigs func(p0 context.Context) (map[int]*EdgeUpdateConfig, error) `perm:"read"`
 
+		GetEdgeUpdateConfigsOfNode func(p0 context.Context, p1 string) (map[int]*EdgeUpdateConfig, error) `perm:"read"`
+
+		GetElectionRecords func(p0 context.Context, p1 int, p2 int) (*types.ListElectionRecordsRsp, error) `perm:"read"`
+
 		GetExternalAddress func(p0 context.Context) (string, error) `perm:"read"`
 
+		GetNatPunchResult func(p0 context.Context, p1 string) (*types.NatPunchResult, error) `perm:"read"`
+
+		GetNatPunchStats func(p0 context.Context) ([]*types.NatPunchStats, error) `perm:"read"`
+
+		GetNodeBlacklist func(p0 context.Context) ([]*types.NodeBlacklistEntry, error) `perm:"read"`
+
+		GetNodeDrainStatus func(p0 context.Context, p1 string) (*types.NodeDrainStatus, error) `perm:"read"`
+
 		GetNodeInfo func(p0 context.Context, p1 string) (types.NodeInfo, error) `perm:"read"`
 
+		GetNodeLabels func(p0 context.Context, p1 string) (map[string]string, error) `perm:"read"`
+
 		GetNodeList func(p0 context.Context, p1 int, p2 int) (*types.ListNodesRsp, error) `perm:"read"`
 
+		GetNodeListBySelector func(p0 context.Context, p1 int, p2 int, p3 string) (*types.ListNodesRsp, error) `perm:"read"`
+
+		GetNodeNATHistory func(p0 context.Context, p1 string, p2 int) ([]*types.NodeNATRecord, error) `perm:"read"`
+
 		GetNodeNATType func(p0 context.Context, p1 string) (types.NatType, error) `perm:"write"`
 
+		GetNodeStatsHistory func(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 time.Duration) ([]*types.NodeStats, error) `perm:"read"`
+
 		GetOnlineNodeCount func(p0 context.Context, p1 types.NodeType) (int, error) `perm:"read"`
 
 		GetSchedulerPublicKey func(p0 context.Context) (string, error) `perm:"write"`
 
+		GetServedTrafficStats func(p0 context.Context, p1 types.ListServedTrafficReq) (*types.ListServedTrafficRsp, error) `perm:"read"`
+
+		GetValidatableNodePublicKey func(p0 context.Context, p1 string, p2 string) (string, error) `perm:"write"`
+
+		GetValidationChallenge func(p0 context.Context, p1 string, p2 string) (*types.ValidationChallenge, error) `perm:"read"`
+
 		GetValidationResults func(p0 context.Context, p1 time.Time, p2 time.Time, p3 int, p4 int) (*types.ListValidationResultRsp, error) `perm:"read"`
 
+		GetValidationSummaries func(p0 context.Context, p1 types.ValidationSummaryReq) (*types.ListValidationSummaryRsp, error) `perm:"read"`
+
+		LiftNodeQuarantine func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		ListValidationResults func(p0 context.Context, p1 types.ListValidationResultsReq) (*types.ListValidationResultRsp, error) `perm:"read"`
+
 		NatPunch func(p0 context.Context, p1 *types.NatPunchReq) error `perm:"read"`
 
 		NodeExists func(p0 context.Context, p1 string) error `perm:"write"`
@@ -213,6 +265,8 @@ type SchedulerStruct struct {
 
 		PullAsset func(p0 context.Context, p1 *types.PullAssetReq) error `perm:"admin"`
 
+		QuarantineNode func(p0 context.Context, p1 string, p2 string, p3 time.Time) error `perm:"admin"`
+
 		RePullFailedAssets func(p0 context.Context, p1 []types.AssetHash) error `perm:"admin"`
 
 		RegisterNode func(p0 context.Context, p1 string, p2 types.NodeType) (string, error) `perm:"admin"`
@@ -221,18 +275,40 @@ type SchedulerStruct struct {
 
 		RemoveAssetReplica func(p0 context.Context, p1 string, p2 string) error `perm:"admin"`
 
+		RemoveNodeLabels func(p0 context.Context, p1 string, p2 []string) error `perm:"admin"`
+
+		ReportNodeStats func(p0 context.Context, p1 *types.NodeStats) error `perm:"write"`
+
 		SetEdgeUpdateConfig func(p0 context.Context, p1 *EdgeUpdateConfig) error `perm:"admin"`
 
+		SetNodeLabels func(p0 context.Context, p1 string, p2 map[string]string) error `perm:"admin"`
+
+		SetNodeMaintenance func(p0 context.Context, p1 string, p2 bool) error `perm:"admin"`
+
+		StartNatPunch func(p0 context.Context, p1 *types.NatPunchReq) (*types.NatPunchSession, error) `perm:"read"`
+
+		SubmitDownloadRecords func(p0 context.Context, p1 []*types.DownloadHistory) error `perm:"write"`
+
+		SubmitSyncResult func(p0 context.Context, p1 *types.SyncResult) error `perm:"write"`
+
 		SubmitUserProofsOfWork func(p0 context.Context, p1 []*types.UserProofOfWork) error `perm:"read"`
 
+		SubscribeEvents func(p0 context.Context, p1 types.EventFilter) (<-chan types.Event, error) `perm:"read"`
+
+		SyncNodeAssetView func(p0 context.Context, p1 string, p2 bool) (*types.SyncResult, error) `perm:"admin"`
+
 		TriggerElection func(p0 context.Context) error `perm:"admin"`
 
+		UnbanNode func(p0 context.Context, p1 string) error `perm:"admin"`
+
 		UnregisterNode func(p0 context.Context, p1 string) error `perm:"admin"`
 
 		UpdateAssetExpiration func(p0 context.Context, p1 string, p2 time.Time) error `perm:"admin"`
 
 		UpdateNodePort func(p0 context.Context, p1 string, p2 string) error `perm:"admin"`
 
+		ValidateNode func(p0 context.Context, p1 string) (string, error) `perm:"admin"`
+
 		VerifyNodeAuthToken func(p0 context.Context, p1 string) ([]auth.Permission, error) `perm:"read"`
 	}
 }
@@ -294,6 +370,17 @@ func (s *AssetStub) GetPullingAssetInfo(p0 context.Context) (*types.InProgressAs
 	return nil, ErrNotSupported
 }
 
+func (s *AssetStruct) GetStorageProof(p0 context.Context, p1 *types.StorageChallenge) (map[int]string, error) {
+	if s.Internal.GetStorageProof == nil {
+		return *new(map[int]string), ErrNotSupported
+	}
+	return s.Internal.GetStorageProof(p0, p1)
+}
+
+func (s *AssetStub) GetStorageProof(p0 context.Context, p1 *types.StorageChallenge) (map[int]string, error) {
+	return *new(map[int]string), ErrNotSupported
+}
+
 func (s *AssetStruct) PullAsset(p0 context.Context, p1 string, p2 []*types.CandidateDownloadInfo) error {
 	if s.Internal.PullAsset == nil {
 		return ErrNotSupported
@@ -459,6 +546,17 @@ func (s *DataSyncStub) CompareTopHash(p0 context.Context, p1 string) (bool, erro
 	return false, ErrNotSupported
 }
 
+func (s *DataSyncStruct) SyncAssetView(p0 context.Context, p1 map[uint32]string, p2 bool) (*types.SyncResult, error) {
+	if s.Internal.SyncAssetView == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SyncAssetView(p0, p1, p2)
+}
+
+func (s *DataSyncStub) SyncAssetView(p0 context.Context, p1 map[uint32]string, p2 bool) (*types.SyncResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *DeviceStruct) GetNodeID(p0 context.Context) (string, error) {
 	if s.Internal.GetNodeID == nil {
 		return "", ErrNotSupported
@@ -481,6 +579,17 @@ func (s *DeviceStub) GetNodeInfo(p0 context.Context) (types.NodeInfo, error) {
 	return *new(types.NodeInfo), ErrNotSupported
 }
 
+func (s *EdgeStruct) EdgeNATPunch(p0 context.Context, p1 *types.NatPunchSession) (*types.NatPunchResult, error) {
+	if s.Internal.EdgeNATPunch == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.EdgeNATPunch(p0, p1)
+}
+
+func (s *EdgeStub) EdgeNATPunch(p0 context.Context, p1 *types.NatPunchSession) (*types.NatPunchResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *EdgeStruct) ExternalServiceAddress(p0 context.Context, p1 string) (string, error) {
 	if s.Internal.ExternalServiceAddress == nil {
 		return "", ErrNotSupported
@@ -492,6 +601,17 @@ func (s *EdgeStub) ExternalServiceAddress(p0 context.Context, p1 string) (string
 	return "", ErrNotSupported
 }
 
+func (s *EdgeStruct) ProbeNATType(p0 context.Context, p1 string) (types.NatType, error) {
+	if s.Internal.ProbeNATType == nil {
+		return *new(types.NatType), ErrNotSupported
+	}
+	return s.Internal.ProbeNATType(p0, p1)
+}
+
+func (s *EdgeStub) ProbeNATType(p0 context.Context, p1 string) (types.NatType, error) {
+	return *new(types.NatType), ErrNotSupported
+}
+
 func (s *EdgeStruct) UserNATPunch(p0 context.Context, p1 string, p2 *types.NatPunchReq) error {
 	if s.Internal.UserNATPunch == nil {
 		return ErrNotSupported
@@ -547,6 +667,17 @@ func (s *LocatorStub) GetUserAccessPoint(p0 context.Context, p1 string) (*Access
 	return nil, ErrNotSupported
 }
 
+func (s *SchedulerStruct) BanNode(p0 context.Context, p1 string, p2 string, p3 time.Time) error {
+	if s.Internal.BanNode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.BanNode(p0, p1, p2, p3)
+}
+
+func (s *SchedulerStub) BanNode(p0 context.Context, p1 string, p2 string, p3 time.Time) error {
+	return ErrNotSupported
+}
+
 func (s *SchedulerStruct) CandidateConnect(p0 context.Context, p1 *types.ConnectOptions) error {
 	if s.Internal.CandidateConnect == nil {
 		return ErrNotSupported
@@ -580,6 +711,17 @@ func (s *SchedulerStub) DeleteEdgeUpdateConfig(p0 context.Context, p1 int) error
 	return ErrNotSupported
 }
 
+func (s *SchedulerStruct) DrainNode(p0 context.Context, p1 string) error {
+	if s.Internal.DrainNode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.DrainNode(p0, p1)
+}
+
+func (s *SchedulerStub) DrainNode(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *SchedulerStruct) EdgeConnect(p0 context.Context, p1 *types.ConnectOptions) error {
 	if s.Internal.EdgeConnect == nil {
 		return ErrNotSupported
@@ -591,17 +733,39 @@ func (s *SchedulerStub) EdgeConnect(p0 context.Context, p1 *types.ConnectOptions
 	return ErrNotSupported
 }
 
-func (s *SchedulerStruct) GetAssetListForBucket(p0 context.Context, p1 string) ([]string, error) {
+func (s *SchedulerStruct) GetAssetListForBucket(p0 context.Context, p1 uint32) ([]string, error) {
 	if s.Internal.GetAssetListForBucket == nil {
 		return *new([]string), ErrNotSupported
 	}
 	return s.Internal.GetAssetListForBucket(p0, p1)
 }
 
-func (s *SchedulerStub) GetAssetListForBucket(p0 context.Context, p1 string) ([]string, error) {
+func (s *SchedulerStub) GetAssetListForBucket(p0 context.Context, p1 uint32) ([]string, error) {
 	return *new([]string), ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetAssetListForRange(p0 context.Context, p1 types.AssetRange) ([]string, error) {
+	if s.Internal.GetAssetListForRange == nil {
+		return *new([]string), ErrNotSupported
+	}
+	return s.Internal.GetAssetListForRange(p0, p1)
+}
+
+func (s *SchedulerStub) GetAssetListForRange(p0 context.Context, p1 types.AssetRange) ([]string, error) {
+	return *new([]string), ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetAssetRangeHashes(p0 context.Context, p1 types.AssetRange) (map[uint32]string, error) {
+	if s.Internal.GetAssetRangeHashes == nil {
+		return *new(map[uint32]string), ErrNotSupported
+	}
+	return s.Internal.GetAssetRangeHashes(p0, p1)
+}
+
+func (s *SchedulerStub) GetAssetRangeHashes(p0 context.Context, p1 types.AssetRange) (map[uint32]string, error) {
+	return *new(map[uint32]string), ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetAssetRecord(p0 context.Context, p1 string) (*types.AssetRecord, error) {
 	if s.Internal.GetAssetRecord == nil {
 		return nil, ErrNotSupported
@@ -635,6 +799,17 @@ func (s *SchedulerStub) GetAssetReplicaInfos(p0 context.Context, p1 types.ListRe
 	return nil, ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetAssetViewSyncStatus(p0 context.Context, p1 string) (*types.NodeSyncStatus, error) {
+	if s.Internal.GetAssetViewSyncStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetAssetViewSyncStatus(p0, p1)
+}
+
+func (s *SchedulerStub) GetAssetViewSyncStatus(p0 context.Context, p1 string) (*types.NodeSyncStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetCandidateDownloadInfos(p0 context.Context, p1 string) ([]*types.CandidateDownloadInfo, error) {
 	if s.Internal.GetCandidateDownloadInfos == nil {
 		return *new([]*types.CandidateDownloadInfo), ErrNotSupported
@@ -646,6 +821,17 @@ func (s *SchedulerStub) GetCandidateDownloadInfos(p0 context.Context, p1 string)
 	return *new([]*types.CandidateDownloadInfo), ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetDownloadRecords(p0 context.Context, p1 types.ListDownloadRecordsReq) (*types.ListDownloadRecordRsp, error) {
+	if s.Internal.GetDownloadRecords == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetDownloadRecords(p0, p1)
+}
+
+func (s *SchedulerStub) GetDownloadRecords(p0 context.Context, p1 types.ListDownloadRecordsReq) (*types.ListDownloadRecordRsp, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetEdgeDownloadInfos(p0 context.Context, p1 string) (*types.EdgeDownloadInfoList, error) {
 	if s.Internal.GetEdgeDownloadInfos == nil {
 		return nil, ErrNotSupported
@@ -679,6 +865,28 @@ func (s *SchedulerStub) GetEdgeUpdateConfigs(p0 context.Context) (map[int]*EdgeU
 	return *new(map[int]*EdgeUpdateConfig), ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetEdgeUpdateConfigsOfNode(p0 context.Context, p1 string) (map[int]*EdgeUpdateConfig, error) {
+	if s.Internal.GetEdgeUpdateConfigsOfNode == nil {
+		return *new(map[int]*EdgeUpdateConfig), ErrNotSupported
+	}
+	return s.Internal.GetEdgeUpdateConfigsOfNode(p0, p1)
+}
+
+func (s *SchedulerStub) GetEdgeUpdateConfigsOfNode(p0 context.Context, p1 string) (map[int]*EdgeUpdateConfig, error) {
+	return *new(map[int]*EdgeUpdateConfig), ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetElectionRecords(p0 context.Context, p1 int, p2 int) (*types.ListElectionRecordsRsp, error) {
+	if s.Internal.GetElectionRecords == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetElectionRecords(p0, p1, p2)
+}
+
+func (s *SchedulerStub) GetElectionRecords(p0 context.Context, p1 int, p2 int) (*types.ListElectionRecordsRsp, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetExternalAddress(p0 context.Context) (string, error) {
 	if s.Internal.GetExternalAddress == nil {
 		return "", ErrNotSupported
@@ -690,6 +898,50 @@ func (s *SchedulerStub) GetExternalAddress(p0 context.Context) (string, error) {
 	return "", ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetNatPunchResult(p0 context.Context, p1 string) (*types.NatPunchResult, error) {
+	if s.Internal.GetNatPunchResult == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetNatPunchResult(p0, p1)
+}
+
+func (s *SchedulerStub) GetNatPunchResult(p0 context.Context, p1 string) (*types.NatPunchResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetNatPunchStats(p0 context.Context) ([]*types.NatPunchStats, error) {
+	if s.Internal.GetNatPunchStats == nil {
+		return *new([]*types.NatPunchStats), ErrNotSupported
+	}
+	return s.Internal.GetNatPunchStats(p0)
+}
+
+func (s *SchedulerStub) GetNatPunchStats(p0 context.Context) ([]*types.NatPunchStats, error) {
+	return *new([]*types.NatPunchStats), ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetNodeBlacklist(p0 context.Context) ([]*types.NodeBlacklistEntry, error) {
+	if s.Internal.GetNodeBlacklist == nil {
+		return *new([]*types.NodeBlacklistEntry), ErrNotSupported
+	}
+	return s.Internal.GetNodeBlacklist(p0)
+}
+
+func (s *SchedulerStub) GetNodeBlacklist(p0 context.Context) ([]*types.NodeBlacklistEntry, error) {
+	return *new([]*types.NodeBlacklistEntry), ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetNodeDrainStatus(p0 context.Context, p1 string) (*types.NodeDrainStatus, error) {
+	if s.Internal.GetNodeDrainStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetNodeDrainStatus(p0, p1)
+}
+
+func (s *SchedulerStub) GetNodeDrainStatus(p0 context.Context, p1 string) (*types.NodeDrainStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetNodeInfo(p0 context.Context, p1 string) (types.NodeInfo, error) {
 	if s.Internal.GetNodeInfo == nil {
 		return *new(types.NodeInfo), ErrNotSupported
@@ -701,6 +953,17 @@ func (s *SchedulerStub) GetNodeInfo(p0 context.Context, p1 string) (types.NodeIn
 	return *new(types.NodeInfo), ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetNodeLabels(p0 context.Context, p1 string) (map[string]string, error) {
+	if s.Internal.GetNodeLabels == nil {
+		return *new(map[string]string), ErrNotSupported
+	}
+	return s.Internal.GetNodeLabels(p0, p1)
+}
+
+func (s *SchedulerStub) GetNodeLabels(p0 context.Context, p1 string) (map[string]string, error) {
+	return *new(map[string]string), ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetNodeList(p0 context.Context, p1 int, p2 int) (*types.ListNodesRsp, error) {
 	if s.Internal.GetNodeList == nil {
 		return nil, ErrNotSupported
@@ -712,6 +975,28 @@ func (s *SchedulerStub) GetNodeList(p0 context.Context, p1 int, p2 int) (*types.
 	return nil, ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetNodeListBySelector(p0 context.Context, p1 int, p2 int, p3 string) (*types.ListNodesRsp, error) {
+	if s.Internal.GetNodeListBySelector == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetNodeListBySelector(p0, p1, p2, p3)
+}
+
+func (s *SchedulerStub) GetNodeListBySelector(p0 context.Context, p1 int, p2 int, p3 string) (*types.ListNodesRsp, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetNodeNATHistory(p0 context.Context, p1 string, p2 int) ([]*types.NodeNATRecord, error) {
+	if s.Internal.GetNodeNATHistory == nil {
+		return *new([]*types.NodeNATRecord), ErrNotSupported
+	}
+	return s.Internal.GetNodeNATHistory(p0, p1, p2)
+}
+
+func (s *SchedulerStub) GetNodeNATHistory(p0 context.Context, p1 string, p2 int) ([]*types.NodeNATRecord, error) {
+	return *new([]*types.NodeNATRecord), ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetNodeNATType(p0 context.Context, p1 string) (types.NatType, error) {
 	if s.Internal.GetNodeNATType == nil {
 		return *new(types.NatType), ErrNotSupported
@@ -723,6 +1008,17 @@ func (s *SchedulerStub) GetNodeNATType(p0 context.Context, p1 string) (types.Nat
 	return *new(types.NatType), ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetNodeStatsHistory(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 time.Duration) ([]*types.NodeStats, error) {
+	if s.Internal.GetNodeStatsHistory == nil {
+		return *new([]*types.NodeStats), ErrNotSupported
+	}
+	return s.Internal.GetNodeStatsHistory(p0, p1, p2, p3, p4)
+}
+
+func (s *SchedulerStub) GetNodeStatsHistory(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 time.Duration) ([]*types.NodeStats, error) {
+	return *new([]*types.NodeStats), ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetOnlineNodeCount(p0 context.Context, p1 types.NodeType) (int, error) {
 	if s.Internal.GetOnlineNodeCount == nil {
 		return 0, ErrNotSupported
@@ -745,6 +1041,39 @@ func (s *SchedulerStub) GetSchedulerPublicKey(p0 context.Context) (string, error
 	return "", ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetServedTrafficStats(p0 context.Context, p1 types.ListServedTrafficReq) (*types.ListServedTrafficRsp, error) {
+	if s.Internal.GetServedTrafficStats == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetServedTrafficStats(p0, p1)
+}
+
+func (s *SchedulerStub) GetServedTrafficStats(p0 context.Context, p1 types.ListServedTrafficReq) (*types.ListServedTrafficRsp, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetValidatableNodePublicKey(p0 context.Context, p1 string, p2 string) (string, error) {
+	if s.Internal.GetValidatableNodePublicKey == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.GetValidatableNodePublicKey(p0, p1, p2)
+}
+
+func (s *SchedulerStub) GetValidatableNodePublicKey(p0 context.Context, p1 string, p2 string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *SchedulerStruct) GetValidationChallenge(p0 context.Context, p1 string, p2 string) (*types.ValidationChallenge, error) {
+	if s.Internal.GetValidationChallenge == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetValidationChallenge(p0, p1, p2)
+}
+
+func (s *SchedulerStub) GetValidationChallenge(p0 context.Context, p1 string, p2 string) (*types.ValidationChallenge, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *SchedulerStruct) GetValidationResults(p0 context.Context, p1 time.Time, p2 time.Time, p3 int, p4 int) (*types.ListValidationResultRsp, error) {
 	if s.Internal.GetValidationResults == nil {
 		return nil, ErrNotSupported
@@ -756,6 +1085,39 @@ func (s *SchedulerStub) GetValidationResults(p0 context.Context, p1 time.Time, p
 	return nil, ErrNotSupported
 }
 
+func (s *SchedulerStruct) GetValidationSummaries(p0 context.Context, p1 types.ValidationSummaryReq) (*types.ListValidationSummaryRsp, error) {
+	if s.Internal.GetValidationSummaries == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetValidationSummaries(p0, p1)
+}
+
+func (s *SchedulerStub) GetValidationSummaries(p0 context.Context, p1 types.ValidationSummaryReq) (*types.ListValidationSummaryRsp, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *SchedulerStruct) LiftNodeQuarantine(p0 context.Context, p1 string) error {
+	if s.Internal.LiftNodeQuarantine == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.LiftNodeQuarantine(p0, p1)
+}
+
+func (s *SchedulerStub) LiftNodeQuarantine(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *SchedulerStruct) ListValidationResults(p0 context.Context, p1 types.ListValidationResultsReq) (*types.ListValidationResultRsp, error) {
+	if s.Internal.ListValidationResults == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ListValidationResults(p0, p1)
+}
+
+func (s *SchedulerStub) ListValidationResults(p0 context.Context, p1 types.ListValidationResultsReq) (*types.ListValidationResultRsp, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *SchedulerStruct) NatPunch(p0 context.Context, p1 *types.NatPunchReq) error {
 	if s.Internal.NatPunch == nil {
 		return ErrNotSupported
@@ -822,6 +1184,17 @@ func (s *SchedulerStub) PullAsset(p0 context.Context, p1 *types.PullAssetReq) er
 	return ErrNotSupported
 }
 
+func (s *SchedulerStruct) QuarantineNode(p0 context.Context, p1 string, p2 string, p3 time.Time) error {
+	if s.Internal.QuarantineNode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.QuarantineNode(p0, p1, p2, p3)
+}
+
+func (s *SchedulerStub) QuarantineNode(p0 context.Context, p1 string, p2 string, p3 time.Time) error {
+	return ErrNotSupported
+}
+
 func (s *SchedulerStruct) RePullFailedAssets(p0 context.Context, p1 []types.AssetHash) error {
 	if s.Internal.RePullFailedAssets == nil {
 		return ErrNotSupported
@@ -866,6 +1239,28 @@ func (s *SchedulerStub) RemoveAssetReplica(p0 context.Context, p1 string, p2 str
 	return ErrNotSupported
 }
 
+func (s *SchedulerStruct) RemoveNodeLabels(p0 context.Context, p1 string, p2 []string) error {
+	if s.Internal.RemoveNodeLabels == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RemoveNodeLabels(p0, p1, p2)
+}
+
+func (s *SchedulerStub) RemoveNodeLabels(p0 context.Context, p1 string, p2 []string) error {
+	return ErrNotSupported
+}
+
+func (s *SchedulerStruct) ReportNodeStats(p0 context.Context, p1 *types.NodeStats) error {
+	if s.Internal.ReportNodeStats == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ReportNodeStats(p0, p1)
+}
+
+func (s *SchedulerStub) ReportNodeStats(p0 context.Context, p1 *types.NodeStats) error {
+	return ErrNotSupported
+}
+
 func (s *SchedulerStruct) SetEdgeUpdateConfig(p0 context.Context, p1 *EdgeUpdateConfig) error {
 	if s.Internal.SetEdgeUpdateConfig == nil {
 		return ErrNotSupported
@@ -877,6 +1272,61 @@ func (s *SchedulerStub) SetEdgeUpdateConfig(p0 context.Context, p1 *EdgeUpdateCo
 	return ErrNotSupported
 }
 
+func (s *SchedulerStruct) SetNodeLabels(p0 context.Context, p1 string, p2 map[string]string) error {
+	if s.Internal.SetNodeLabels == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SetNodeLabels(p0, p1, p2)
+}
+
+func (s *SchedulerStub) SetNodeLabels(p0 context.Context, p1 string, p2 map[string]string) error {
+	return ErrNotSupported
+}
+
+func (s *SchedulerStruct) SetNodeMaintenance(p0 context.Context, p1 string, p2 bool) error {
+	if s.Internal.SetNodeMaintenance == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SetNodeMaintenance(p0, p1, p2)
+}
+
+func (s *SchedulerStub) SetNodeMaintenance(p0 context.Context, p1 string, p2 bool) error {
+	return ErrNotSupported
+}
+
+func (s *SchedulerStruct) StartNatPunch(p0 context.Context, p1 *types.NatPunchReq) (*types.NatPunchSession, error) {
+	if s.Internal.StartNatPunch == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StartNatPunch(p0, p1)
+}
+
+func (s *SchedulerStub) StartNatPunch(p0 context.Context, p1 *types.NatPunchReq) (*types.NatPunchSession, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *SchedulerStruct) SubmitDownloadRecords(p0 context.Context, p1 []*types.DownloadHistory) error {
+	if s.Internal.SubmitDownloadRecords == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SubmitDownloadRecords(p0, p1)
+}
+
+func (s *SchedulerStub) SubmitDownloadRecords(p0 context.Context, p1 []*types.DownloadHistory) error {
+	return ErrNotSupported
+}
+
+func (s *SchedulerStruct) SubmitSyncResult(p0 context.Context, p1 *types.SyncResult) error {
+	if s.Internal.SubmitSyncResult == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SubmitSyncResult(p0, p1)
+}
+
+func (s *SchedulerStub) SubmitSyncResult(p0 context.Context, p1 *types.SyncResult) error {
+	return ErrNotSupported
+}
+
 func (s *SchedulerStruct) SubmitUserProofsOfWork(p0 context.Context, p1 []*types.UserProofOfWork) error {
 	if s.Internal.SubmitUserProofsOfWork == nil {
 		return ErrNotSupported
@@ -888,6 +1338,28 @@ func (s *SchedulerStub) SubmitUserProofsOfWork(p0 context.Context, p1 []*types.U
 	return ErrNotSupported
 }
 
+func (s *SchedulerStruct) SubscribeEvents(p0 context.Context, p1 types.EventFilter) (<-chan types.Event, error) {
+	if s.Internal.SubscribeEvents == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SubscribeEvents(p0, p1)
+}
+
+func (s *SchedulerStub) SubscribeEvents(p0 context.Context, p1 types.EventFilter) (<-chan types.Event, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *SchedulerStruct) SyncNodeAssetView(p0 context.Context, p1 string, p2 bool) (*types.SyncResult, error) {
+	if s.Internal.SyncNodeAssetView == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SyncNodeAssetView(p0, p1, p2)
+}
+
+func (s *SchedulerStub) SyncNodeAssetView(p0 context.Context, p1 string, p2 bool) (*types.SyncResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *SchedulerStruct) TriggerElection(p0 context.Context) error {
 	if s.Internal.TriggerElection == nil {
 		return ErrNotSupported
@@ -899,6 +1371,17 @@ func (s *SchedulerStub) TriggerElection(p0 context.Context) error {
 	return ErrNotSupported
 }
 
+func (s *SchedulerStruct) UnbanNode(p0 context.Context, p1 string) error {
+	if s.Internal.UnbanNode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.UnbanNode(p0, p1)
+}
+
+func (s *SchedulerStub) UnbanNode(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *SchedulerStruct) UnregisterNode(p0 context.Context, p1 string) error {
 	if s.Internal.UnregisterNode == nil {
 		return ErrNotSupported
@@ -932,6 +1415,17 @@ func (s *SchedulerStub) UpdateNodePort(p0 context.Context, p1 string, p2 string)
 	return ErrNotSupported
 }
 
+func (s *SchedulerStruct) ValidateNode(p0 context.Context, p1 string) (string, error) {
+	if s.Internal.ValidateNode == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.ValidateNode(p0, p1)
+}
+
+func (s *SchedulerStub) ValidateNode(p0 context.Context, p1 string) (string, error) {
+	return "", ErrNotSupported
+}
+
 func (s *SchedulerStruct) VerifyNodeAuthToken(p0 context.Context, p1 string) ([]auth.Permission, error) {
 	if s.Internal.VerifyNodeAuthToken == nil {
 		return *new([]auth.Permission), ErrNotSupported
@@ -31,6 +31,89 @@ type RemoveAssetResult struct {
 	DiskUsage   float64
 }
 
+// SyncResult represents the result of the asset view sync of a node
+type SyncResult struct {
+	// ExtraAssets are the CIDs of the assets which are not in the asset view of the scheduler, the node deletes them
+	ExtraAssets []string
+	// LostAssets are the CIDs of the assets which are in the asset view of the scheduler but not in the node
+	LostAssets []string
+	// MismatchBuckets is the count of the buckets which do not match the asset view of the scheduler
+	MismatchBuckets int
+}
+
+// Constants of the Merkle tree of a bucket in the asset view
+const (
+	// AssetRangeFanout is the number of the sub-ranges of a range
+	AssetRangeFanout = 16
+	// AssetRangeMaxLevel is the level of the smallest ranges
+	AssetRangeMaxLevel = 4
+)
+
+// AssetRange represents a range of the assets in a bucket of the asset view.
+// A bucket is divided into AssetRangeFanout sub-ranges level by level as a Merkle tree, the range at level 0 is the whole bucket.
+// An asset is in the range at the level whose index is the asset key modulo AssetRangeFanout to the power of the level,
+// the asset key is the fnv-1a hash of the asset multihash divided by the number of the buckets.
+type AssetRange struct {
+	Bucket uint32
+	Level  int
+	Index  uint32
+}
+
+// Contains returns whether the asset with the key is in the range
+func (r AssetRange) Contains(key uint32) bool {
+	return key%assetRangeSize(r.Level) == r.Index
+}
+
+// SubRange returns the sub-range at the next level which contains the asset with the key
+func (r AssetRange) SubRange(key uint32) AssetRange {
+	return AssetRange{Bucket: r.Bucket, Level: r.Level + 1, Index: key % assetRangeSize(r.Level+1)}
+}
+
+// assetRangeSize returns the number of the ranges at the level
+func assetRangeSize(level int) uint32 {
+	size := uint32(1)
+	for i := 0; i < level; i++ {
+		size *= AssetRangeFanout
+	}
+	return size
+}
+
+// SyncState represents the state of the asset view sync of a node
+type SyncState int
+
+// Constants defining various states of the asset view sync
+const (
+	// SyncStateConsistent the assets of the node match the asset view of the scheduler
+	SyncStateConsistent SyncState = iota
+	// SyncStateMismatch the buckets mismatched and the node synced them
+	SyncStateMismatch
+	// SyncStateFailed the sync failed
+	SyncStateFailed
+)
+
+// String returns the name of the sync state
+func (s SyncState) String() string {
+	switch s {
+	case SyncStateConsistent:
+		return "consistent"
+	case SyncStateMismatch:
+		return "mismatch"
+	case SyncStateFailed:
+		return "failed"
+	}
+
+	return "unknown"
+}
+
+// NodeSyncStatus represents the status of the last asset view sync of a node
+type NodeSyncStatus struct {
+	NodeID          string
+	LastSyncTime    time.Time
+	State           SyncState
+	MismatchBuckets int
+	Error           string
+}
+
 // AssetRecord represents information about an asset record
 type AssetRecord struct {
 	CID                   string          `db:"cid"`
@@ -44,6 +127,7 @@ type AssetRecord struct {
 	State                 string          `db:"state"`
 	NeedCandidateReplicas int64           `db:"candidate_replicas"`
 	ServerID              dtypes.ServerID `db:"scheduler_sid"`
+	NodeSelector          string          `db:"node_selector"`
 
 	ReplicaInfos []*ReplicaInfo
 	EdgeReplica  int64
@@ -67,6 +151,8 @@ type PullAssetReq struct {
 	Replicas   int64
 	ServerID   string
 	Expiration time.Time
+	// NodeSelector places the replicas on the nodes matching the label selector only, e.g. isp=ct
+	NodeSelector string
 }
 
 // ReplicaStatus represents the status of a replica pull
@@ -139,6 +225,16 @@ type InProgressAsset struct {
 	DoneSize  int64
 }
 
+// StorageChallenge is the challenge of a storage proof, the node proves it stores the random blocks of the asset
+type StorageChallenge struct {
+	AssetCID string
+	// Seed selects the random blocks as the validation does
+	Seed  int64
+	Count int
+	// Nonce is appended to the block data, so the hashes can not be computed in advance
+	Nonce []byte
+}
+
 // AssetHash is an identifier for a asset.
 type AssetHash string
 
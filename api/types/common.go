@@ -1,6 +1,8 @@
 package types
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
@@ -54,6 +56,168 @@ type NodeInfo struct {
 	LastSeen        time.Time       `db:"last_seen"`
 	IsQuitted       bool            `db:"quitted"`
 	SchedulerID     dtypes.ServerID `db:"scheduler_sid"`
+	QuitCount       int             `json:"quit_count" db:"quit_count"`
+	Reputation      float64         `json:"reputation" db:"reputation"` // reputation score, 0 ~ 100
+	State           NodeState       `json:"state" db:"state"`
+	// Labels are the operator-defined key/value metadata of the node, e.g. rack=sz-3
+	Labels map[string]string `json:"labels" db:"-"`
+	// Quarantined nodes stay online but receive no new replicas and serve no downloads
+	Quarantined bool `json:"quarantined" db:"-"`
+}
+
+// NodeState represents the service state of a node
+type NodeState int
+
+const (
+	// NodeStateNormal the node receives new replicas and validations
+	NodeStateNormal NodeState = iota
+	// NodeStateMaintenance the node receives no new replicas or validations, its replicas are kept
+	NodeStateMaintenance
+	// NodeStateDraining the node receives no new replicas or validations, its replicas are re-created on other nodes
+	NodeStateDraining
+)
+
+// String returns the name of the node state
+func (s NodeState) String() string {
+	switch s {
+	case NodeStateNormal:
+		return "normal"
+	case NodeStateMaintenance:
+		return "maintenance"
+	case NodeStateDraining:
+		return "draining"
+	}
+
+	return "unknown"
+}
+
+// NodeDrainStatus represents the progress of draining a node
+type NodeDrainStatus struct {
+	NodeID string
+	State  NodeState
+	// Assets is the number of the assets which have a replica on the node
+	Assets int
+	// PendingAssets is the number of the assets which do not have enough replicas on the other nodes
+	PendingAssets int
+	// Drained is true if every asset has enough replicas on the other nodes, the node can be unregistered safely
+	Drained bool
+	// StalledAssets are the pending assets which can not be replenished now, the key is the cid and the value is the reason,
+	// e.g. the asset is not in servicing, the drain does not finish until they are replenished
+	StalledAssets map[string]string
+}
+
+// NodeBlacklistType represents the type of a node blacklist entry
+type NodeBlacklistType int
+
+const (
+	// NodeBlacklistBan the node can not login or connect to the scheduler
+	NodeBlacklistBan NodeBlacklistType = iota
+	// NodeBlacklistQuarantine the node stays online but receives no new replicas and serves no downloads
+	NodeBlacklistQuarantine
+)
+
+// String returns the name of the node blacklist type
+func (t NodeBlacklistType) String() string {
+	switch t {
+	case NodeBlacklistBan:
+		return "ban"
+	case NodeBlacklistQuarantine:
+		return "quarantine"
+	}
+
+	return "unknown"
+}
+
+// NodeBlacklistEntry represents a ban or quarantine of a node, it takes no effect after the expiration
+type NodeBlacklistEntry struct {
+	NodeID      string            `db:"node_id"`
+	Type        NodeBlacklistType `db:"type"`
+	Reason      string            `db:"reason"`
+	Automatic   bool              `db:"automatic"` // added by the scheduler when the reputation or validation success rate is too low
+	Expiration  time.Time         `db:"expiration"`
+	CreatedTime time.Time         `db:"created_time"`
+}
+
+// LabelRequirement is a term of a label selector, the label of the key equals or not equals the value
+type LabelRequirement struct {
+	Key      string
+	Value    string
+	NotEqual bool
+}
+
+// LabelSelector selects the nodes whose labels match all the requirements
+type LabelSelector []LabelRequirement
+
+// ParseLabelSelector parses a label selector such as "rack=sz-3,isp!=ct", an empty string selects every node
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	selector := make(LabelSelector, 0)
+	if strings.TrimSpace(s) == "" {
+		return selector, nil
+	}
+
+	for _, term := range strings.Split(s, ",") {
+		r := LabelRequirement{}
+
+		sep := "="
+		if strings.Contains(term, "!=") {
+			sep = "!="
+			r.NotEqual = true
+		}
+
+		kv := strings.SplitN(term, sep, 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid label selector term %q", term)
+		}
+
+		r.Key = strings.TrimSpace(kv[0])
+		r.Value = strings.TrimSpace(kv[1])
+		if err := ValidateLabel(r.Key, r.Value); err != nil {
+			return nil, err
+		}
+
+		selector = append(selector, r)
+	}
+
+	return selector, nil
+}
+
+// Matches returns whether the labels match all the requirements of the selector, a missing label never equals the value
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		value, ok := labels[r.Key]
+		if (ok && value == r.Value) == r.NotEqual {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns the selector in the form parsed by ParseLabelSelector
+func (s LabelSelector) String() string {
+	terms := make([]string, 0, len(s))
+	for _, r := range s {
+		sep := "="
+		if r.NotEqual {
+			sep = "!="
+		}
+		terms = append(terms, r.Key+sep+r.Value)
+	}
+
+	return strings.Join(terms, ",")
+}
+
+// ValidateLabel checks the key and value of a label, they are not empty and contain none of the separators of a label selector
+func ValidateLabel(key, value string) error {
+	if key == "" || value == "" {
+		return fmt.Errorf("label key and value can not be empty")
+	}
+
+	if strings.ContainsAny(key, "=!, ") || strings.ContainsAny(value, "=!, ") {
+		return fmt.Errorf("invalid label %s=%s", key, value)
+	}
+
+	return nil
 }
 
 // NodeType node type
@@ -98,8 +262,65 @@ const (
 	EventNodeOnline EventTopics = "node_online"
 	// EventNodeOffline node offline event
 	EventNodeOffline EventTopics = "node_offline"
+	// EventAssetState asset state transition event
+	EventAssetState EventTopics = "asset_state"
+	// EventValidationResult validation result event
+	EventValidationResult EventTopics = "validation_result"
+	// EventReplicaChange replica added, updated or removed event
+	EventReplicaChange EventTopics = "replica_change"
 )
 
+// EventTopicsAll all topics which can be subscribed by SubscribeEvents
+var EventTopicsAll = []EventTopics{EventNodeOnline, EventNodeOffline, EventAssetState, EventValidationResult, EventReplicaChange}
+
 func (t EventTopics) String() string {
 	return string(t)
 }
+
+// Event is a scheduler event pushed to the subscribers
+type Event struct {
+	Topic    EventTopics
+	Time     time.Time
+	NodeID   string
+	AssetCID string
+	// State is the new asset state, the validation status or the replica status, depending on the topic
+	State   string
+	Message string
+}
+
+// EventFilter filters the subscribed events, an empty field matches everything
+type EventFilter struct {
+	Topics    []EventTopics
+	NodeIDs   []string
+	AssetCIDs []string
+}
+
+// Matches returns whether the event passes the filter
+func (f *EventFilter) Matches(e *Event) bool {
+	return matchString(string(e.Topic), eventTopicStrings(f.Topics)) &&
+		matchString(e.NodeID, f.NodeIDs) &&
+		matchString(e.AssetCID, f.AssetCIDs)
+}
+
+// matchString returns whether the value is in the list, an empty list matches everything
+func matchString(value string, list []string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func eventTopicStrings(topics []EventTopics) []string {
+	out := make([]string, 0, len(topics))
+	for _, t := range topics {
+		out = append(out, t.String())
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		expect   LabelSelector
+		err      bool
+	}{
+		{selector: "", expect: LabelSelector{}},
+		{selector: "  ", expect: LabelSelector{}},
+		{selector: "rack=sz-3", expect: LabelSelector{{Key: "rack", Value: "sz-3"}}},
+		{selector: "rack=sz-3, isp!=ct", expect: LabelSelector{{Key: "rack", Value: "sz-3"}, {Key: "isp", Value: "ct", NotEqual: true}}},
+		{selector: "rack", err: true},
+		{selector: "rack=", err: true},
+		{selector: "=sz-3", err: true},
+		{selector: "rack==sz-3", err: true},
+		{selector: "rack=sz-3,", err: true},
+	}
+
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector(tt.selector)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expect an error", tt.selector)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: %v", tt.selector, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(selector, tt.expect) {
+			t.Errorf("%q: expect %v, got %v", tt.selector, tt.expect, selector)
+		}
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"rack": "sz-3", "isp": "cm"}
+
+	tests := []struct {
+		selector string
+		expect   bool
+	}{
+		{selector: "", expect: true},
+		{selector: "rack=sz-3", expect: true},
+		{selector: "rack=sz-4", expect: false},
+		{selector: "isp!=ct", expect: true},
+		{selector: "isp!=cm", expect: false},
+		{selector: "rack=sz-3,isp!=ct", expect: true},
+		{selector: "rack=sz-3,isp!=cm", expect: false},
+		// a missing label never equals the value
+		{selector: "zone=a", expect: false},
+		{selector: "zone!=a", expect: true},
+	}
+
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := selector.Matches(labels); got != tt.expect {
+			t.Errorf("%q: expect %v, got %v", tt.selector, tt.expect, got)
+		}
+
+		if got := selector.String(); got != tt.selector {
+			t.Errorf("expect selector string %q, got %q", tt.selector, got)
+		}
+	}
+}
@@ -6,12 +6,14 @@ import (
 
 // DownloadHistory represents the record of a node download
 type DownloadHistory struct {
-	ID           string    `json:"-"`
+	ID           string    `json:"id" db:"id"`
+	CredentialID string    `json:"credential_id" db:"credential_id"`
 	NodeID       string    `json:"node_id" db:"node_id"`
 	BlockCID     string    `json:"block_cid" db:"block_cid"`
 	AssetCID     string    `json:"asset_cid" db:"asset_cid"`
-	BlockSize    int       `json:"block_size" db:"block_size"`
-	Speed        int64     `json:"speed" db:"speed"`
+	BlockSize    int       `json:"block_size" db:"block_size"` // bytes of the response
+	Speed        int64     `json:"speed" db:"speed"`           // bytes per second
+	Duration     int64     `json:"duration" db:"duration"`     // milliseconds
 	Reward       int64     `json:"reward" db:"reward"`
 	Status       int       `json:"status" db:"status"`
 	FailedReason string    `json:"failed_reason" db:"failed_reason"`
@@ -20,6 +22,26 @@ type DownloadHistory struct {
 	CompleteTime time.Time `json:"complete_time" db:"complete_time"`
 }
 
+// Download status of DownloadHistory
+const (
+	DownloadStatusSucceeded = iota
+	DownloadStatusFailed
+)
+
+// ListDownloadRecordsReq represents a request to list the download records
+type ListDownloadRecordsReq struct {
+	// Optional, filter by node
+	NodeID string `json:"node_id"`
+	// Optional, filter by asset
+	AssetCID string `json:"asset_cid"`
+	// Unix timestamp
+	StartTime int64 `json:"start_time"`
+	// Unix timestamp
+	EndTime int64 `json:"end_time"`
+	Cursor  int   `json:"cursor"`
+	Count   int   `json:"count"`
+}
+
 // EdgeDownloadInfo represents download information for an edge node
 type EdgeDownloadInfo struct {
 	URL         string
@@ -123,6 +145,57 @@ type ListValidationResultRsp struct {
 	ValidationResultInfos []ValidationResultInfo `json:"validation_result_infos"`
 }
 
+// ListValidationResultsReq represents a request to list validation results
+type ListValidationResultsReq struct {
+	// Optional, filter by node
+	NodeID string `json:"node_id"`
+	// Optional, filter by validator
+	ValidatorID string `json:"validator_id"`
+	// Optional, filter by round
+	RoundID string `json:"round_id"`
+	// Optional, filter by status
+	Statuses []ValidationStatus `json:"statuses"`
+	// Unix timestamp, optional
+	StartTime int64 `json:"start_time"`
+	// Unix timestamp, optional
+	EndTime int64 `json:"end_time"`
+	Cursor  int   `json:"cursor"`
+	Count   int   `json:"count"`
+}
+
+// ValidationSummaryReq represents a request to summarize the validations of the nodes
+type ValidationSummaryReq struct {
+	// Optional, summarize a node
+	NodeID string `json:"node_id"`
+	// Unix timestamp
+	StartTime int64 `json:"start_time"`
+	// Unix timestamp, the current time if it is 0
+	EndTime int64 `json:"end_time"`
+	Cursor  int   `json:"cursor"`
+	Count   int   `json:"count"`
+}
+
+// ValidationSummary is the validation statistics of a node in a time window
+type ValidationSummary struct {
+	NodeID string `json:"node_id" db:"node_id"`
+	// Total number of the validations, including the ones which are not attributed to the node
+	Total     int `json:"total" db:"total"`
+	Succeeded int `json:"succeeded" db:"succeeded"`
+	Failed    int `json:"failed" db:"failed"`
+	Timeouts  int `json:"timeouts" db:"timeouts"`
+	// SuccessRatio is succeeded / (succeeded + failed + timeouts)
+	SuccessRatio float64 `json:"success_ratio" db:"success_ratio"`
+	// Bandwidth is the average bandwidth of the succeeded bandwidth validations
+	Bandwidth      float64    `json:"bandwidth" db:"bandwidth"`
+	LastFailedTime *time.Time `json:"last_failed_time" db:"last_failed_time"`
+}
+
+// ListValidationSummaryRsp represents a list of validation summaries
+type ListValidationSummaryRsp struct {
+	Total     int                  `json:"total"`
+	Summaries []*ValidationSummary `json:"summaries"`
+}
+
 // ValidationResultInfo validator result info
 type ValidationResultInfo struct {
 	RoundID     string           `db:"round_id"`
@@ -135,10 +208,113 @@ type ValidationResultInfo struct {
 	Bandwidth   float64          `db:"bandwidth"`
 	StartTime   time.Time        `db:"start_time"`
 	EndTime     time.Time        `db:"end_time"`
+	BeaconRound uint64           `db:"beacon_round"` // round of the randomness beacon which the seed is derived from
+	CandidateID string           `db:"candidate_id"` // candidate which provides the reference blocks
+	Type        ValidationType   `db:"validation_type"`
 
 	UploadTraffic float64 `db:"upload_traffic"`
 }
 
+// ValidationChallenge is the public challenge of a validation, it can be re-derived from the randomness beacon
+type ValidationChallenge struct {
+	RoundID     string
+	NodeID      string
+	ServerID    string
+	BeaconRound uint64
+	// Randomness is the hex randomness of the beacon round
+	Randomness string
+	Seed       int64
+	Cid        string
+	// BlockNumber is the number of blocks the node sent
+	BlockNumber int64
+	// ExpectedCIDs is the blocks the node is expected to send, key is the index of the block
+	ExpectedCIDs map[int]string
+}
+
+// ValidationRoundInfo is the state of a validation round, it is persisted to resume the round after the scheduler restarts
+type ValidationRoundInfo struct {
+	RoundID     string `db:"round_id"`
+	SchedulerID string `db:"scheduler_sid"`
+	Seed        int64  `db:"seed"`
+	BeaconRound uint64 `db:"beacon_round"`
+	// Pairings is the json of the validatable nodes of each validator, map[validator][]node
+	Pairings  string    `db:"pairings"`
+	StartTime time.Time `db:"start_time"`
+	// Ended the round is finished, the results which are not received are timed out
+	Ended bool `db:"ended"`
+}
+
+// ValidatorInfo represents a validator elected by the scheduler
+type ValidatorInfo struct {
+	NodeID      string    `db:"node_id"`
+	ElectedTime time.Time `db:"elected_time"`
+}
+
+// ElectionCandidate is a candidate node in an election, with the inputs of its weight
+type ElectionCandidate struct {
+	NodeID        string
+	BandwidthDown float64
+	Reputation    float64
+	Latitude      float64
+	Longitude     float64
+	// Weight is the weight before the geographic spread is applied
+	Weight float64
+	// Retained the validator is retained because its tenure is shorter than the minimum
+	Retained bool
+}
+
+// ElectionRecord is the audit record of a validator election
+type ElectionRecord struct {
+	ID          int64  `db:"id"`
+	SchedulerID string `db:"scheduler_sid"`
+	Reason      string `db:"reason"`
+	Seed        int64  `db:"seed"`
+	// Candidates is the json of []*ElectionCandidate
+	Candidates string `db:"candidates"`
+	// Validators is the json of the elected node ids
+	Validators  string    `db:"validators"`
+	CreatedTime time.Time `db:"created_time"`
+}
+
+// ListElectionRecordsRsp represents a list of election records
+type ListElectionRecordsRsp struct {
+	Total   int               `json:"total"`
+	Records []*ElectionRecord `json:"records"`
+}
+
+// ReputationFactors are the factors of the node reputation
+type ReputationFactors struct {
+	NodeID             string  `db:"node_id"`
+	OnlineDuration     int     `db:"online_duration"`     // minutes
+	RegisteredDuration int     `db:"registered_duration"` // minutes since the node registered
+	QuitCount          int     `db:"quit_count"`
+	BandwidthUp        float64 `db:"bandwidth_up"` // declared upload bandwidth
+	// validations in the reputation window
+	Validations int64   `db:"validations"`
+	Succeeded   int64   `db:"succeeded"`
+	Timeouts    int64   `db:"timeouts"`
+	Bandwidth   float64 `db:"bandwidth"` // average measured bandwidth of the succeeded validations
+}
+
+// NodeStats is a resource usage report of a node, or the average of the reports in a time step of the history
+type NodeStats struct {
+	NodeID        string    `db:"node_id"`
+	Time          time.Time `db:"time"`
+	CPUUsage      float64   `db:"cpu_usage"`      // percent
+	MemoryUsage   float64   `db:"memory_usage"`   // percent
+	DiskUsage     float64   `db:"disk_usage"`     // percent
+	BandwidthUp   float64   `db:"bandwidth_up"`   // measured upload rate, bytes per second
+	BandwidthDown float64   `db:"bandwidth_down"` // measured download rate, bytes per second
+}
+
+// NodeNATRecord is a NAT type detected for a node, a record is added when the NAT type or the external ip changes
+type NodeNATRecord struct {
+	NodeID       string    `db:"node_id"`
+	NATType      string    `db:"nat_type"`
+	ExternalAddr string    `db:"external_addr"` // the external ip of the node
+	CreatedTime  time.Time `db:"created_time"`
+}
+
 // ValidationStatus Validation Status
 type ValidationStatus int
 
@@ -172,16 +348,73 @@ const (
 	ValidationStatusLoadDBErr
 	// ValidationStatusCIDToHashErr is the validation status when there is an error converting a CID to a hash.
 	ValidationStatusCIDToHashErr
+
+	// Candidate error
+
+	// ValidationStatusCandidateBlockErr is the validation status when the reference blocks of the candidate are wrong.
+	ValidationStatusCandidateBlockErr
+)
+
+// String status to string
+func (v ValidationStatus) String() string {
+	switch v {
+	case ValidationStatusCreate:
+		return "Create"
+	case ValidationStatusSuccess:
+		return "Success"
+	case ValidationStatusCancel:
+		return "Cancel"
+	case ValidationStatusNodeTimeOut:
+		return "NodeTimeOut"
+	case ValidationStatusValidateFail:
+		return "ValidateFail"
+	case ValidationStatusValidatorTimeOut:
+		return "ValidatorTimeOut"
+	case ValidationStatusGetValidatorBlockErr:
+		return "GetValidatorBlockErr"
+	case ValidationStatusValidatorMismatch:
+		return "ValidatorMismatch"
+	case ValidationStatusLoadDBErr:
+		return "LoadDBErr"
+	case ValidationStatusCIDToHashErr:
+		return "CIDToHashErr"
+	case ValidationStatusCandidateBlockErr:
+		return "CandidateBlockErr"
+	}
+
+	return "Unknown"
+}
+
+// ValidationType is the type of the validation
+type ValidationType int
+
+const (
+	// ValidationTypeBandwidth the node sends random blocks to the validator, it measures the bandwidth and proves the storage
+	ValidationTypeBandwidth ValidationType = iota
+	// ValidationTypeStorage the node returns the hashes of random blocks with a nonce, it proves the storage only
+	ValidationTypeStorage
 )
 
+// String type to string
+func (v ValidationType) String() string {
+	switch v {
+	case ValidationTypeBandwidth:
+		return "Bandwidth"
+	case ValidationTypeStorage:
+		return "Storage"
+	}
+
+	return "Unknown"
+}
+
 // Credentials gateway access credentials
 type Credentials struct {
-	ID        string
-	NodeID    string
-	AssetCID  string
-	ClientID  string
-	LimitRate int64
-	ValidTime int64
+	ID        string `db:"id"`
+	NodeID    string `db:"node_id"`
+	AssetCID  string `db:"asset_cid"`
+	ClientID  string `db:"client_id"`
+	LimitRate int64  `db:"limit_rate"`
+	ValidTime int64  `db:"valid_time"` // Unix timestamp
 }
 
 // GatewayCredentials be use for access gateway
@@ -192,13 +425,72 @@ type GatewayCredentials struct {
 	Sign string
 }
 
+// UserProofOfWork the proof of a user download, submitted by the user
 type UserProofOfWork struct {
-	TicketID      string
+	TicketID      string // ID of the credentials
 	ClientID      string
-	DownloadSpeed int64
-	DownloadSize  int64
-	StartTime     int64
-	EndTime       int64
+	NodeID        string // Optional, the node which served the download
+	AssetCID      string // Optional, the downloaded asset
+	DownloadSpeed int64  // bytes per second
+	DownloadSize  int64  // bytes
+	StartTime     int64  // Unix timestamp in milliseconds
+	EndTime       int64  // Unix timestamp in milliseconds
+}
+
+// ProofOfWorkStatus represents the status of a user proof of work
+type ProofOfWorkStatus int
+
+const (
+	// ProofOfWorkStatusUnconfirmed the proof is not covered by the traffic reported by the node yet
+	ProofOfWorkStatusUnconfirmed ProofOfWorkStatus = iota
+	// ProofOfWorkStatusConfirmed the proof is covered by the traffic reported by the node
+	ProofOfWorkStatusConfirmed
+)
+
+// ProofOfWorkInfo represents an accepted user proof of work
+type ProofOfWorkInfo struct {
+	ID            int64             `db:"id"`
+	TicketID      string            `db:"ticket_id"`
+	ClientID      string            `db:"client_id"`
+	NodeID        string            `db:"node_id"`
+	AssetCID      string            `db:"asset_cid"`
+	DownloadSpeed int64             `db:"download_speed"`
+	DownloadSize  int64             `db:"download_size"`
+	StartTime     time.Time         `db:"start_time"`
+	EndTime       time.Time         `db:"end_time"`
+	Status        ProofOfWorkStatus `db:"status"`
+	CreatedTime   time.Time         `db:"created_time"`
+}
+
+// NodeServedTraffic represents the traffic served by a node
+type NodeServedTraffic struct {
+	NodeID string `db:"node_id"`
+	// number of the confirmed proofs
+	ProofCount int64 `db:"proof_count"`
+	// bytes of the confirmed proofs
+	ServedSize int64 `db:"served_size"`
+	// average download speed of the confirmed proofs, bytes per second
+	AvgSpeed int64 `db:"avg_speed"`
+	// bytes of the succeeded downloads reported by the node
+	ReportedSize int64 `db:"reported_size"`
+}
+
+// ListServedTrafficReq represents a request to list the traffic served by nodes
+type ListServedTrafficReq struct {
+	// Optional, filter by node
+	NodeID string `json:"node_id"`
+	// Unix timestamp
+	StartTime int64 `json:"start_time"`
+	// Unix timestamp
+	EndTime int64 `json:"end_time"`
+	Cursor  int   `json:"cursor"`
+	Count   int   `json:"count"`
+}
+
+// ListServedTrafficRsp represents a response containing the traffic served by nodes
+type ListServedTrafficRsp struct {
+	Data  []*NodeServedTraffic `json:"data"`
+	Total int64                `json:"total"`
 }
 
 type NatPunchReq struct {
@@ -206,9 +498,69 @@ type NatPunchReq struct {
 	NodeID      string
 	// seconds
 	Timeout int
+	// UserAddr is the UDP address of the user observed by the NAT probe service, it is required by StartNatPunch,
+	// the user sends the punch packets from the socket the address is observed from
+	UserAddr string
+}
+
+// NatPunchSession is the rendezvous of a hole punch between a user and an edge, both parties start to send
+// the punch packets to the observed address of each other at the start time
+type NatPunchSession struct {
+	ID          string
+	UserAddr    string // the edge sends to the observed address of the user
+	EdgeAddr    string // the user sends to the address the NAT of the edge maps its UDP service socket to
+	EdgeNATType string
+	StartTime   time.Time
+	Interval    time.Duration // interval of the punch packets
+	Attempts    int           // number of the punch packets
+}
+
+// NatPunchStatus represents the status of a hole punch
+type NatPunchStatus int
+
+const (
+	// NatPunchPending the hole punch is in progress
+	NatPunchPending NatPunchStatus = iota
+	// NatPunchSucceeded the edge reaches the user through the punched hole
+	NatPunchSucceeded
+	// NatPunchFailed the edge can not reach the user
+	NatPunchFailed
+)
+
+func (s NatPunchStatus) String() string {
+	switch s {
+	case NatPunchPending:
+		return "Pending"
+	case NatPunchSucceeded:
+		return "Succeeded"
+	case NatPunchFailed:
+		return "Failed"
+	}
+
+	return "Unknown"
+}
+
+// NatPunchResult is the result of a hole punch
+type NatPunchResult struct {
+	SessionID string
+	Status    NatPunchStatus
+	// PunchedAddr is the address of the edge the user connects to, it is empty if the hole punch fails
+	PunchedAddr string
+	Error       string
+}
+
+// NatPunchStats is the hole punch statistics of the edges with the NAT type
+type NatPunchStats struct {
+	NATType   string `db:"nat_type"`
+	Attempts  int64  `db:"attempts"`
+	Successes int64  `db:"successes"`
 }
 
 type ConnectOptions struct {
 	Token         string
 	TcpServerPort int
+	// TcpServerTLS the tcp server of the candidate is wrapped with tls
+	TcpServerTLS bool
+	// TcpServerCertHash the fingerprint of the tls certificate of the tcp server
+	TcpServerCertHash string
 }
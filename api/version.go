@@ -74,4 +74,6 @@ type EdgeUpdateConfig struct {
 	DownloadURL string    `db:"download_url"`
 	Hash        string    `db:"hash"`
 	UpdateTime  time.Time `db:"update_time"`
+	// NodeSelector rolls out the update to the nodes matching the label selector only, every node is updated if it is empty
+	NodeSelector string `db:"node_selector"`
 }
@@ -159,6 +159,7 @@ var pullAssetCmd = &cli.Command{
 		cidFlag,
 		replicaCountFlag,
 		expirationDateFlag,
+		nodeSelectorFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		cid := cctx.String("cid")
@@ -176,7 +177,7 @@ var pullAssetCmd = &cli.Command{
 			return xerrors.New("cid is nil")
 		}
 
-		info := &types.PullAssetReq{CID: cid}
+		info := &types.PullAssetReq{CID: cid, NodeSelector: cctx.String("node-selector")}
 
 		if date == "" {
 			date = time.Now().Add(defaultExpiration).Format(defaultDateTimeLayout)
@@ -3,6 +3,9 @@ package cli
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/docker/go-units"
@@ -20,6 +23,21 @@ var nodeCmd = &cli.Command{
 		nodeQuitCmd,
 		setNodePortCmd,
 		edgeExternalAddrCmd,
+		syncNodeAssetViewCmd,
+		nodeSyncStatusCmd,
+		drainNodeCmd,
+		nodeDrainStatusCmd,
+		nodeMaintenanceCmd,
+		listNodesCmd,
+		nodeLabelCmd,
+		banNodeCmd,
+		unbanNodeCmd,
+		quarantineNodeCmd,
+		unquarantineNodeCmd,
+		nodeBlacklistCmd,
+		nodeStatsCmd,
+		nodeNATHistoryCmd,
+		natPunchStatsCmd,
 	},
 }
 
@@ -131,6 +149,9 @@ var showNodeInfoCmd = &cli.Command{
 		//
 		fmt.Printf("DownloadCount: %d \n", info.DownloadBlocks)
 		fmt.Printf("NatType: %s \n", natType.String())
+		fmt.Printf("State: %s \n", info.State.String())
+		fmt.Printf("Labels: %s \n", formatLabels(info.Labels))
+		fmt.Printf("Quarantined: %v \n", info.Quarantined)
 
 		return nil
 	},
@@ -200,3 +221,571 @@ var edgeExternalAddrCmd = &cli.Command{
 		return nil
 	},
 }
+
+var syncNodeAssetViewCmd = &cli.Command{
+	Name:      "sync",
+	Usage:     "Sync the assets of the node with its asset view",
+	ArgsUsage: "<node-id>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only show the extra and lost assets, the node and the replicas are not changed",
+			Value: false,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+		nodeID := cctx.Args().First()
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		result, err := schedulerAPI.SyncNodeAssetView(ctx, nodeID, cctx.Bool("dry-run"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("mismatch buckets: %d\n", result.MismatchBuckets)
+		fmt.Printf("extra assets: %d\n", len(result.ExtraAssets))
+		for _, c := range result.ExtraAssets {
+			fmt.Printf("\t%s\n", c)
+		}
+		fmt.Printf("lost assets: %d\n", len(result.LostAssets))
+		for _, c := range result.LostAssets {
+			fmt.Printf("\t%s\n", c)
+		}
+
+		return nil
+	},
+}
+
+var nodeSyncStatusCmd = &cli.Command{
+	Name:      "sync-status",
+	Usage:     "Show the status of the last asset view sync of the node",
+	ArgsUsage: "<node-id>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+		nodeID := cctx.Args().First()
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		status, err := schedulerAPI.GetAssetViewSyncStatus(ctx, nodeID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("node id: %s\n", status.NodeID)
+		fmt.Printf("last sync time: %s\n", status.LastSyncTime.Format(defaultDateTimeLayout))
+		fmt.Printf("state: %s\n", status.State.String())
+		fmt.Printf("mismatch buckets: %d\n", status.MismatchBuckets)
+		if status.Error != "" {
+			fmt.Printf("error: %s\n", status.Error)
+		}
+
+		return nil
+	},
+}
+
+var drainNodeCmd = &cli.Command{
+	Name:  "drain",
+	Usage: "Drain the node, its replicas are re-created on the other nodes",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.DrainNode(ctx, nodeID)
+	},
+}
+
+var nodeDrainStatusCmd = &cli.Command{
+	Name:  "drain-status",
+	Usage: "Show the drain progress of the node",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		status, err := schedulerAPI.GetNodeDrainStatus(ctx, nodeID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("node id: %s\n", status.NodeID)
+		fmt.Printf("state: %s\n", status.State.String())
+		fmt.Printf("assets: %d\n", status.Assets)
+		fmt.Printf("pending assets: %d\n", status.PendingAssets)
+		fmt.Printf("drained: %v\n", status.Drained)
+		for cid, reason := range status.StalledAssets {
+			fmt.Printf("stalled asset: %s, %s\n", cid, reason)
+		}
+
+		return nil
+	},
+}
+
+var nodeMaintenanceCmd = &cli.Command{
+	Name:  "maintenance",
+	Usage: "Put the node in or out of maintenance",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		&cli.BoolFlag{
+			Name:  "disable",
+			Usage: "take the node out of maintenance",
+			Value: false,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.SetNodeMaintenance(ctx, nodeID, !cctx.Bool("disable"))
+	},
+}
+
+var listNodesCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the nodes matching the label selector",
+	Flags: []cli.Flag{
+		limitFlag,
+		offsetFlag,
+		nodeSelectorFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		rsp, err := schedulerAPI.GetNodeListBySelector(ctx, cctx.Int("offset"), cctx.Int("limit"), cctx.String("node-selector"))
+		if err != nil {
+			return err
+		}
+
+		for _, info := range rsp.Data {
+			fmt.Printf("%s\t%s\t%s\t%s\n", info.NodeID, info.Type.String(), info.State.String(), formatLabels(info.Labels))
+		}
+		fmt.Printf("total: %d\n", rsp.Total)
+
+		return nil
+	},
+}
+
+var nodeLabelCmd = &cli.Command{
+	Name:  "label",
+	Usage: "Manage the labels of the node",
+	Subcommands: []*cli.Command{
+		setNodeLabelsCmd,
+		removeNodeLabelsCmd,
+		listNodeLabelsCmd,
+	},
+}
+
+var setNodeLabelsCmd = &cli.Command{
+	Name:      "set",
+	Usage:     "Add or update the labels of the node",
+	ArgsUsage: "<key=value>...",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		if cctx.NArg() < 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		labels := make(map[string]string)
+		for _, arg := range cctx.Args().Slice() {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) != 2 {
+				return xerrors.Errorf("invalid label %s, the format is key=value", arg)
+			}
+			labels[kv[0]] = kv[1]
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.SetNodeLabels(ctx, nodeID, labels)
+	},
+}
+
+var removeNodeLabelsCmd = &cli.Command{
+	Name:      "rm",
+	Usage:     "Remove the labels of the node",
+	ArgsUsage: "<key>...",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		if cctx.NArg() < 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.RemoveNodeLabels(ctx, nodeID, cctx.Args().Slice())
+	},
+}
+
+var listNodeLabelsCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the labels of the node",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		labels, err := schedulerAPI.GetNodeLabels(ctx, nodeID)
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Printf("%s=%s\n", key, labels[key])
+		}
+
+		return nil
+	},
+}
+
+// formatLabels formats the labels as key=value pairs sorted by the key
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+var banNodeCmd = &cli.Command{
+	Name:  "ban",
+	Usage: "Ban the node, it is disconnected and can not login again until the ban expires",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		&cli.StringFlag{
+			Name:  "reason",
+			Usage: "the reason of the ban",
+			Value: "",
+		},
+		&cli.DurationFlag{
+			Name:  "duration",
+			Usage: "the duration of the ban",
+			Value: 30 * 24 * time.Hour,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.BanNode(ctx, nodeID, cctx.String("reason"), time.Now().Add(cctx.Duration("duration")))
+	},
+}
+
+var unbanNodeCmd = &cli.Command{
+	Name:  "unban",
+	Usage: "Remove the ban of the node",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.UnbanNode(ctx, nodeID)
+	},
+}
+
+var quarantineNodeCmd = &cli.Command{
+	Name:  "quarantine",
+	Usage: "Quarantine the node, it stays online but receives no new replicas and serves no downloads",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		&cli.StringFlag{
+			Name:  "reason",
+			Usage: "the reason of the quarantine",
+			Value: "",
+		},
+		&cli.DurationFlag{
+			Name:  "duration",
+			Usage: "the duration of the quarantine",
+			Value: 24 * time.Hour,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.QuarantineNode(ctx, nodeID, cctx.String("reason"), time.Now().Add(cctx.Duration("duration")))
+	},
+}
+
+var unquarantineNodeCmd = &cli.Command{
+	Name:  "unquarantine",
+	Usage: "Remove the quarantine of the node",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return schedulerAPI.LiftNodeQuarantine(ctx, nodeID)
+	},
+}
+
+var nodeBlacklistCmd = &cli.Command{
+	Name:  "blacklist",
+	Usage: "List the banned and quarantined nodes",
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		entries, err := schedulerAPI.GetNodeBlacklist(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			auto := ""
+			if entry.Automatic {
+				auto = " (automatic)"
+			}
+			fmt.Printf("%s\t%s%s\tuntil %s\t%s\n", entry.NodeID, entry.Type.String(), auto, entry.Expiration.Format(defaultDateTimeLayout), entry.Reason)
+		}
+
+		return nil
+	},
+}
+
+var nodeStatsCmd = &cli.Command{
+	Name:  "stats",
+	Usage: "Show the resource usage history of the node",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		&cli.DurationFlag{
+			Name:  "duration",
+			Usage: "the duration of the history until now",
+			Value: 24 * time.Hour,
+		},
+		&cli.DurationFlag{
+			Name:  "step",
+			Usage: "the usage is averaged in every step",
+			Value: time.Hour,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		to := time.Now()
+		from := to.Add(-cctx.Duration("duration"))
+		list, err := schedulerAPI.GetNodeStatsHistory(ctx, nodeID, from, to, cctx.Duration("step"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("time\t\t\tcpu\tmemory\tdisk\tupload\tdownload\n")
+		for _, stats := range list {
+			fmt.Printf("%s\t%.2f%%\t%.2f%%\t%.2f%%\t%s/s\t%s/s\n", stats.Time.Format(defaultDateTimeLayout), stats.CPUUsage, stats.MemoryUsage,
+				stats.DiskUsage, units.BytesSize(stats.BandwidthUp), units.BytesSize(stats.BandwidthDown))
+		}
+
+		return nil
+	},
+}
+
+var nodeNATHistoryCmd = &cli.Command{
+	Name:  "nat-history",
+	Usage: "Show the NAT types detected for the node",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		limitFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		list, err := schedulerAPI.GetNodeNATHistory(ctx, nodeID, cctx.Int("limit"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("time\t\t\tnat type\t\taddress\n")
+		for _, record := range list {
+			fmt.Printf("%s\t%-16s\t%s\n", record.CreatedTime.Format(defaultDateTimeLayout), record.NATType, record.ExternalAddr)
+		}
+
+		return nil
+	},
+}
+
+var natPunchStatsCmd = &cli.Command{
+	Name:  "punch-stats",
+	Usage: "Show the hole punch success rates of the NAT types",
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		list, err := schedulerAPI.GetNatPunchStats(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("nat type		attempts	successes	rate\n")
+		for _, stats := range list {
+			rate := float64(0)
+			if stats.Attempts > 0 {
+				rate = float64(stats.Successes) / float64(stats.Attempts) * 100
+			}
+			fmt.Printf("%-16s\t%d\t\t%d\t\t%.2f%%\n", stats.NATType, stats.Attempts, stats.Successes, rate)
+		}
+
+		return nil
+	},
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/Filecoin-Titan/titan/api"
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/urfave/cli/v2"
 )
 
@@ -13,7 +14,9 @@ import (
 var SchedulerCMDs = []*cli.Command{
 	WithCategory("node", nodeCmd),
 	WithCategory("asset", assetCmd),
+	WithCategory("validation", validationCmd),
 	startElectionCmd,
+	eventsCmd,
 	// other
 	edgeUpdaterCmd,
 }
@@ -76,6 +79,12 @@ var (
 		Usage: "port",
 		Value: "",
 	}
+
+	nodeSelectorFlag = &cli.StringFlag{
+		Name:  "node-selector",
+		Usage: "select the nodes by labels, e.g. 'rack=sz-3,isp!=ct'",
+		Value: "",
+	}
 )
 
 var setNodePortCmd = &cli.Command{
@@ -105,6 +114,54 @@ var setNodePortCmd = &cli.Command{
 	},
 }
 
+var eventsCmd = &cli.Command{
+	Name:  "events",
+	Usage: "Watch the node, asset, validation and replica events",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "topic",
+			Usage: "only watch the topic, node_online node_offline asset_state validation_result replica_change",
+		},
+		&cli.StringSliceFlag{
+			Name:  "node-id",
+			Usage: "only watch the events of the node",
+		},
+		&cli.StringSliceFlag{
+			Name:  "cid",
+			Usage: "only watch the events of the asset",
+		},
+	},
+
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		filter := types.EventFilter{
+			NodeIDs:   cctx.StringSlice("node-id"),
+			AssetCIDs: cctx.StringSlice("cid"),
+		}
+		for _, topic := range cctx.StringSlice("topic") {
+			filter.Topics = append(filter.Topics, types.EventTopics(topic))
+		}
+
+		events, err := schedulerAPI.SubscribeEvents(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for event := range events {
+			fmt.Printf("%s %-17s node:%s cid:%s state:%s %s\n", event.Time.Format(defaultDateTimeLayout), event.Topic, event.NodeID, event.AssetCID, event.State, event.Message)
+		}
+
+		return nil
+	},
+}
+
 var startElectionCmd = &cli.Command{
 	Name:  "start-election",
 	Usage: "Start election validator",
@@ -190,6 +247,7 @@ var edgeUpdateInfoCmd = &cli.Command{
 			fmt.Printf("Hash:%s\n", updateInfo.Hash)
 			fmt.Printf("Version:%s\n", updateInfo.Version)
 			fmt.Printf("DownloadURL:%s\n", updateInfo.DownloadURL)
+			fmt.Printf("NodeSelector:%s\n", updateInfo.NodeSelector)
 			fmt.Println()
 		}
 
@@ -226,6 +284,7 @@ var setEdgeUpdateInfoCmd = &cli.Command{
 			Usage: "node type: 1 is edge, 6 is update",
 			Value: 1,
 		},
+		nodeSelectorFlag,
 	},
 
 	Action: func(cctx *cli.Context) error {
@@ -247,7 +306,7 @@ var setEdgeUpdateInfoCmd = &cli.Command{
 			return err
 		}
 
-		updateInfo := &api.EdgeUpdateConfig{AppName: appName, NodeType: nodeType, Version: version, Hash: hash, DownloadURL: downloadURL}
+		updateInfo := &api.EdgeUpdateConfig{AppName: appName, NodeType: nodeType, Version: version, Hash: hash, DownloadURL: downloadURL, NodeSelector: cctx.String("node-selector")}
 		err = schedulerAPI.SetEdgeUpdateConfig(ctx, updateInfo)
 		if err != nil {
 			return err
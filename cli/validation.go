@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/lib/tablewriter"
+	"github.com/docker/go-units"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var validationCmd = &cli.Command{
+	Name:  "validation",
+	Usage: "Query validation results",
+	Subcommands: []*cli.Command{
+		listValidationResultsCmd,
+		showValidationResultCmd,
+		validationSummaryCmd,
+		validateNodeCmd,
+	},
+}
+
+var (
+	roundIDFlag = &cli.StringFlag{
+		Name:  "round-id",
+		Usage: "validation round id",
+		Value: "",
+	}
+
+	validatorIDFlag = &cli.StringFlag{
+		Name:  "validator-id",
+		Usage: "validator node id",
+		Value: "",
+	}
+)
+
+var listValidationResultsCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List validation results",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		validatorIDFlag,
+		roundIDFlag,
+		&cli.IntSliceFlag{
+			Name:  "status",
+			Usage: "filter by status, 0:Create 1:Success 2:Cancel 3:NodeTimeOut 4:ValidateFail 5:ValidatorTimeOut 6:GetValidatorBlockErr 7:ValidatorMismatch 8:LoadDBErr 9:CIDToHashErr 10:CandidateBlockErr",
+		},
+		&cli.StringFlag{
+			Name:  "start-time",
+			Usage: "start time (2006-01-02 15:04:05)",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "end-time",
+			Usage: "end time (2006-01-02 15:04:05)",
+			Value: "",
+		},
+		limitFlag,
+		offsetFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		req := types.ListValidationResultsReq{
+			NodeID:      cctx.String("node-id"),
+			ValidatorID: cctx.String("validator-id"),
+			RoundID:     cctx.String("round-id"),
+			Cursor:      cctx.Int("offset"),
+			Count:       cctx.Int("limit"),
+		}
+
+		for _, status := range cctx.IntSlice("status") {
+			req.Statuses = append(req.Statuses, types.ValidationStatus(status))
+		}
+
+		if req.StartTime, err = parseUnixTime(cctx.String("start-time")); err != nil {
+			return err
+		}
+
+		if req.EndTime, err = parseUnixTime(cctx.String("end-time")); err != nil {
+			return err
+		}
+
+		rsp, err := schedulerAPI.ListValidationResults(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("RoundID"),
+			tablewriter.Col("NodeID"),
+			tablewriter.Col("ValidatorID"),
+			tablewriter.Col("Type"),
+			tablewriter.Col("Status"),
+			tablewriter.Col("Blocks"),
+			tablewriter.Col("Bandwidth"),
+			tablewriter.Col("StartTime"),
+		)
+
+		for _, info := range rsp.ValidationResultInfos {
+			tw.Write(map[string]interface{}{
+				"RoundID":     info.RoundID,
+				"NodeID":      info.NodeID,
+				"ValidatorID": info.ValidatorID,
+				"Type":        info.Type.String(),
+				"Status":      info.Status.String(),
+				"Blocks":      info.BlockNumber,
+				"Bandwidth":   units.BytesSize(info.Bandwidth),
+				"StartTime":   info.StartTime.Format(defaultDateTimeLayout),
+			})
+		}
+
+		tw.Flush(os.Stdout)
+		fmt.Printf("total: %d\n", rsp.Total)
+		return nil
+	},
+}
+
+var showValidationResultCmd = &cli.Command{
+	Name:  "show",
+	Usage: "Show the validation result of a node in a round",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		roundIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		roundID := cctx.String("round-id")
+		if nodeID == "" || roundID == "" {
+			return xerrors.New("node-id and round-id are required")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		rsp, err := schedulerAPI.ListValidationResults(ctx, types.ListValidationResultsReq{NodeID: nodeID, RoundID: roundID, Count: 1})
+		if err != nil {
+			return err
+		}
+
+		if len(rsp.ValidationResultInfos) == 0 {
+			return xerrors.Errorf("validation of node %s in round %s not found", nodeID, roundID)
+		}
+
+		info := rsp.ValidationResultInfos[0]
+		fmt.Printf("RoundID:\t%s\n", info.RoundID)
+		fmt.Printf("NodeID:\t\t%s\n", info.NodeID)
+		fmt.Printf("ValidatorID:\t%s\n", info.ValidatorID)
+		fmt.Printf("CandidateID:\t%s\n", info.CandidateID)
+		fmt.Printf("Type:\t\t%s\n", info.Type.String())
+		fmt.Printf("Status:\t\t%s\n", info.Status.String())
+		fmt.Printf("CID:\t\t%s\n", info.Cid)
+		fmt.Printf("Blocks:\t\t%d\n", info.BlockNumber)
+		fmt.Printf("Bandwidth:\t%s\n", units.BytesSize(info.Bandwidth))
+		fmt.Printf("Duration:\t%d\n", info.Duration)
+		fmt.Printf("BeaconRound:\t%d\n", info.BeaconRound)
+		fmt.Printf("StartTime:\t%s\n", info.StartTime.Format(defaultDateTimeLayout))
+		fmt.Printf("EndTime:\t%s\n", info.EndTime.Format(defaultDateTimeLayout))
+
+		return nil
+	},
+}
+
+var validationSummaryCmd = &cli.Command{
+	Name:  "summary",
+	Usage: "Show the validation success ratio of the nodes, the lowest first",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+		&cli.IntFlag{
+			Name:        "days",
+			Usage:       "the validations in the last days are counted",
+			Value:       7,
+			DefaultText: "7",
+		},
+		limitFlag,
+		offsetFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		now := time.Now()
+		req := types.ValidationSummaryReq{
+			NodeID:    cctx.String("node-id"),
+			StartTime: now.AddDate(0, 0, -cctx.Int("days")).Unix(),
+			EndTime:   now.Unix(),
+			Cursor:    cctx.Int("offset"),
+			Count:     cctx.Int("limit"),
+		}
+
+		rsp, err := schedulerAPI.GetValidationSummaries(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("NodeID"),
+			tablewriter.Col("Total"),
+			tablewriter.Col("Succeeded"),
+			tablewriter.Col("Failed"),
+			tablewriter.Col("Timeouts"),
+			tablewriter.Col("SuccessRatio"),
+			tablewriter.Col("Bandwidth"),
+			tablewriter.Col("LastFailed"),
+		)
+
+		for _, summary := range rsp.Summaries {
+			lastFailed := ""
+			if summary.LastFailedTime != nil {
+				lastFailed = summary.LastFailedTime.Format(defaultDateTimeLayout)
+			}
+
+			tw.Write(map[string]interface{}{
+				"NodeID":       summary.NodeID,
+				"Total":        summary.Total,
+				"Succeeded":    summary.Succeeded,
+				"Failed":       summary.Failed,
+				"Timeouts":     summary.Timeouts,
+				"SuccessRatio": fmt.Sprintf("%.2f%%", summary.SuccessRatio*100),
+				"Bandwidth":    units.BytesSize(summary.Bandwidth),
+				"LastFailed":   lastFailed,
+			})
+		}
+
+		tw.Flush(os.Stdout)
+		fmt.Printf("total: %d\n", rsp.Total)
+		return nil
+	},
+}
+
+var validateNodeCmd = &cli.Command{
+	Name:  "run",
+	Usage: "Validate a node immediately",
+	Flags: []cli.Flag{
+		nodeIDFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeID := cctx.String("node-id")
+		if nodeID == "" {
+			return xerrors.New("node-id is nil")
+		}
+
+		ctx := ReqContext(cctx)
+		schedulerAPI, closer, err := GetSchedulerAPI(cctx, "")
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		roundID, err := schedulerAPI.ValidateNode(ctx, nodeID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("validation round: %s\n", roundID)
+		return nil
+	},
+}
+
+// parseUnixTime parses the time with the default layout in the local time zone, it returns 0 if the value is empty
+func parseUnixTime(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	t, err := time.ParseInLocation(defaultDateTimeLayout, value, time.Local)
+	if err != nil {
+		return 0, xerrors.Errorf("parse time %s: %w", value, err)
+	}
+
+	return t.Unix(), nil
+}
@@ -56,6 +56,10 @@ var runCmd = &cli.Command{
 			Usage: "update server url",
 			Value: "http://192.168.0.132:3456/rpc/v0",
 		},
+		&cli.StringFlag{
+			Name:  "node-id",
+			Usage: "id of the edge node, the updates rolled out to the node by its labels are applied",
+		},
 		&cli.StringFlag{
 			Name:  "install-path",
 			Usage: "install path",
@@ -94,7 +98,7 @@ func newSchedulerAPI(cctx *cli.Context, schedulerURL string) (api.Scheduler, jso
 }
 
 func checkUpdate(cctx *cli.Context, schedulerAPI api.Scheduler) {
-	updateInfos, err := edgeUpdateInfos(schedulerAPI)
+	updateInfos, err := edgeUpdateInfos(schedulerAPI, cctx.String("node-id"))
 	if err != nil {
 		log.Errorf("EdgeUpdateInfo error:%s", err.Error())
 	}
@@ -111,11 +115,11 @@ func checkUpdate(cctx *cli.Context, schedulerAPI api.Scheduler) {
 	}
 }
 
-func edgeUpdateInfos(schedulerAPI api.Scheduler) (map[int]*api.EdgeUpdateConfig, error) {
+func edgeUpdateInfos(schedulerAPI api.Scheduler, nodeID string) (map[int]*api.EdgeUpdateConfig, error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), 15*time.Second)
 	defer cancel()
 
-	return schedulerAPI.GetEdgeUpdateConfigs(ctx)
+	return schedulerAPI.GetEdgeUpdateConfigsOfNode(ctx, nodeID)
 }
 
 func getLocalEdgeVersion(appPath string) (api.Version, error) {
@@ -22,6 +22,7 @@ import (
 	cliutil "github.com/Filecoin-Titan/titan/cli/util"
 	"github.com/Filecoin-Titan/titan/node"
 	"github.com/Filecoin-Titan/titan/node/asset"
+	"github.com/Filecoin-Titan/titan/node/candidate"
 	"github.com/Filecoin-Titan/titan/node/httpserver"
 	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
 
@@ -223,6 +224,7 @@ var runCmd = &cli.Command{
 			node.Repo(r),
 			node.Override(new(dtypes.NodeID), dtypes.NodeID(candidateCfg.NodeID)),
 			node.Override(new(api.Scheduler), schedulerAPI),
+			node.Override(new(*rsa.PrivateKey), privateKey),
 			node.Override(new(dtypes.NodeMetadataPath), func() dtypes.NodeMetadataPath {
 				metadataPath := candidateCfg.MetadataPath
 				if len(metadataPath) == 0 {
@@ -268,6 +270,9 @@ var runCmd = &cli.Command{
 			return xerrors.Errorf("creating node: %w", err)
 		}
 
+		// the validated nodes pin the certificate of the tcp server
+		tcpCertHash := candidateAPI.(*candidate.Candidate).TCPSrv.CertificateHash()
+
 		handler := CandidateHandler(candidateAPI.AuthVerify, candidateAPI, true)
 		handler = httpServer.NewHandler(handler)
 
@@ -364,7 +369,7 @@ var runCmd = &cli.Command{
 
 					select {
 					case <-readyCh:
-						opts := &types.ConnectOptions{Token: token, TcpServerPort: tcpServerPort}
+						opts := &types.ConnectOptions{Token: token, TcpServerPort: tcpServerPort, TcpServerTLS: candidateCfg.TCPSrvTLS, TcpServerCertHash: tcpCertHash}
 						err := schedulerAPI.CandidateConnect(ctx, opts)
 						if err != nil {
 							log.Errorf("Registering candidate failed: %+v", err)
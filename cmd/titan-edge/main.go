@@ -215,6 +215,7 @@ var runCmd = &cli.Command{
 			node.Repo(r),
 			node.Override(new(dtypes.NodeID), dtypes.NodeID(edgeCfg.NodeID)),
 			node.Override(new(api.Scheduler), schedulerAPI),
+			node.Override(new(*rsa.PrivateKey), privateKey),
 			node.Override(new(net.PacketConn), udpPacketConn),
 			node.Override(new(dtypes.NodeMetadataPath), func() dtypes.NodeMetadataPath {
 				metadataPath := edgeCfg.MetadataPath
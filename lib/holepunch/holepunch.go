@@ -0,0 +1,108 @@
+// Package holepunch implements the UDP packets sent by both parties of a hole punch.
+//
+// The parties learn the observed address of each other from a rendezvous server, and send the punch packets
+// to each other from the same socket at the same time, so both NATs map the socket and let the packets of
+// the other party in.
+package holepunch
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const maxPacketSize = 256
+
+// magic prefixes the punch packets, so they are told apart from the other packets received on the socket
+var magic = []byte("titan-punch:")
+
+// NewPacket returns a punch packet of the session
+func NewPacket(session string) []byte {
+	return append(append([]byte{}, magic...), session...)
+}
+
+// ParsePacket returns the session of the punch packet, ok is false if it is not a punch packet
+func ParsePacket(data []byte) (session string, ok bool) {
+	if !bytes.HasPrefix(data, magic) {
+		return "", false
+	}
+
+	return string(data[len(magic):]), true
+}
+
+// Send sends the punch packets of the session to the address from the connection, it waits until the start time,
+// then sends a packet every interval until the attempts are used up or the context is done
+func Send(ctx context.Context, conn net.PacketConn, addr net.Addr, session string, start time.Time, interval time.Duration, attempts int) error {
+	select {
+	case <-time.After(time.Until(start)):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	packet := NewPacket(session)
+	for i := 0; i < attempts; i++ {
+		if _, err := conn.WriteTo(packet, addr); err != nil {
+			return xerrors.Errorf("send punch packet to %s: %w", addr.String(), err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Punch sends the punch packets of the session to the peer and waits for a punch packet of the peer,
+// it returns the address the packet comes from, the port differs from the peer address if the peer is behind a symmetric NAT.
+// It is used by the party which owns the connection, the other packets received on it are dropped.
+func Punch(ctx context.Context, conn net.PacketConn, peer string, session string, start time.Time, interval time.Duration, attempts int) (net.Addr, error) {
+	peerAddr, err := net.ResolveUDPAddr("udp", peer)
+	if err != nil {
+		return nil, xerrors.Errorf("resolve peer address %s: %w", peer, err)
+	}
+
+	deadline := start.Add(interval * time.Duration(attempts))
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	// keep sending after the packet of the peer is received, so the peer receives one as well
+	sendCtx, cancel := context.WithDeadline(ctx, deadline)
+	go func() {
+		defer cancel()
+		Send(sendCtx, conn, peerAddr, session, start, interval, attempts) //nolint:errcheck
+	}()
+
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer conn.SetReadDeadline(time.Time{}) //nolint:errcheck
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, xerrors.Errorf("no punch packet from %s: %w", peer, err)
+		}
+
+		s, ok := ParsePacket(buf[:n])
+		if !ok || s != session {
+			continue
+		}
+
+		if udpAddr, ok := addr.(*net.UDPAddr); ok && !udpAddr.IP.Equal(peerAddr.IP) {
+			continue
+		}
+
+		return addr, nil
+	}
+}
@@ -0,0 +1,72 @@
+package holepunch
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+
+	return conn
+}
+
+func TestParsePacket(t *testing.T) {
+	session, ok := ParsePacket(NewPacket("abc"))
+	if !ok || session != "abc" {
+		t.Errorf("expect session abc, got %s %v", session, ok)
+	}
+
+	if _, ok := ParsePacket([]byte("GET / HTTP/1.1")); ok {
+		t.Error("expect not a punch packet")
+	}
+}
+
+func TestPunch(t *testing.T) {
+	a, b := listen(t), listen(t)
+	start := time.Now().Add(50 * time.Millisecond)
+
+	type result struct {
+		addr net.Addr
+		err  error
+	}
+	ch := make(chan result, 1)
+
+	go func() {
+		addr, err := Punch(context.Background(), b, a.LocalAddr().String(), "s1", start, 20*time.Millisecond, 10)
+		ch <- result{addr, err}
+	}()
+
+	addr, err := Punch(context.Background(), a, b.LocalAddr().String(), "s1", start, 20*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != b.LocalAddr().String() {
+		t.Errorf("expect punched address %s, got %s", b.LocalAddr().String(), addr.String())
+	}
+
+	r := <-ch
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if r.addr.String() != a.LocalAddr().String() {
+		t.Errorf("expect punched address %s, got %s", a.LocalAddr().String(), r.addr.String())
+	}
+}
+
+func TestPunchTimeout(t *testing.T) {
+	a, b := listen(t), listen(t)
+
+	// the peer only sends the packets of another session
+	go Send(context.Background(), b, a.LocalAddr(), "s2", time.Now(), 10*time.Millisecond, 5) //nolint:errcheck
+
+	if _, err := Punch(context.Background(), a, b.LocalAddr().String(), "s1", time.Now(), 10*time.Millisecond, 10); err == nil {
+		t.Error("expect punch to fail")
+	}
+}
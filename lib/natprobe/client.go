@@ -0,0 +1,193 @@
+package natprobe
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultRequestTimeout = time.Second // Timeout of a binding request
+	defaultRetries        = 3           // Number of attempts of a binding request
+)
+
+// Result is the NAT behavior discovered by the client
+type Result struct {
+	NATType types.NatType
+	// MappedAddr is the address of the client observed by the server
+	MappedAddr string
+}
+
+// Client discovers the NAT behavior by the binding requests sent from the connection
+type Client struct {
+	conn    net.PacketConn
+	Timeout time.Duration
+	Retries int
+}
+
+// NewClient returns a new client sending the binding requests from the connection
+func NewClient(conn net.PacketConn) *Client {
+	return &Client{conn: conn, Timeout: defaultRequestTimeout, Retries: defaultRetries}
+}
+
+// Probe discovers the NAT type with the server, the filtering tests run before the mapping test,
+// because sending to the alternate address of the server opens the filter for it
+func (c *Client) Probe(ctx context.Context, server string) (*Result, error) {
+	serverAddr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, xerrors.Errorf("resolve server address %s: %w", server, err)
+	}
+
+	// test I, the mapped address and the alternate address of the server
+	rsp, err := c.request(ctx, serverAddr, &Request{})
+	if err != nil {
+		return nil, xerrors.Errorf("no binding response from %s, udp may be blocked: %w", server, err)
+	}
+
+	result := &Result{MappedAddr: rsp.MappedAddr}
+	noNAT := c.isLocalAddr(rsp.MappedAddr)
+
+	otherAddr, err := net.ResolveUDPAddr("udp", rsp.OtherAddr)
+	if err != nil {
+		return nil, xerrors.Errorf("resolve other address %s: %w", rsp.OtherAddr, err)
+	}
+	changeIP := !otherAddr.IP.Equal(serverAddr.IP)
+
+	// filtering test II, the response comes from the alternate IP and port
+	if changeIP {
+		if _, err := c.request(ctx, serverAddr, &Request{ChangeIP: true, ChangePort: true}); err == nil {
+			result.NATType = types.NatTypeFullCone
+			if noNAT {
+				result.NATType = types.NatTypeNo
+			}
+			return result, nil
+		}
+	}
+
+	// filtering test III, the response comes from the alternate port
+	_, err = c.request(ctx, serverAddr, &Request{ChangePort: true})
+	portFiltered := err != nil
+
+	if noNAT && !portFiltered && !changeIP {
+		// the server can not tell the address dependent filtering apart
+		result.NATType = types.NatTypeNo
+		return result, nil
+	}
+
+	// mapping test, the mapped address of the request sent to the alternate address
+	rsp, err = c.request(ctx, otherAddr, &Request{})
+	if err != nil {
+		return nil, xerrors.Errorf("no binding response from %s: %w", otherAddr.String(), err)
+	}
+
+	switch {
+	case rsp.MappedAddr != result.MappedAddr:
+		result.NATType = types.NatTypeSymmetric
+	case portFiltered:
+		result.NATType = types.NatTypePortRestricted
+	default:
+		result.NATType = types.NatTypeRestricted
+	}
+
+	return result, nil
+}
+
+// request sends the binding request to the address and waits for the response, the request is sent again on timeout
+func (c *Client) request(ctx context.Context, addr net.Addr, req *Request) (*Response, error) {
+	req.ID = uuid.NewString()
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := c.Retries
+	if retries <= 0 {
+		retries = 1
+	}
+
+	var lastErr error
+	for i := 0; i < retries; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if _, err := c.conn.WriteTo(data, addr); err != nil {
+			return nil, xerrors.Errorf("send binding request to %s: %w", addr.String(), err)
+		}
+
+		rsp, err := c.readResponse(ctx, req.ID)
+		if err == nil {
+			if rsp.Error != "" {
+				return nil, xerrors.New(rsp.Error)
+			}
+			return rsp, nil
+		}
+
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// readResponse reads the response of the request until the timeout, the other packets are ignored
+func (c *Client) readResponse(ctx context.Context, id string) (*Response, error) {
+	deadline := time.Now().Add(c.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if err := c.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer c.conn.SetReadDeadline(time.Time{}) //nolint:errcheck
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		rsp := &Response{}
+		if err := json.Unmarshal(buf[:n], rsp); err != nil || rsp.ID != id {
+			continue
+		}
+
+		return rsp, nil
+	}
+}
+
+// isLocalAddr returns whether the mapped address is the local address of the connection
+func (c *Client) isLocalAddr(mapped string) bool {
+	mAddr, err := net.ResolveUDPAddr("udp", mapped)
+	if err != nil {
+		return false
+	}
+
+	local, ok := c.conn.LocalAddr().(*net.UDPAddr)
+	if !ok || local.Port != mAddr.Port {
+		return false
+	}
+
+	if !local.IP.IsUnspecified() {
+		return local.IP.Equal(mAddr.IP)
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(mAddr.IP) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,247 @@
+package natprobe
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+type filtering int
+
+const (
+	endpointIndependent filtering = iota
+	addressDependent
+	addressAndPortDependent
+)
+
+type packet struct {
+	data []byte
+	addr net.Addr
+}
+
+// fakeNAT is a packet connection behind a simulated NAT, every mapping is a local UDP socket
+type fakeNAT struct {
+	symmetric bool
+	filtering filtering
+
+	lock     sync.Mutex
+	mappings map[string]net.PacketConn // the key is the destination if the mapping is symmetric
+	permits  map[string]struct{}       // the destinations the client has sent to
+	packets  chan packet
+	deadline time.Time
+}
+
+func newFakeNAT(symmetric bool, f filtering) *fakeNAT {
+	return &fakeNAT{
+		symmetric: symmetric,
+		filtering: f,
+		mappings:  make(map[string]net.PacketConn),
+		permits:   make(map[string]struct{}),
+		packets:   make(chan packet, 16),
+	}
+}
+
+func (n *fakeNAT) WriteTo(p []byte, addr net.Addr) (int, error) {
+	key := ""
+	if n.symmetric {
+		key = addr.String()
+	}
+
+	n.lock.Lock()
+	n.permits[addr.String()] = struct{}{}
+	conn, ok := n.mappings[key]
+	if !ok {
+		var err error
+		conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			n.lock.Unlock()
+			return 0, err
+		}
+		n.mappings[key] = conn
+		go n.receive(conn)
+	}
+	n.lock.Unlock()
+
+	return conn.WriteTo(p, addr)
+}
+
+// receive forwards the packets which pass the filter to the client
+func (n *fakeNAT) receive(conn net.PacketConn) {
+	buf := make([]byte, maxMessageSize)
+	for {
+		size, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if !n.allowed(addr) {
+			continue
+		}
+
+		data := make([]byte, size)
+		copy(data, buf[:size])
+		n.packets <- packet{data: data, addr: addr}
+	}
+}
+
+func (n *fakeNAT) allowed(addr net.Addr) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	switch n.filtering {
+	case endpointIndependent:
+		return true
+	case addressDependent:
+		host, _, _ := net.SplitHostPort(addr.String())
+		for permit := range n.permits {
+			if h, _, _ := net.SplitHostPort(permit); h == host {
+				return true
+			}
+		}
+		return false
+	default:
+		_, ok := n.permits[addr.String()]
+		return ok
+	}
+}
+
+func (n *fakeNAT) ReadFrom(p []byte) (int, net.Addr, error) {
+	n.lock.Lock()
+	deadline := n.deadline
+	n.lock.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case pkt := <-n.packets:
+		return copy(p, pkt.data), pkt.addr, nil
+	case <-timeout:
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+}
+
+func (n *fakeNAT) Close() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, conn := range n.mappings {
+		conn.Close() //nolint:errcheck
+	}
+	return nil
+}
+
+func (n *fakeNAT) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 4000}
+}
+
+func (n *fakeNAT) SetDeadline(t time.Time) error {
+	return n.SetReadDeadline(t)
+}
+
+func (n *fakeNAT) SetReadDeadline(t time.Time) error {
+	n.lock.Lock()
+	n.deadline = t
+	n.lock.Unlock()
+	return nil
+}
+
+func (n *fakeNAT) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func newTestServer(t *testing.T) *Server {
+	s, err := NewServer("127.0.0.1:0", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("listen on two loopback addresses: %s", err.Error())
+	}
+	t.Cleanup(func() { s.Close() }) //nolint:errcheck
+
+	return s
+}
+
+func probe(t *testing.T, conn net.PacketConn, server string) *Result {
+	c := NewClient(conn)
+	c.Timeout = 200 * time.Millisecond
+	c.Retries = 2
+
+	result, err := c.Probe(context.Background(), server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestProbe(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name      string
+		symmetric bool
+		filtering filtering
+		expect    types.NatType
+	}{
+		{"full cone", false, endpointIndependent, types.NatTypeFullCone},
+		{"restricted", false, addressDependent, types.NatTypeRestricted},
+		{"port restricted", false, addressAndPortDependent, types.NatTypePortRestricted},
+		{"symmetric", true, addressAndPortDependent, types.NatTypeSymmetric},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nat := newFakeNAT(tt.symmetric, tt.filtering)
+			defer nat.Close() //nolint:errcheck
+
+			result := probe(t, nat, s.PrimaryAddr())
+			if result.NATType != tt.expect {
+				t.Errorf("expect %s, got %s", tt.expect.String(), result.NATType.String())
+			}
+		})
+	}
+}
+
+func TestProbeNoNAT(t *testing.T) {
+	s := newTestServer(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	result := probe(t, conn, s.PrimaryAddr())
+	if result.NATType != types.NatTypeNo {
+		t.Errorf("expect %s, got %s", types.NatTypeNo.String(), result.NATType.String())
+	}
+
+	if result.MappedAddr != conn.LocalAddr().String() {
+		t.Errorf("expect mapped address %s, got %s", conn.LocalAddr().String(), result.MappedAddr)
+	}
+}
+
+func TestServerSingleIP(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close() //nolint:errcheck
+
+	// the full cone NAT can not be told apart from the restricted one without the alternate IP
+	nat := newFakeNAT(false, endpointIndependent)
+	defer nat.Close() //nolint:errcheck
+
+	result := probe(t, nat, s.PrimaryAddr())
+	if result.NATType != types.NatTypeRestricted {
+		t.Errorf("expect %s, got %s", types.NatTypeRestricted.String(), result.NATType.String())
+	}
+}
@@ -0,0 +1,177 @@
+// Package natprobe implements a RFC 5780 style NAT behavior discovery over UDP.
+//
+// The server listens on two ports of one or two IP addresses. The client sends binding requests from
+// the socket behind the NAT, the server answers with the mapped address it observes, optionally from
+// its alternate port or address, so the client can tell the mapping and filtering behavior of the NAT.
+package natprobe
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("natprobe")
+
+const maxMessageSize = 1024
+
+// Request is a binding request sent by the client
+type Request struct {
+	ID string `json:"id"`
+	// ChangeIP asks the server to respond from the alternate IP address
+	ChangeIP bool `json:"change_ip"`
+	// ChangePort asks the server to respond from the alternate port
+	ChangePort bool `json:"change_port"`
+}
+
+// Response is a binding response sent by the server
+type Response struct {
+	ID string `json:"id"`
+	// MappedAddr is the source address of the request observed by the server
+	MappedAddr string `json:"mapped_addr"`
+	// ResponseAddr is the address the response is sent from
+	ResponseAddr string `json:"response_addr"`
+	// OtherAddr is the alternate address of the server, it differs from the primary address in port, and in IP if the server has two
+	OtherAddr string `json:"other_addr"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Server answers the binding requests on the primary and alternate addresses
+type Server struct {
+	// conns[i][j] listens on the i-th IP and the j-th port, the second IP is absent if the addresses have the same IP
+	conns [2][2]net.PacketConn
+	ips   int
+
+	wg sync.WaitGroup
+}
+
+// NewServer listens on the primary and alternate addresses, the alternate address must have a different port,
+// the filtering behavior can only be told apart fully if it has a different IP as well
+func NewServer(primary, alternate string) (*Server, error) {
+	pAddr, err := net.ResolveUDPAddr("udp", primary)
+	if err != nil {
+		return nil, xerrors.Errorf("resolve primary address %s: %w", primary, err)
+	}
+
+	aAddr, err := net.ResolveUDPAddr("udp", alternate)
+	if err != nil {
+		return nil, xerrors.Errorf("resolve alternate address %s: %w", alternate, err)
+	}
+
+	if pAddr.Port != 0 && pAddr.Port == aAddr.Port {
+		return nil, xerrors.Errorf("alternate address %s must have a different port from %s", alternate, primary)
+	}
+
+	ips := []net.IP{pAddr.IP, aAddr.IP}
+	ports := []int{pAddr.Port, aAddr.Port}
+
+	s := &Server{ips: 2}
+	if pAddr.IP.Equal(aAddr.IP) {
+		s.ips = 1
+	}
+
+	for j := 0; j < 2; j++ {
+		for i := 0; i < s.ips; i++ {
+			conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: ips[i], Port: ports[j]})
+			if err != nil {
+				s.close()
+				return nil, xerrors.Errorf("listen %s: %w", net.JoinHostPort(ips[i].String(), strconv.Itoa(ports[j])), err)
+			}
+
+			// a zero port is resolved by the first socket, the sockets on the other IP share it
+			ports[j] = conn.LocalAddr().(*net.UDPAddr).Port
+			s.conns[i][j] = conn
+		}
+	}
+
+	for i := 0; i < s.ips; i++ {
+		for j := 0; j < 2; j++ {
+			s.wg.Add(1)
+			go s.serve(i, j)
+		}
+	}
+
+	return s, nil
+}
+
+// PrimaryAddr returns the primary address the clients send the binding requests to
+func (s *Server) PrimaryAddr() string {
+	return s.conns[0][0].LocalAddr().String()
+}
+
+// OtherAddr returns the alternate address of the server
+func (s *Server) OtherAddr() string {
+	return s.conns[s.ips-1][1].LocalAddr().String()
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	s.close()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server) close() {
+	for i := range s.conns {
+		for j := range s.conns[i] {
+			if s.conns[i][j] != nil {
+				s.conns[i][j].Close() //nolint:errcheck
+			}
+		}
+	}
+}
+
+// serve answers the binding requests received by conns[i][j]
+func (s *Server) serve(i, j int) {
+	defer s.wg.Done()
+
+	conn := s.conns[i][j]
+	buf := make([]byte, maxMessageSize)
+
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !xerrors.Is(err, net.ErrClosed) {
+				log.Errorf("read binding request on %s err:%s", conn.LocalAddr().String(), err.Error())
+			}
+			return
+		}
+
+		req := &Request{}
+		if err := json.Unmarshal(buf[:n], req); err != nil {
+			log.Debugf("invalid binding request from %s: %s", addr.String(), err.Error())
+			continue
+		}
+
+		ri, rj := i, j
+		rsp := &Response{ID: req.ID, MappedAddr: addr.String(), OtherAddr: s.OtherAddr()}
+
+		if req.ChangeIP && s.ips == 1 {
+			rsp.Error = "change ip is not supported"
+		} else {
+			if req.ChangeIP {
+				ri = 1 - i
+			}
+			if req.ChangePort {
+				rj = 1 - j
+			}
+		}
+
+		rConn := s.conns[ri][rj]
+		rsp.ResponseAddr = rConn.LocalAddr().String()
+
+		data, err := json.Marshal(rsp)
+		if err != nil {
+			log.Errorf("marshal binding response err:%s", err.Error())
+			continue
+		}
+
+		if _, err := rConn.WriteTo(data, addr); err != nil {
+			log.Debugf("send binding response to %s err:%s", addr.String(), err.Error())
+		}
+	}
+}
@@ -24,6 +24,9 @@ var (
 
 	Endpoint, _     = tag.NewKey("endpoint")
 	APIInterface, _ = tag.NewKey("api") // to distinguish between gateway api and full node api endpoint calls
+
+	// gateway
+	CacheType, _ = tag.NewKey("cache_type")
 )
 
 // Measures
@@ -31,6 +34,10 @@ var (
 	// common
 	TitanInfo          = stats.Int64("info", "Arbitrary counter to tag titan info to", stats.UnitDimensionless)
 	APIRequestDuration = stats.Float64("api/request_duration_ms", "Duration of API requests", stats.UnitMilliseconds)
+
+	// gateway
+	GatewayCacheHit  = stats.Int64("gateway/cache_hit", "Counter of gateway cache hits", stats.UnitDimensionless)
+	GatewayCacheMiss = stats.Int64("gateway/cache_miss", "Counter of gateway cache misses", stats.UnitDimensionless)
 )
 
 var (
@@ -47,6 +54,17 @@ var (
 		Aggregation: defaultMillisecondsDistribution,
 		TagKeys:     []tag.Key{APIInterface, Endpoint},
 	}
+
+	GatewayCacheHitView = &view.View{
+		Measure:     GatewayCacheHit,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{CacheType},
+	}
+	GatewayCacheMissView = &view.View{
+		Measure:     GatewayCacheMiss,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{CacheType},
+	}
 )
 
 // DefaultViews is an array of OpenCensus views for metric gathering purposes
@@ -54,6 +72,8 @@ var DefaultViews = func() []*view.View {
 	views := []*view.View{
 		InfoView,
 		APIRequestDurationView,
+		GatewayCacheHitView,
+		GatewayCacheMissView,
 	}
 	views = append(views, rpcmetrics.DefaultViews...)
 	return views
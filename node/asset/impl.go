@@ -138,6 +138,16 @@ func (a *Asset) GetBlocksOfAsset(assetCID string, randomSeed int64, randomCount
 	return a.mgr.GetBlocksOfAsset(root, randomSeed, randomCount)
 }
 
+// GetStorageProof returns the hashes of the data and the nonce of the random blocks of the challenged asset.
+func (a *Asset) GetStorageProof(ctx context.Context, challenge *types.StorageChallenge) (map[int]string, error) {
+	root, err := cid.Decode(challenge.AssetCID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.mgr.GetStorageProof(ctx, root, challenge.Seed, challenge.Count, challenge.Nonce)
+}
+
 // BlockCountOfAsset returns the block count for the given asset.
 func (a *Asset) BlockCountOfAsset(assetCID string) (int, error) {
 	c, err := cid.Decode(assetCID)
@@ -91,6 +91,27 @@ func (lru *lruCache) hasBlock(ctx context.Context, root, block cid.Cid) (bool, e
 	return false, xerrors.Errorf("can not convert interface to *cacheValue")
 }
 
+// getBlockSize gets the size of the block with a given root and block ID from the index of the cached asset.
+func (lru *lruCache) getBlockSize(ctx context.Context, root, block cid.Cid) (int, error) {
+	key := Key(root.Hash().String())
+	v, ok := lru.cache.Get(key)
+	if !ok {
+		if err := lru.add(root); err != nil {
+			return 0, xerrors.Errorf("add cache %s %w", root.String(), err)
+		}
+
+		if v, ok = lru.cache.Get(key); !ok {
+			return 0, xerrors.Errorf("asset %s not exist", root.String())
+		}
+	}
+
+	if c, ok := v.(*cacheValue); ok {
+		return c.bs.GetSize(ctx, block)
+	}
+
+	return 0, xerrors.Errorf("can not convert interface to *cacheValue")
+}
+
 // assetIndex returns the index of an asset with a given root.
 func (lru *lruCache) assetIndex(root cid.Cid) (index.Index, error) {
 	key := Key(root.Hash().String())
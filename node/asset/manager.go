@@ -2,7 +2,8 @@ package asset
 
 import (
 	"context"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
 	"math/rand"
 	"os"
 	"sync"
@@ -38,6 +39,9 @@ type Manager struct {
 	bFetcher     fetcher.BlockFetcher
 	lru          *lruCache
 	storage.Storage
+
+	deleteHandlers     []func(root cid.Cid)
+	deleteHandlersLock sync.RWMutex
 }
 
 // ManagerOptions is the struct that contains options for Manager
@@ -301,6 +305,12 @@ func (m *Manager) DeleteAsset(root cid.Cid) error {
 	// remove lru puller
 	m.lru.remove(root)
 
+	m.deleteHandlersLock.RLock()
+	for _, handler := range m.deleteHandlers {
+		handler(root)
+	}
+	m.deleteHandlersLock.RUnlock()
+
 	ok, err := m.deleteAssetFromWaitList(root)
 	if err != nil {
 		return err
@@ -413,6 +423,19 @@ func (m *Manager) GetBlock(ctx context.Context, root, block cid.Cid) (blocks.Blo
 	return m.lru.getBlock(ctx, root, block)
 }
 
+// GetBlockSize returns the size of the block with the given CID from the index of the asset.
+func (m *Manager) GetBlockSize(ctx context.Context, root, block cid.Cid) (int, error) {
+	return m.lru.getBlockSize(ctx, root, block)
+}
+
+// OnDeleteAsset registers a handler which is called when an asset is deleted.
+func (m *Manager) OnDeleteAsset(handler func(root cid.Cid)) {
+	m.deleteHandlersLock.Lock()
+	defer m.deleteHandlersLock.Unlock()
+
+	m.deleteHandlers = append(m.deleteHandlers, handler)
+}
+
 // HasBlock checks if a block with the given CID exists in the LRU cache
 func (m *Manager) HasBlock(ctx context.Context, root, block cid.Cid) (bool, error) {
 	return m.lru.hasBlock(ctx, root, block)
@@ -454,32 +477,37 @@ func (m *Manager) GetBlocksOfAsset(root cid.Cid, randomSeed int64, randomCount i
 	return ret, nil
 }
 
-// AddLostAsset adds a lost asset to the Manager's waitList if it is not already present in the storage
-func (m *Manager) AddLostAsset(root cid.Cid) error {
-	if has, err := m.AssetExists(root); err != nil {
-		return err
-	} else if has {
-		return nil
+// GetStorageProof returns the hashes of the data and the nonce of the random blocks, the blocks are selected as GetBlocksOfAsset
+func (m *Manager) GetStorageProof(ctx context.Context, root cid.Cid, randomSeed int64, randomCount int, nonce []byte) (map[int]string, error) {
+	blks, err := m.GetBlocksOfAsset(root, randomSeed, randomCount)
+	if err != nil {
+		return nil, err
 	}
 
-	switch types.RunningNodeType {
-	case types.NodeCandidate:
-		m.addToWaitList(root, nil)
-	case types.NodeEdge:
-		return fmt.Errorf("not implement")
-	default:
-		return fmt.Errorf("not support node type:%s", types.RunningNodeType)
+	ret := make(map[int]string, len(blks))
+	for i, blkCID := range blks {
+		c, err := cid.Decode(blkCID)
+		if err != nil {
+			return nil, err
+		}
+
+		blk, err := m.lru.getBlock(ctx, root, c)
+		if err != nil {
+			return nil, xerrors.Errorf("get block %s: %w", blkCID, err)
+		}
+
+		h := sha256.New()
+		h.Write(blk.RawData())
+		h.Write(nonce)
+		ret[i] = hex.EncodeToString(h.Sum(nil))
 	}
 
-	return nil
+	return ret, nil
 }
 
 // GetAssetsOfBucket retrieves the list of assets in a given bucket ID from the storage
-func (m *Manager) GetAssetsOfBucket(ctx context.Context, bucketID uint32, isRemote bool) ([]cid.Cid, error) {
-	if !isRemote {
-		return m.Storage.GetAssetsInBucket(ctx, bucketID)
-	}
-	return nil, nil
+func (m *Manager) GetAssetsOfBucket(ctx context.Context, bucketID uint32) ([]cid.Cid, error) {
+	return m.Storage.GetAssetsInBucket(ctx, bucketID)
 }
 
 // GetChecker returns a new instance of a random asset validator based on a given random seed
@@ -1,12 +1,17 @@
 package asset
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/Filecoin-Titan/titan/node/asset/fetcher"
 	"github.com/Filecoin-Titan/titan/node/asset/storage"
+	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 )
@@ -52,3 +57,73 @@ func TestManager(t *testing.T) {
 
 	time.Sleep(1 * time.Minute)
 }
+
+func TestGetStorageProof(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	storageMgr, err := storage.NewManager(&storage.ManagerOptions{MetaDataPath: dir, AssetsPaths: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blks := make([]blocks.Block, 0, 20)
+	data := make(map[string][]byte)
+	for i := 0; i < 20; i++ {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("block %d", i)))
+		blks = append(blks, blk)
+		data[blk.Cid().Hash().String()] = blk.RawData()
+	}
+	root := blks[0].Cid()
+
+	if err := storageMgr.StoreBlocks(ctx, root, blks); err != nil {
+		t.Fatal(err)
+	}
+	if err := storageMgr.StoreAsset(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := newLRUCache(storageMgr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Storage: storageMgr, lru: cache}
+
+	seed, count, nonce := int64(7), 5, []byte("nonce")
+	selected, err := mgr.GetBlocksOfAsset(root, seed, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := mgr.GetStorageProof(ctx, root, seed, count, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(proof) != count {
+		t.Fatalf("expect %d hashes, got %d", count, len(proof))
+	}
+
+	// the proof is sha256(block || nonce) of the blocks selected by GetBlocksOfAsset with the same seed
+	for i, blkCID := range selected {
+		c, err := cid.Decode(blkCID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := sha256.Sum256(append(append([]byte{}, data[c.Hash().String()]...), nonce...))
+		if proof[i] != hex.EncodeToString(h[:]) {
+			t.Errorf("block %d: expect hash %x, got %s", i, h, proof[i])
+		}
+	}
+
+	other, err := mgr.GetStorageProof(ctx, root, seed, count, []byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range proof {
+		if other[i] == proof[i] {
+			t.Errorf("block %d: expect a different hash with a different nonce", i)
+		}
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"sort"
 	"sync"
 
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/ipfs/go-cid"
 	ds "github.com/ipfs/go-datastore"
 	"github.com/multiformats/go-multihash"
@@ -196,10 +198,62 @@ func (av *assetsView) update(ctx context.Context, bucketID uint32, assetHashes [
 	return nil
 }
 
-// calculateBucketHash calculates the hash of all asset hashes within a bucket.
+// getAssetsOfRange returns the asset hashes in the range of a bucket.
+func (av *assetsView) getAssetsOfRange(ctx context.Context, r types.AssetRange) ([]multihash.Multihash, error) {
+	assetHashes, err := av.bucket.getAssetHashes(ctx, r.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]multihash.Multihash, 0, len(assetHashes))
+	for _, h := range assetHashes {
+		if r.Contains(av.assetKey(h)) {
+			out = append(out, h)
+		}
+	}
+
+	return out, nil
+}
+
+// getRangeHashes returns the hashes of the non-empty sub-ranges of the range, the key is the index of the sub-range.
+func (av *assetsView) getRangeHashes(ctx context.Context, r types.AssetRange) (map[uint32]string, error) {
+	if r.Level >= types.AssetRangeMaxLevel {
+		return nil, xerrors.Errorf("range level %d is the max level", r.Level)
+	}
+
+	assetHashes, err := av.getAssetsOfRange(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	subRanges := make(map[uint32][]multihash.Multihash)
+	for _, h := range assetHashes {
+		index := r.SubRange(av.assetKey(h)).Index
+		subRanges[index] = append(subRanges[index], h)
+	}
+
+	out := make(map[uint32]string, len(subRanges))
+	for index, hashes := range subRanges {
+		hash, err := av.calculateBucketHash(hashes)
+		if err != nil {
+			return nil, err
+		}
+		out[index] = hash
+	}
+
+	return out, nil
+}
+
+// calculateBucketHash calculates the hash of all asset hashes within a bucket or a range, the hashes are sorted first.
 func (av *assetsView) calculateBucketHash(hashes []multihash.Multihash) (string, error) {
+	sorted := make([]multihash.Multihash, len(hashes))
+	copy(sorted, hashes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
 	hash := sha256.New()
-	for _, h := range hashes {
+	for _, h := range sorted {
 		if _, err := hash.Write(h); err != nil {
 			return "", err
 		}
@@ -207,11 +261,19 @@ func (av *assetsView) calculateBucketHash(hashes []multihash.Multihash) (string,
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// calculateTopHash calculates the top hash value from the bucket hash values.
+// calculateTopHash calculates the top hash value from the bucket hash values in the order of the bucket id.
 func (av *assetsView) calculateTopHash(checksums map[uint32]string) (string, error) {
+	bucketIDs := make([]uint32, 0, len(checksums))
+	for bucketID := range checksums {
+		bucketIDs = append(bucketIDs, bucketID)
+	}
+	sort.Slice(bucketIDs, func(i, j int) bool {
+		return bucketIDs[i] < bucketIDs[j]
+	})
+
 	hash := sha256.New()
-	for _, checksum := range checksums {
-		if cs, err := hex.DecodeString(checksum); err != nil {
+	for _, bucketID := range bucketIDs {
+		if cs, err := hex.DecodeString(checksums[bucketID]); err != nil {
 			return "", err
 		} else if _, err := hash.Write(cs); err != nil {
 			return "", err
@@ -271,6 +333,13 @@ func (b *bucket) bucketID(c cid.Cid) uint32 {
 	return h.Sum32() % b.size
 }
 
+// assetKey returns the key of the asset to locate the range in its bucket
+func (b *bucket) assetKey(mh multihash.Multihash) uint32 {
+	h := fnv.New32a()
+	h.Write(mh)
+	return h.Sum32() / b.size
+}
+
 func removeHash(sources []multihash.Multihash, target multihash.Multihash) []multihash.Multihash {
 	// remove mhs
 	for i, mh := range sources {
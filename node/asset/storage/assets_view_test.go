@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/multiformats/go-multihash"
@@ -104,3 +106,66 @@ func TestAssetView(t *testing.T) {
 		t.Logf("bucketHashes: %#v", bucketHashes)
 	}
 }
+
+func TestAssetRange(t *testing.T) {
+	assetsView, err := newAssetsView(t.TempDir(), 1)
+	if err != nil {
+		t.Fatalf("new assets view error:%s", err.Error())
+	}
+
+	ctx := context.Background()
+	count := 300
+	for i := 0; i < count; i++ {
+		mh, err := multihash.Sum([]byte(fmt.Sprintf("asset-%d", i)), multihash.SHA2_256, -1)
+		if err != nil {
+			t.Fatalf("sum error:%s", err.Error())
+		}
+
+		if err := assetsView.addAsset(ctx, cid.NewCidV0(mh)); err != nil {
+			t.Fatalf("add asset error:%s", err.Error())
+		}
+	}
+
+	bucketHashes, err := assetsView.getBucketHashes(ctx)
+	if err != nil {
+		t.Fatalf("get bucketHashes error:%s", err.Error())
+	}
+
+	root := types.AssetRange{}
+	assets, err := assetsView.getAssetsOfRange(ctx, root)
+	if err != nil {
+		t.Fatalf("get assets of range error:%s", err.Error())
+	}
+
+	if hash, err := assetsView.calculateBucketHash(assets); err != nil {
+		t.Fatalf("calculate hash error:%s", err.Error())
+	} else if hash != bucketHashes[0] {
+		t.Errorf("hash of the root range %s is not the bucket hash %s", hash, bucketHashes[0])
+	}
+
+	rangeHashes, err := assetsView.getRangeHashes(ctx, root)
+	if err != nil {
+		t.Fatalf("get range hashes error:%s", err.Error())
+	}
+
+	total := 0
+	for index, rangeHash := range rangeHashes {
+		sub := types.AssetRange{Level: 1, Index: index}
+		subAssets, err := assetsView.getAssetsOfRange(ctx, sub)
+		if err != nil {
+			t.Fatalf("get assets of range error:%s", err.Error())
+		}
+
+		if hash, err := assetsView.calculateBucketHash(subAssets); err != nil {
+			t.Fatalf("calculate hash error:%s", err.Error())
+		} else if hash != rangeHash {
+			t.Errorf("hash of the range %d mismatch", index)
+		}
+
+		total += len(subAssets)
+	}
+
+	if total != count {
+		t.Errorf("the sub-ranges have %d assets, expect %d", total, count)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"path/filepath"
 
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-libipfs/blocks"
 	logging "github.com/ipfs/go-log/v2"
@@ -161,6 +162,25 @@ func (m *Manager) GetAssetsInBucket(ctx context.Context, bucketID uint32) ([]cid
 	return cids, nil
 }
 
+// GetAssetRangeHashes retrieves the hashes of the sub-ranges of a range in a bucket
+func (m *Manager) GetAssetRangeHashes(ctx context.Context, r types.AssetRange) (map[uint32]string, error) {
+	return m.assetsView.getRangeHashes(ctx, r)
+}
+
+// GetAssetsInRange retrieves the assets in a range of a bucket
+func (m *Manager) GetAssetsInRange(ctx context.Context, r types.AssetRange) ([]cid.Cid, error) {
+	hashes, err := m.assetsView.getAssetsOfRange(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	cids := make([]cid.Cid, 0, len(hashes))
+	for _, h := range hashes {
+		cids = append(cids, cid.NewCidV0(h))
+	}
+	return cids, nil
+}
+
 // AddAssetToView adds an asset to the assets view
 func (m *Manager) AddAssetToView(ctx context.Context, root cid.Cid) error {
 	return m.assetsView.addAsset(ctx, root)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-libipfs/blocks"
 )
@@ -29,6 +30,8 @@ type Storage interface {
 	GetTopHash(ctx context.Context) (string, error)
 	GetBucketHashes(ctx context.Context) (map[uint32]string, error)
 	GetAssetsInBucket(ctx context.Context, bucketID uint32) ([]cid.Cid, error)
+	GetAssetRangeHashes(ctx context.Context, r types.AssetRange) (map[uint32]string, error)
+	GetAssetsInRange(ctx context.Context, r types.AssetRange) ([]cid.Cid, error)
 	AddAssetToView(ctx context.Context, root cid.Cid) error
 	RemoveAssetFromView(ctx context.Context, root cid.Cid) error
 
@@ -13,6 +13,7 @@ import (
 	"github.com/Filecoin-Titan/titan/node/scheduler"
 	"github.com/Filecoin-Titan/titan/node/scheduler/assets"
 	"github.com/Filecoin-Titan/titan/node/scheduler/db"
+	"github.com/Filecoin-Titan/titan/node/scheduler/nat"
 	"github.com/Filecoin-Titan/titan/node/scheduler/node"
 	"github.com/Filecoin-Titan/titan/node/scheduler/sync"
 	"github.com/Filecoin-Titan/titan/node/scheduler/validation"
@@ -62,8 +63,9 @@ func ConfigScheduler(c interface{}) Option {
 		Override(new(dtypes.SessionCallbackFunc), node.KeepaliveCallBackFunc),
 		Override(new(dtypes.MetadataDS), modules.Datastore),
 		Override(new(*assets.Manager), modules.NewStorageManager),
-		Override(new(*sync.DataSync), sync.NewDataSync),
+		Override(new(*sync.DataSync), modules.NewSchedulerDataSync),
 		Override(new(*validation.Manager), modules.NewValidation),
+		Override(new(*nat.Manager), modules.NewNATManager),
 		Override(new(*scheduler.EdgeUpdateManager), scheduler.NewEdgeUpdateManager),
 		Override(new(dtypes.DatabaseAddress), func() dtypes.DatabaseAddress {
 			return dtypes.DatabaseAddress(cfg.DatabaseAddress)
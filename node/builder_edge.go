@@ -50,6 +50,7 @@ func ConfigEdge(c interface{}) Option {
 	return Options(
 		Override(new(*config.EdgeCfg), cfg),
 		Override(new(*device.Device), modules.NewDevice(cfg.BandwidthUp, cfg.BandwidthDown)),
+		Override(new(*device.StatsReporter), modules.NewStatsReporter),
 		Override(new(dtypes.NodeMetadataPath), dtypes.NodeMetadataPath(cfg.MetadataPath)),
 		Override(new(*storage.Manager), modules.NewNodeStorageManager),
 		Override(new(*asset.Manager), modules.NewAssetsManager(cfg.FetchBatch)),
@@ -24,8 +24,8 @@ type NodeValidatedResulter interface {
 }
 
 // newBlockWaiter creates a new blockWaiter instance
-func newBlockWaiter(nodeID string, ch chan tcpMsg, duration int, resulter NodeValidatedResulter) *blockWaiter {
-	bw := &blockWaiter{ch: ch, duration: duration, result: &api.ValidationResult{NodeID: nodeID}, NodeValidatedResulter: resulter}
+func newBlockWaiter(nodeID, roundID string, ch chan tcpMsg, duration int, resulter NodeValidatedResulter) *blockWaiter {
+	bw := &blockWaiter{ch: ch, duration: duration, result: &api.ValidationResult{NodeID: nodeID, RoundID: roundID}, NodeValidatedResulter: resulter}
 	go bw.wait()
 
 	return bw
@@ -34,9 +34,10 @@ type Candidate struct {
 	*vd.Validation
 	*datasync.DataSync
 
-	Scheduler api.Scheduler
-	Config    *config.CandidateCfg
-	TCPSrv    *TCPServer
+	Scheduler     api.Scheduler
+	Config        *config.CandidateCfg
+	TCPSrv        *TCPServer
+	StatsReporter *device.StatsReporter
 }
 
 // WaitQuiet does nothing and returns nil error.
@@ -3,14 +3,24 @@ package candidate
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
 	"sync"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api"
 	"github.com/Filecoin-Titan/titan/node/config"
+	titanrsa "github.com/Filecoin-Titan/titan/node/rsa"
+	vd "github.com/Filecoin-Titan/titan/node/validation"
 )
 
 type tcpMsg struct {
@@ -19,21 +29,47 @@ type tcpMsg struct {
 	length  int
 }
 
+const (
+	// handshakeTimeout is the timeout of the handshake with the validated node
+	handshakeTimeout = 10 * time.Second
+	// nonceLength is the length of the challenge nonce
+	nonceLength = 32
+)
+
 // TCPServer handles incoming TCP connections from devices.
 type TCPServer struct {
 	schedulerAPI   api.Scheduler
 	config         *config.CandidateCfg
 	blockWaiterMap *sync.Map
-	listen         *net.TCPListener
+	listen         net.Listener
+	tlsConfig      *tls.Config // nil if the tcp server is not wrapped with tls
+	certHash       string      // the fingerprint of the tls certificate, the validated nodes pin it
 }
 
 // NewTCPServer initializes a new instance of TCPServer.
-func NewTCPServer(cfg *config.CandidateCfg, schedulerAPI api.Scheduler) *TCPServer {
-	return &TCPServer{
+func NewTCPServer(cfg *config.CandidateCfg, schedulerAPI api.Scheduler) (*TCPServer, error) {
+	t := &TCPServer{
 		config:         cfg,
 		blockWaiterMap: &sync.Map{},
 		schedulerAPI:   schedulerAPI,
 	}
+
+	if cfg.TCPSrvTLS {
+		tlsConfig, err := newTLSConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("new tls config error %w", err)
+		}
+
+		t.tlsConfig = tlsConfig
+		t.certHash = vd.CertificateHash(tlsConfig.Certificates[0].Certificate[0])
+	}
+
+	return t, nil
+}
+
+// CertificateHash returns the fingerprint of the tls certificate of the tcp server, it is empty if tls is disabled
+func (t *TCPServer) CertificateHash() string {
+	return t.certHash
 }
 
 // StartTCPServer starts listening for incoming TCP connections.
@@ -43,18 +79,24 @@ func (t *TCPServer) StartTCPServer() {
 		log.Fatal(err)
 	}
 
-	listen, err := net.ListenTCP("tcp", tcpAddr)
+	var listen net.Listener
+	listen, err = net.ListenTCP("tcp", tcpAddr)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if t.tlsConfig != nil {
+		listen = tls.NewListener(listen, t.tlsConfig)
+	}
+
 	t.listen = listen
 	// close listener
 	defer listen.Close() //nolint:errcheck // ignore error
 
-	log.Infof("tcp server listen on %s", t.config.TCPSrvAddr)
+	log.Infof("tcp server listen on %s, tls %v", t.config.TCPSrvAddr, t.config.TCPSrvTLS)
 
 	for {
-		conn, err := listen.AcceptTCP()
+		conn, err := listen.Accept()
 		if err != nil {
 			log.Errorf("tcp server close %s", err.Error())
 			return
@@ -69,31 +111,54 @@ func (t *TCPServer) Stop(ctx context.Context) error {
 	return t.listen.Close()
 }
 
+// newTLSConfig returns the tls config of the tcp server, it uses a self sign certificate if the certificate is not set
+func newTLSConfig(cfg *config.CandidateCfg) (*tls.Config, error) {
+	if cfg.CertificatePath != "" && cfg.PrivateKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertificatePath, cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}, nil
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	template := x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().AddDate(10, 0, 0)}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}, nil
+}
+
 // handleMessage handles incoming TCP messages from devices.
-func (t *TCPServer) handleMessage(conn *net.TCPConn) {
+func (t *TCPServer) handleMessage(conn net.Conn) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Infof("handleMessage recovered. Error:\n", r)
 			return
 		}
 	}()
+	defer conn.Close() //nolint:errcheck // ignore error
 
-	// first item is device id
-	msg, err := readTCPMsg(conn)
+	// the node must prove it owns the private key of the node id
+	auth, err := t.handshake(conn)
 	if err != nil {
-		log.Errorf("read nodeID error:%v", err)
-		return
-	}
-
-	if msg.msgType != api.TCPMsgTypeNodeID {
-		log.Errorf("read tcp msg error, msg type not TCPMsgTypeNodeID")
-		return
-	}
-	nodeID := string(msg.msg)
-	if len(nodeID) == 0 {
-		log.Errorf("nodeID is empty")
+		log.Errorf("handshake with %s error:%v", conn.RemoteAddr().String(), err)
 		return
 	}
+	nodeID := auth.NodeID
 
 	_, ok := t.blockWaiterMap.Load(nodeID)
 	if ok {
@@ -102,7 +167,7 @@ func (t *TCPServer) handleMessage(conn *net.TCPConn) {
 	}
 
 	ch := make(chan tcpMsg, 1)
-	bw := newBlockWaiter(nodeID, ch, t.config.ValidateDuration, t.schedulerAPI)
+	bw := newBlockWaiter(nodeID, auth.RoundID, ch, t.config.ValidateDuration, t.schedulerAPI)
 	t.blockWaiterMap.Store(nodeID, bw)
 
 	defer func() {
@@ -121,7 +186,7 @@ func (t *TCPServer) handleMessage(conn *net.TCPConn) {
 		default:
 		}
 		// next item is file content
-		msg, err = readTCPMsg(conn)
+		msg, err := readTCPMsg(conn)
 		if err != nil {
 			log.Infof("read tcp message error:%v, nodeID:%s", err, nodeID)
 			return
@@ -135,6 +200,72 @@ func (t *TCPServer) handleMessage(conn *net.TCPConn) {
 	}
 }
 
+// handshake sends a challenge to the node and verifies the signature of the reply,
+// the node must be scheduled to this validator in the round
+func (t *TCPServer) handshake(conn net.Conn) (*api.ValidationAuth, error) {
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	if err := writeTCPMsg(conn, api.TCPMsgTypeChallenge, nonce); err != nil {
+		return nil, fmt.Errorf("send challenge error %w", err)
+	}
+
+	msg, err := readTCPMsg(conn)
+	if err != nil {
+		return nil, fmt.Errorf("read auth error %w", err)
+	}
+
+	if msg.msgType != api.TCPMsgTypeAuth {
+		return nil, fmt.Errorf("msg type %d is not auth", msg.msgType)
+	}
+
+	auth := &api.ValidationAuth{}
+	if err := json.Unmarshal(msg.msg, auth); err != nil {
+		return nil, err
+	}
+
+	if len(auth.NodeID) == 0 {
+		return nil, fmt.Errorf("nodeID is empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schedulerAPITimeout*time.Second)
+	defer cancel()
+
+	publicPem, err := t.schedulerAPI.GetValidatableNodePublicKey(ctx, auth.NodeID, auth.RoundID)
+	if err != nil {
+		return nil, fmt.Errorf("node %s is not validatable: %w", auth.NodeID, err)
+	}
+
+	publicKey, err := titanrsa.Pem2PublicKey([]byte(publicPem))
+	if err != nil {
+		return nil, err
+	}
+
+	titanRsa := titanrsa.New(crypto.SHA256, crypto.SHA256.New())
+	if err := titanRsa.VerifySign(publicKey, auth.Sign, vd.AuthContent(auth.RoundID, nonce)); err != nil {
+		return nil, fmt.Errorf("node %s verify sign error %w", auth.NodeID, err)
+	}
+
+	return auth, conn.SetDeadline(time.Time{})
+}
+
+// writeTCPMsg writes a TCP message to a connection.
+func writeTCPMsg(conn net.Conn, msgType api.TCPMsgType, data []byte) error {
+	buf := make([]byte, 5+len(data))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(1+len(data)))
+	buf[4] = uint8(msgType)
+	copy(buf[5:], data)
+
+	_, err := conn.Write(buf)
+	return err
+}
+
 // readTCPMsg reads a TCP message from a connection.
 func readTCPMsg(conn net.Conn) (*tcpMsg, error) {
 	contentLen, err := readContentLen(conn)
@@ -105,6 +105,15 @@ func DefaultSchedulerCfg() *SchedulerCfg {
 		CandidateReplicas:  0,
 		ValidatorRatio:     1,
 		ValidatorBaseBwDn:  100,
+
+		NATReprobeInterval: Duration(time.Hour),
+
+		ValidationInterval:   Duration(30 * time.Minute),
+		ValidationDuration:   10,
+		StorageProofInterval: Duration(5 * time.Minute),
+
+		AssetViewSyncInterval:    Duration(12 * time.Hour),
+		AssetViewSyncConcurrency: 5,
 	}
 }
 
@@ -16,12 +16,24 @@ var Doc = map[string][]DocField{
 
 			Comment: ``,
 		},
+		{
+			Name: "TCPSrvTLS",
+			Type: "bool",
+
+			Comment: `wrap the validation tcp server with tls, use CertificatePath and PrivateKeyPath if they are set, otherwise a self sign certificate`,
+		},
 		{
 			Name: "IpfsAPIURL",
 			Type: "string",
 
 			Comment: ``,
 		},
+		{
+			Name: "ValidateDuration",
+			Type: "int",
+
+			Comment: `seconds`,
+		},
 	},
 	"EdgeCfg": {
 		{
@@ -40,7 +52,13 @@ var Doc = map[string][]DocField{
 			Name: "NodeID",
 			Type: "string",
 
-			Comment: `deivce id`,
+			Comment: `node id`,
+		},
+		{
+			Name: "AreaID",
+			Type: "string",
+
+			Comment: `area id`,
 		},
 		{
 			Name: "Secret",
@@ -49,10 +67,16 @@ var Doc = map[string][]DocField{
 			Comment: `used auth when connect to scheduler`,
 		},
 		{
-			Name: "CarfileStorePath",
+			Name: "MetadataPath",
 			Type: "string",
 
-			Comment: `carfilestore path`,
+			Comment: `metadata path`,
+		},
+		{
+			Name: "AssetsPaths",
+			Type: "[]string",
+
+			Comment: `assets path`,
 		},
 		{
 			Name: "BandwidthUp",
@@ -108,7 +132,13 @@ be used if InsecureSkipVerify is true`,
 			Name: "FetchBlockRetry",
 			Type: "int",
 
-			Comment: `FetchBlockFailedRetry retry when get block failed`,
+			Comment: `FetchBlockRetry retry when get block failed`,
+		},
+		{
+			Name: "FetchBatch",
+			Type: "int",
+
+			Comment: `FetchBatch the number of goroutine to fetch block`,
 		},
 	},
 	"LocatorCfg": {
@@ -130,18 +160,6 @@ be used if InsecureSkipVerify is true`,
 
 			Comment: `geodb path`,
 		},
-		{
-			Name: "DatabaseAddress",
-			Type: "string",
-
-			Comment: `mysql db address`,
-		},
-		{
-			Name: "UUID",
-			Type: "string",
-
-			Comment: `uuid`,
-		},
 		{
 			Name: "InsecureSkipVerify",
 			Type: "bool",
@@ -153,14 +171,14 @@ be used if InsecureSkipVerify is true`,
 			Type: "string",
 
 			Comment: `used for http3 server
-be used if InsecureSkipVerify is true`,
+be used if InsecureSkipVerify is false`,
 		},
 		{
 			Name: "PrivateKeyPath",
 			Type: "string",
 
 			Comment: `used for http3 server
-be used if InsecureSkipVerify is true`,
+be used if InsecureSkipVerify is false`,
 		},
 		{
 			Name: "CaCertificatePath",
@@ -168,10 +186,16 @@ be used if InsecureSkipVerify is true`,
 
 			Comment: `self sign certificate, use for client`,
 		},
+		{
+			Name: "EtcdAddresses",
+			Type: "[]string",
+
+			Comment: `etcd server addresses`,
+		},
 	},
 	"SchedulerCfg": {
 		{
-			Name: "ExternalAddress",
+			Name: "ExternalURL",
 			Type: "string",
 
 			Comment: `host external address and port`,
@@ -233,7 +257,25 @@ be used if InsecureSkipVerify is true`,
 			Comment: `test nat type`,
 		},
 		{
-			Name: "EnableValidate",
+			Name: "NATProbePrimaryAddress",
+			Type: "string",
+
+			Comment: `Primary address of the NAT probe service, e.g. 1.2.3.4:3478, the NAT probe service is not started if it is empty`,
+		},
+		{
+			Name: "NATProbeAlternateAddress",
+			Type: "string",
+
+			Comment: `Alternate address of the NAT probe service, it must have a different port, and a different IP to tell the full cone NAT apart`,
+		},
+		{
+			Name: "NATReprobeInterval",
+			Type: "Duration",
+
+			Comment: `Interval of the NAT type re-detection of the online edge nodes, the node is re-detected at once if its address changes`,
+		},
+		{
+			Name: "EnableValidation",
 			Type: "bool",
 
 			Comment: `config to enabled node validation, default: true`,
@@ -245,10 +287,76 @@ be used if InsecureSkipVerify is true`,
 			Comment: `etcd server addresses`,
 		},
 		{
-			Name: "CandidateReplicaCachesCount",
+			Name: "CandidateReplicas",
+			Type: "int",
+
+			Comment: `Number of candidate node replicas (does not contain 'seed')`,
+		},
+		{
+			Name: "ValidatorRatio",
+			Type: "float64",
+
+			Comment: `Proportion of validator in candidate nodes (0 ~ 1)`,
+		},
+		{
+			Name: "ValidatorBaseBwDn",
+			Type: "int",
+
+			Comment: `The base downstream bandwidth per validator window (unit : MiB)`,
+		},
+		{
+			Name: "RandomnessBeaconURL",
+			Type: "string",
+
+			Comment: `drand-style randomness beacon of the validation seed, e.g. https://api.drand.sh, use a local beacon if it is empty`,
+		},
+		{
+			Name: "RandomnessBeaconPublicKey",
+			Type: "string",
+
+			Comment: `hex of the compressed public key of the beacon chain, the beacon entries are verified with it`,
+		},
+		{
+			Name: "RandomnessBeaconSecret",
+			Type: "string",
+
+			Comment: `secret of the local beacon, a random secret is used if it is empty`,
+		},
+		{
+			Name: "ValidationInterval",
+			Type: "Duration",
+
+			Comment: `Interval of the bandwidth validation of a node, suspicious nodes are validated more often and trusted nodes less often`,
+		},
+		{
+			Name: "ValidationDuration",
+			Type: "int",
+
+			Comment: `Duration of a bandwidth validation (unit : second)`,
+		},
+		{
+			Name: "StorageProofInterval",
+			Type: "Duration",
+
+			Comment: `Interval of the storage proofs of the nodes`,
+		},
+		{
+			Name: "ValidationExcludeSelector",
+			Type: "string",
+
+			Comment: `Label selector of the nodes which are not validated, e.g. rack=sz-3, no node is excluded if it is empty`,
+		},
+		{
+			Name: "AssetViewSyncInterval",
+			Type: "Duration",
+
+			Comment: `Interval of the asset view sync of all online nodes`,
+		},
+		{
+			Name: "AssetViewSyncConcurrency",
 			Type: "int",
 
-			Comment: `Cache to the number of candidate nodes (does not contain 'seedCacheCount')`,
+			Comment: `Maximum number of the nodes which sync the asset view at the same time`,
 		},
 	},
 }
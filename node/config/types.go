@@ -48,6 +48,8 @@ type EdgeCfg struct {
 type CandidateCfg struct {
 	EdgeCfg
 	TCPSrvAddr string
+	// wrap the validation tcp server with tls, use CertificatePath and PrivateKeyPath if they are set, otherwise a self sign certificate
+	TCPSrvTLS  bool
 	IpfsAPIURL string
 	// seconds
 	ValidateDuration int
@@ -99,6 +101,12 @@ type SchedulerCfg struct {
 	SchedulerServer1 string
 	// test nat type
 	SchedulerServer2 string
+	// Primary address of the NAT probe service, e.g. 1.2.3.4:3478, the NAT probe service is not started if it is empty
+	NATProbePrimaryAddress string
+	// Alternate address of the NAT probe service, it must have a different port, and a different IP to tell the full cone NAT apart
+	NATProbeAlternateAddress string
+	// Interval of the NAT type re-detection of the online edge nodes, the node is re-detected at once if its address changes
+	NATReprobeInterval Duration
 	// config to enabled node validation, default: true
 	EnableValidation bool
 	// etcd server addresses
@@ -109,4 +117,22 @@ type SchedulerCfg struct {
 	ValidatorRatio float64
 	// The base downstream bandwidth per validator window (unit : MiB)
 	ValidatorBaseBwDn int
+	// drand-style randomness beacon of the validation seed, e.g. https://api.drand.sh, use a local beacon if it is empty
+	RandomnessBeaconURL string
+	// hex of the compressed public key of the beacon chain, the beacon entries are verified with it
+	RandomnessBeaconPublicKey string
+	// secret of the local beacon, a random secret is used if it is empty
+	RandomnessBeaconSecret string
+	// Interval of the bandwidth validation of a node, suspicious nodes are validated more often and trusted nodes less often
+	ValidationInterval Duration
+	// Duration of a bandwidth validation (unit : second)
+	ValidationDuration int
+	// Interval of the storage proofs of the nodes
+	StorageProofInterval Duration
+	// Label selector of the nodes which are not validated, e.g. rack=sz-3, no node is excluded if it is empty
+	ValidationExcludeSelector string
+	// Interval of the asset view sync of all online nodes
+	AssetViewSyncInterval Duration
+	// Maximum number of the nodes which sync the asset view at the same time
+	AssetViewSyncConcurrency int
 }
@@ -0,0 +1,137 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api"
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+const (
+	statsReportInterval    = time.Minute      // Interval of the resource usage reports
+	maxStatsReportInterval = time.Hour        // The interval is doubled up to it while the scheduler does not support the reports
+	statsReportTimeout     = 10 * time.Second // Timeout of a report
+)
+
+// StatsReporter reports the resource usage of the node to the scheduler periodically
+type StatsReporter struct {
+	device    *Device
+	scheduler api.Scheduler
+
+	lastTime      time.Time
+	lastBytesSent uint64
+	lastBytesRecv uint64
+}
+
+// NewStatsReporter creates a new StatsReporter
+func NewStatsReporter(device *Device, scheduler api.Scheduler) *StatsReporter {
+	r := &StatsReporter{device: device, scheduler: scheduler}
+	r.bandwidth()
+
+	return r
+}
+
+// Start reports the resource usage periodically until the context is done
+func (r *StatsReporter) Start(ctx context.Context) {
+	go r.run(ctx)
+}
+
+// run reports the resource usage every interval, the interval is backed off if the scheduler does not support the reports
+func (r *StatsReporter) run(ctx context.Context) {
+	interval := statsReportInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, statsReportTimeout)
+		err := r.scheduler.ReportNodeStats(reqCtx, r.collect())
+		cancel()
+
+		next := nextReportInterval(interval, err)
+		switch {
+		case err == nil:
+		case next == statsReportInterval:
+			log.Warnf("report node stats err:%s", err.Error())
+		case interval == statsReportInterval:
+			log.Warnf("the scheduler does not support the node stats reports, retry in %s", next)
+		default:
+			log.Debugf("report node stats err:%s, retry in %s", err.Error(), next)
+		}
+
+		interval = next
+		timer.Reset(interval)
+	}
+}
+
+// nextReportInterval returns the interval of the next report, it is doubled up to the maximum while the scheduler does not support the reports
+func nextReportInterval(interval time.Duration, err error) time.Duration {
+	if err == nil || !isNotSupported(err) {
+		return statsReportInterval
+	}
+
+	interval *= 2
+	if interval > maxStatsReportInterval {
+		interval = maxStatsReportInterval
+	}
+
+	return interval
+}
+
+// isNotSupported checks whether the scheduler does not have the method
+func isNotSupported(err error) bool {
+	// -32601 is the json rpc error code of the method not found
+	return errors.Is(err, api.ErrNotSupported) || strings.Contains(err.Error(), "(-32601)")
+}
+
+// collect collects the current resource usage of the node
+func (r *StatsReporter) collect() *types.NodeStats {
+	stats := &types.NodeStats{}
+
+	if cpuPercent, err := cpu.Percent(0, false); err != nil {
+		log.Errorf("stat cpu percent error: %s", err.Error())
+	} else if len(cpuPercent) > 0 {
+		stats.CPUUsage = cpuPercent[0]
+	}
+
+	if vmStat, err := mem.VirtualMemory(); err != nil {
+		log.Errorf("stat memory error: %s", err.Error())
+	} else {
+		stats.MemoryUsage = vmStat.UsedPercent
+	}
+
+	_, stats.DiskUsage = r.device.storage.GetDiskUsageStat()
+	stats.BandwidthUp, stats.BandwidthDown = r.bandwidth()
+
+	return stats
+}
+
+// bandwidth returns the upload and download rates of the network interfaces since the last call
+func (r *StatsReporter) bandwidth() (up, down float64) {
+	counters, err := net.IOCounters(false)
+	if err != nil || len(counters) == 0 {
+		return 0, 0
+	}
+
+	now := time.Now()
+	sent, recv := counters[0].BytesSent, counters[0].BytesRecv
+
+	if elapsed := now.Sub(r.lastTime).Seconds(); !r.lastTime.IsZero() && elapsed > 0 && sent >= r.lastBytesSent && recv >= r.lastBytesRecv {
+		up = float64(sent-r.lastBytesSent) / elapsed
+		down = float64(recv-r.lastBytesRecv) / elapsed
+	}
+
+	r.lastTime, r.lastBytesSent, r.lastBytesRecv = now, sent, recv
+	return up, down
+}
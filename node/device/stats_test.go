@@ -0,0 +1,32 @@
+package device
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api"
+	"golang.org/x/xerrors"
+)
+
+func TestNextReportInterval(t *testing.T) {
+	notFound := xerrors.New("RPC error (-32601): method 'titan.ReportNodeStats' not found")
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		err      error
+		expect   time.Duration
+	}{
+		{"success", 8 * time.Minute, nil, statsReportInterval},
+		{"other error", 8 * time.Minute, xerrors.New("timeout"), statsReportInterval},
+		{"not supported", statsReportInterval, api.ErrNotSupported, 2 * statsReportInterval},
+		{"method not found", 4 * time.Minute, notFound, 8 * time.Minute},
+		{"maximum", 45 * time.Minute, notFound, maxStatsReportInterval},
+	}
+
+	for _, tt := range tests {
+		if got := nextReportInterval(tt.interval, tt.err); got != tt.expect {
+			t.Errorf("%s: expect %s, got %s", tt.name, tt.expect, got)
+		}
+	}
+}
@@ -2,13 +2,18 @@ package edge
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api"
 	"github.com/Filecoin-Titan/titan/api/client"
 	"github.com/Filecoin-Titan/titan/api/types"
 	cliutil "github.com/Filecoin-Titan/titan/cli/util"
+	"github.com/Filecoin-Titan/titan/lib/holepunch"
+	"github.com/Filecoin-Titan/titan/lib/natprobe"
 	"github.com/Filecoin-Titan/titan/node/asset"
 	"github.com/Filecoin-Titan/titan/node/common"
 	"github.com/Filecoin-Titan/titan/node/device"
@@ -31,8 +36,9 @@ type Edge struct {
 	*validate.Validation
 	*datasync.DataSync
 
-	PConn        net.PacketConn
-	SchedulerAPI api.Scheduler
+	StatsReporter *device.StatsReporter
+	PConn         net.PacketConn
+	SchedulerAPI  api.Scheduler
 }
 
 // WaitQuiet waits for the edge device to become idle.
@@ -41,9 +47,20 @@ func (edge *Edge) WaitQuiet(ctx context.Context) error {
 	return nil
 }
 
-// ExternalServiceAddress returns the external service address of the scheduler.
+// ExternalServiceAddress returns the address the NAT of the edge device maps its UDP service socket to,
+// the scheduler observes it from a request sent over HTTP/3 from the service socket.
 func (edge *Edge) ExternalServiceAddress(ctx context.Context, schedulerURL string) (string, error) {
-	schedulerAPI, closer, err := client.NewScheduler(ctx, schedulerURL, nil)
+	httpClient, err := cliutil.NewHTTP3Client(edge.PConn, true, "")
+	if err != nil {
+		return "", xerrors.Errorf("new http3 client %w", err)
+	}
+
+	// the quic connections are closed, the service socket is kept
+	if closer, ok := httpClient.Transport.(io.Closer); ok {
+		defer closer.Close() //nolint:errcheck
+	}
+
+	schedulerAPI, closer, err := client.NewSchedulerWithHTTPClient(ctx, schedulerURL, nil, httpClient)
 	if err != nil {
 		return "", err
 	}
@@ -52,6 +69,29 @@ func (edge *Edge) ExternalServiceAddress(ctx context.Context, schedulerURL strin
 	return schedulerAPI.GetExternalAddress(ctx)
 }
 
+// ProbeNATType discovers the NAT type of the edge device with the NAT probe service at the address.
+func (edge *Edge) ProbeNATType(ctx context.Context, probeAddr string) (types.NatType, error) {
+	udpPacketConn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return types.NatTypeUnknow, xerrors.Errorf("list udp %w", err)
+	}
+
+	defer func() {
+		err = udpPacketConn.Close()
+		if err != nil {
+			log.Errorf("udpPacketConn Close err:%s", err.Error())
+		}
+	}()
+
+	result, err := natprobe.NewClient(udpPacketConn).Probe(ctx, probeAddr)
+	if err != nil {
+		return types.NatTypeUnknow, err
+	}
+
+	log.Debugf("probe nat type %s, mapped address %s", result.NATType.String(), result.MappedAddr)
+	return result.NATType, nil
+}
+
 // UserNATPunch checks network connectivity from the edge device to the specified URL.
 func (edge *Edge) UserNATPunch(ctx context.Context, sourceURL string, req *types.NatPunchReq) error {
 	return edge.checkNetworkConnectivity(sourceURL, req.Timeout)
@@ -85,3 +125,76 @@ func (edge *Edge) checkNetworkConnectivity(targetURL string, timeout int) error
 
 	return nil
 }
+
+// EdgeNATPunch punches a hole to the user of the session from the service socket of the edge device,
+// and confirms the hole with an HTTP/3 request to the user.
+func (edge *Edge) EdgeNATPunch(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error) {
+	userAddr, err := net.ResolveUDPAddr("udp", session.UserAddr)
+	if err != nil {
+		return nil, xerrors.Errorf("resolve user address %s: %w", session.UserAddr, err)
+	}
+
+	deadline := session.StartTime.Add(session.Interval * time.Duration(session.Attempts))
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	// the packets open the mapping of the service socket to the user, the user sends to the edge at the same time
+	go func() {
+		if err := holepunch.Send(ctx, edge.PConn, userAddr, session.ID, session.StartTime, session.Interval, session.Attempts); err != nil && ctx.Err() == nil {
+			log.Warnf("send punch packets to %s err:%s", session.UserAddr, err.Error())
+		}
+	}()
+
+	result := &types.NatPunchResult{SessionID: session.ID, Status: types.NatPunchFailed}
+	if err := edge.pingUser(ctx, session); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	// the punch packets are sent from the service socket, so the user reaches the edge at its mapped address
+	result.Status = types.NatPunchSucceeded
+	result.PunchedAddr = session.EdgeAddr
+	return result, nil
+}
+
+// pingUser requests the user with HTTP/3 from the service socket until it responds or the context is done.
+func (edge *Edge) pingUser(ctx context.Context, session *types.NatPunchSession) error {
+	httpClient, err := cliutil.NewHTTP3Client(edge.PConn, true, "")
+	if err != nil {
+		return xerrors.Errorf("new http3 client %w", err)
+	}
+
+	// the quic connections are closed, the service socket is kept
+	if closer, ok := httpClient.Transport.(io.Closer); ok {
+		defer closer.Close() //nolint:errcheck
+	}
+
+	targetURL := fmt.Sprintf("https://%s/ping", session.UserAddr)
+
+	select {
+	case <-time.After(time.Until(session.StartTime)):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := httpClient.Do(req)
+		if err == nil {
+			resp.Body.Close() //nolint:errcheck
+			return nil
+		}
+
+		log.Debugf("ping user %s err:%s", targetURL, err.Error())
+
+		select {
+		case <-time.After(session.Interval):
+		case <-ctx.Done():
+			return xerrors.Errorf("user %s unreachable: %w", session.UserAddr, err)
+		}
+	}
+}
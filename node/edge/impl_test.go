@@ -0,0 +1,86 @@
+package edge
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/node/handler"
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// fakeScheduler returns the remote address of the request as the scheduler does
+type fakeScheduler struct{}
+
+func (s *fakeScheduler) GetExternalAddress(ctx context.Context) (string, error) {
+	return handler.GetRemoteAddr(ctx), nil
+}
+
+func listenUDP(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+
+	return conn
+}
+
+// serveScheduler serves the fake scheduler over HTTP/3, it returns the url of the rpc
+func serveScheduler(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.Register("titan", &fakeScheduler{})
+
+	conn := listenUDP(t)
+	srv := &http3.Server{
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{{Certificate: [][]byte{certDER}, PrivateKey: key}}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), handler.RemoteAddr{}, r.RemoteAddr)
+			rpcServer.ServeHTTP(w, r.WithContext(ctx))
+		}),
+	}
+	t.Cleanup(func() { srv.Close() }) //nolint:errcheck
+
+	go srv.Serve(conn) //nolint:errcheck
+
+	return fmt.Sprintf("https://%s/rpc/v0", conn.LocalAddr().String())
+}
+
+func TestExternalServiceAddress(t *testing.T) {
+	schedulerURL := serveScheduler(t)
+	edge := &Edge{PConn: listenUDP(t)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	addr, err := edge.ExternalServiceAddress(ctx, schedulerURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the request is sent from the service socket, so the scheduler observes the address of the socket
+	if addr != edge.PConn.LocalAddr().String() {
+		t.Errorf("expect the address of the service socket %s, got %s", edge.PConn.LocalAddr().String(), addr)
+	}
+}
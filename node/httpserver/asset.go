@@ -18,4 +18,8 @@ type Asset interface {
 	HasBlock(ctx context.Context, root, block cid.Cid) (bool, error)
 	// GetBlock retrieves a block with the given CID from the asset data for a given root CID.
 	GetBlock(ctx context.Context, root, block cid.Cid) (blocks.Block, error)
+	// GetBlockSize returns the size of a block with the given CID in the asset data for a given root CID.
+	GetBlockSize(ctx context.Context, root, block cid.Cid) (int, error)
+	// OnDeleteAsset registers a handler which is called when the asset data for a given root CID is deleted.
+	OnDeleteAsset(handler func(root cid.Cid))
 }
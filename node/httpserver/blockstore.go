@@ -29,10 +29,9 @@ func (robs *readOnlyBlockStore) Get(ctx context.Context, c cid.Cid) (blocks.Bloc
 	return robs.hs.asset.GetBlock(ctx, robs.root, c)
 }
 
-// GetSize returns the size of the block with the given CID. Since the store is read-only, it always returns 0.
+// GetSize returns the size of the block with the given CID.
 func (robs *readOnlyBlockStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
-	log.Errorf("read only block store, not implement GetSize %s", c.String())
-	return 0, nil
+	return robs.hs.asset.GetBlockSize(ctx, robs.root, c)
 }
 
 // Put stores a block in the block store. Since the store is read-only, it always returns an error.
@@ -0,0 +1,126 @@
+package httpserver
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/Filecoin-Titan/titan/metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	maxSizeOfPathCache = 4096
+	maxSizeOfSizeCache = 4096
+
+	cacheTypePath = "path"
+	cacheTypeSize = "size"
+)
+
+// pathKey is the key of the resolved path cache
+type pathKey struct {
+	root string
+	path string
+}
+
+// sizeKey is the key of the file size cache
+type sizeKey struct {
+	root string
+	file string
+}
+
+// cachedPath is the value of the resolved path cache
+type cachedPath struct {
+	node      cid.Cid
+	root      cid.Cid
+	remainder string
+}
+
+// resolvedCache caches the resolved paths and the file sizes of the assets
+type resolvedCache struct {
+	paths *lru.Cache
+	sizes *lru.Cache
+
+	hits   int64
+	misses int64
+}
+
+// newResolvedCache creates a new resolvedCache.
+func newResolvedCache() *resolvedCache {
+	paths, err := lru.New(maxSizeOfPathCache)
+	if err != nil {
+		log.Panicf("new path cache error %s", err.Error())
+	}
+
+	sizes, err := lru.New(maxSizeOfSizeCache)
+	if err != nil {
+		log.Panicf("new size cache error %s", err.Error())
+	}
+
+	return &resolvedCache{paths: paths, sizes: sizes}
+}
+
+// getPath returns the cached resolved path of the asset
+func (rc *resolvedCache) getPath(asset cid.Cid, p string) (*cachedPath, bool) {
+	v, ok := rc.paths.Get(pathKey{root: asset.Hash().String(), path: p})
+	rc.record(cacheTypePath, ok)
+	if !ok {
+		return nil, false
+	}
+	return v.(*cachedPath), true
+}
+
+// addPath caches the resolved path of the asset
+func (rc *resolvedCache) addPath(asset cid.Cid, p string, rp *cachedPath) {
+	rc.paths.Add(pathKey{root: asset.Hash().String(), path: p}, rp)
+}
+
+// getFileSize returns the cached size of the file in the asset
+func (rc *resolvedCache) getFileSize(asset, file cid.Cid) (int64, bool) {
+	v, ok := rc.sizes.Get(sizeKey{root: asset.Hash().String(), file: file.KeyString()})
+	rc.record(cacheTypeSize, ok)
+	if !ok {
+		return 0, false
+	}
+	return v.(int64), true
+}
+
+// addFileSize caches the size of the file in the asset
+func (rc *resolvedCache) addFileSize(asset, file cid.Cid, size int64) {
+	rc.sizes.Add(sizeKey{root: asset.Hash().String(), file: file.KeyString()}, size)
+}
+
+// removeAsset removes all the cached values of the asset
+func (rc *resolvedCache) removeAsset(asset cid.Cid) {
+	root := asset.Hash().String()
+	for _, key := range rc.paths.Keys() {
+		if k, ok := key.(pathKey); ok && k.root == root {
+			rc.paths.Remove(key)
+		}
+	}
+
+	for _, key := range rc.sizes.Keys() {
+		if k, ok := key.(sizeKey); ok && k.root == root {
+			rc.sizes.Remove(key)
+		}
+	}
+}
+
+// stats returns the number of cache hits and misses
+func (rc *resolvedCache) stats() (hits, misses int64) {
+	return atomic.LoadInt64(&rc.hits), atomic.LoadInt64(&rc.misses)
+}
+
+// record records a cache hit or miss
+func (rc *resolvedCache) record(cacheType string, hit bool) {
+	ctx, _ := tag.New(context.Background(), tag.Upsert(metrics.CacheType, cacheType))
+	if hit {
+		atomic.AddInt64(&rc.hits, 1)
+		stats.Record(ctx, metrics.GatewayCacheHit.M(1))
+	} else {
+		atomic.AddInt64(&rc.misses, 1)
+		stats.Record(ctx, metrics.GatewayCacheMiss.M(1))
+	}
+}
@@ -27,10 +27,18 @@ type Handler struct {
 	hs      *HttpServer
 }
 
-// ServeHTTP checks if the request path starts with the IPFS path prefix and delegates to the appropriate handler
+// ServeHTTP checks if the request is for the subdomain gateway or the request path starts with the IPFS path prefix and delegates to the appropriate handler
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if cidLabel, gatewayHost, ok := parseSubdomainHost(r.Host); ok {
+		h.hs.serveSubdomain(w, r, cidLabel, gatewayHost)
+		return
+	}
+
 	switch {
 	case strings.HasPrefix(r.URL.Path, ipfsPathPrefix):
+		if h.hs.redirectToSubdomain(w, r) {
+			return
+		}
 		h.hs.handler(w, r)
 	default:
 		h.handler.ServeHTTP(w, r)
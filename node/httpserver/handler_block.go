@@ -57,5 +57,5 @@ func (hs *HttpServer) serveRawBlock(w http.ResponseWriter, r *http.Request, cred
 	// If-None-Match+Etag, Content-Length and range requests
 	http.ServeContent(w, r, name, modtime, content)
 
-	// TODO: limit rate and report to scheduler
+	// TODO: limit rate
 }
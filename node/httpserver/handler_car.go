@@ -3,24 +3,125 @@ package httpserver
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"net/http"
 
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/ipfs/go-cid"
+	legacy "github.com/ipfs/go-ipld-legacy"
+	"github.com/ipfs/go-libipfs/blocks"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs"
 	"github.com/ipfs/interface-go-ipfs-core/path"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-car/v2/storage"
+	mh "github.com/multiformats/go-multihash"
 )
 
+const (
+	// dag-scope values of the trustless gateway
+	dagScopeAll    = "all"
+	dagScopeEntity = "entity"
+	dagScopeBlock  = "block"
+
+	// order values of the car content type
+	carOrderDFS     = "dfs"
+	carOrderUnknown = "unk"
+
+	// dups values of the car content type
+	carDupsYes = "y"
+	carDupsNo  = "n"
+)
+
+// carParams represents the parameters of a trustless gateway CAR request
+type carParams struct {
+	version string
+	scope   string
+	order   string
+	dups    bool
+}
+
+// getCarParams parses the CAR parameters from the Accept header params and the url query
+func getCarParams(r *http.Request, formatParams map[string]string) (*carParams, error) {
+	query := r.URL.Query()
+
+	// parameters from the Accept header take precedence over the url query
+	param := func(name, queryName string) string {
+		if v, ok := formatParams[name]; ok {
+			return v
+		}
+		return query.Get(queryName)
+	}
+
+	params := &carParams{version: param("version", "car-version")}
+	switch params.version {
+	case "", "1", "2":
+	default:
+		return nil, fmt.Errorf("not support car version %s", params.version)
+	}
+
+	switch order := param("order", "car-order"); order {
+	case "", carOrderDFS, carOrderUnknown:
+		// blocks are always returned in depth-first order
+		params.order = carOrderDFS
+	default:
+		return nil, fmt.Errorf("not support car order %s", order)
+	}
+
+	switch dups := param("dups", "car-dups"); dups {
+	case "", carDupsNo:
+		params.dups = false
+	case carDupsYes:
+		params.dups = true
+	default:
+		return nil, fmt.Errorf("not support car dups %s", dups)
+	}
+
+	switch scope := query.Get("dag-scope"); scope {
+	case "", dagScopeAll:
+		params.scope = dagScopeAll
+	case dagScopeEntity, dagScopeBlock:
+		params.scope = scope
+	default:
+		return nil, fmt.Errorf("not support dag-scope %s", scope)
+	}
+
+	if params.version == "2" && params.scope != dagScopeAll {
+		return nil, fmt.Errorf("dag-scope %s not support car version 2", params.scope)
+	}
+
+	return params, nil
+}
+
+// contentType returns the Content-Type of the CAR response
+func (p *carParams) contentType() string {
+	dups := carDupsNo
+	if p.dups {
+		dups = carDupsYes
+	}
+	return fmt.Sprintf("%s; version=1; order=%s; dups=%s", formatCar, p.order, dups)
+}
+
+// etag returns a weak etag for the CAR response, the etag depends on the content path and the CAR parameters
+func (p *carParams) etag(contentPath path.Path, c cid.Cid) string {
+	h := fnv.New64a()
+	h.Write([]byte(contentPath.String() + p.contentType() + p.scope)) //nolint:errcheck  // fnv never return error
+	return fmt.Sprintf(`W/"%s.car.%x"`, c.String(), h.Sum64())
+}
+
 // ServeCar handles HTTP requests for serving CAR files
-func (hs *HttpServer) serveCar(w http.ResponseWriter, r *http.Request, credentials *types.Credentials, carVersion string) {
+func (hs *HttpServer) serveCar(w http.ResponseWriter, r *http.Request, credentials *types.Credentials, formatParams map[string]string) {
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
-	switch carVersion {
-	case "": // noop, client does not care about version
-	case "1":
-	case "2":
-	default:
-		http.Error(w, fmt.Sprintf("not support car version %s", carVersion), http.StatusBadRequest)
+	params, err := getCarParams(r, formatParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if params.version == "2" {
+		hs.serveCarV2(ctx, w, r, credentials)
 		return
 	}
 
@@ -30,6 +131,78 @@ func (hs *HttpServer) serveCar(w http.ResponseWriter, r *http.Request, credentia
 		return
 	}
 
+	has, err := hs.asset.AssetExists(root)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !has {
+		http.Error(w, fmt.Sprintf("can not found asset %s", root.String()), http.StatusNotFound)
+		return
+	}
+
+	// record the blocks loaded while resolving the path, the client needs them to verify the path
+	bs := &recordBlockStore{readOnlyBlockStore: &readOnlyBlockStore{hs, root}}
+	contentPath := path.New(r.URL.Path)
+	resolvedPath, err := hs.resolvePathWithBlockStore(ctx, contentPath, bs)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("can not resolved path: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	terminalCID := resolvedPath.Cid()
+
+	// Set Content-Disposition
+	var name string
+	if urlFilename := r.URL.Query().Get("filename"); urlFilename != "" {
+		name = urlFilename
+	} else {
+		name = terminalCID.String() + ".car"
+	}
+	setContentDispositionHeader(w, name, "attachment")
+
+	// Set Cache-Control (same logic as for a regular files)
+	addCacheControlHeaders(w, r, contentPath, terminalCID)
+
+	// Weak Etag W/ because we can't guarantee byte-for-byte identical
+	// responses, but still want to benefit from HTTP Caching. Two CAR
+	// responses for the same CID and selector will be logically equivalent.
+	etag := params.etag(contentPath, terminalCID)
+	w.Header().Set("Etag", etag)
+
+	// Finish early if Etag match
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", params.contentType())
+	w.Header().Set("X-Content-Type-Options", "nosniff") // no funny business in the browsers :^)
+
+	car, err := storage.NewWritable(w, []cid.Cid{resolvedPath.Root()}, carv2.WriteAsCarV1(true), carv2.AllowDuplicatePuts(params.dups))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("create car writer error: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	cw := &carWriter{hs: hs, root: root, car: car, dups: params.dups, visited: make(map[cid.Cid]struct{})}
+	if err = cw.writeDAG(ctx, bs.pathBlocks(terminalCID), terminalCID, params.scope); err != nil {
+		// the response status and header already sent, can only break the stream
+		log.Errorf("write car %s error: %s", contentPath.String(), err.Error())
+		return
+	}
+
+	// TODO: limit rate
+}
+
+// serveCarV2 serves the whole CARv2 file of the asset
+func (hs *HttpServer) serveCarV2(ctx context.Context, w http.ResponseWriter, r *http.Request, credentials *types.Credentials) {
+	root, err := cid.Decode(credentials.AssetCID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decode root cid error: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
 	contentPath := path.New(r.URL.Path)
 	resolvedPath, err := hs.resolvePath(ctx, contentPath, root)
 	if err != nil {
@@ -58,14 +231,6 @@ func (hs *HttpServer) serveCar(w http.ResponseWriter, r *http.Request, credentia
 	}
 	setContentDispositionHeader(w, name, "attachment")
 
-	// Set Cache-Control (same logic as for a regular files)
-	addCacheControlHeaders(w, r, contentPath, rootCID)
-
-	// Weak Etag W/ because we can't guarantee byte-for-byte identical
-	// responses, but still want to benefit from HTTP Caching. Two CAR
-	// responses for the same CID and selector will be logically equivalent,
-	// but when CAR is streamed, then in theory, blocks may arrive from
-	// datastore in non-deterministic order.
 	etag := `W/` + getEtag(r, rootCID)
 	w.Header().Set("Etag", etag)
 
@@ -75,14 +240,16 @@ func (hs *HttpServer) serveCar(w http.ResponseWriter, r *http.Request, credentia
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/vnd.ipld.car; version=1")
+	w.Header().Set("Content-Type", fmt.Sprintf("%s; version=2", formatCar))
 	w.Header().Set("X-Content-Type-Options", "nosniff") // no funny business in the browsers :^)
 
 	modtime := addCacheControlHeaders(w, r, contentPath, rootCID)
+	// addCacheControlHeaders override the Etag, set it again
+	w.Header().Set("Etag", etag)
 
 	reader, err := hs.asset.GetAsset(rootCID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("not support car version %s", carVersion), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("get asset %s error: %s", rootCID.String(), err.Error()), http.StatusInternalServerError)
 		return
 	}
 	defer reader.Close() //nolint:errcheck  // ignore error
@@ -90,5 +257,155 @@ func (hs *HttpServer) serveCar(w http.ResponseWriter, r *http.Request, credentia
 	// If-None-Match+Etag, Content-Length and range requests
 	http.ServeContent(w, r, name, modtime, reader)
 
-	// TODO: limit rate and report to scheduler
+	// TODO: limit rate
+}
+
+// recordBlockStore is a read-only block store which records the order of the loaded blocks
+type recordBlockStore struct {
+	*readOnlyBlockStore
+	loaded []cid.Cid
+}
+
+// Get retrieves a block with a given CID from the block store and records it.
+func (rbs *recordBlockStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := rbs.readOnlyBlockStore.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	rbs.loaded = append(rbs.loaded, c)
+	return blk, nil
+}
+
+// pathBlocks returns the blocks loaded before the terminal block, they are the blocks of the path traversal
+func (rbs *recordBlockStore) pathBlocks(terminal cid.Cid) []cid.Cid {
+	visited := make(map[cid.Cid]struct{})
+	cids := make([]cid.Cid, 0, len(rbs.loaded))
+	for _, c := range rbs.loaded {
+		if c.Equals(terminal) {
+			break
+		}
+		if _, ok := visited[c]; ok {
+			continue
+		}
+		visited[c] = struct{}{}
+		cids = append(cids, c)
+	}
+	return cids
+}
+
+// carWriter writes the blocks of a DAG to a CARv1 stream in depth-first order
+type carWriter struct {
+	hs      *HttpServer
+	root    cid.Cid
+	car     storage.WritableCar
+	dups    bool
+	visited map[cid.Cid]struct{}
+}
+
+// writeDAG writes the path blocks and the blocks of the terminal DAG selected by the dag-scope
+func (cw *carWriter) writeDAG(ctx context.Context, pathBlocks []cid.Cid, terminal cid.Cid, scope string) error {
+	for _, c := range pathBlocks {
+		if _, err := cw.writeBlock(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	switch scope {
+	case dagScopeBlock:
+		_, err := cw.writeBlock(ctx, terminal)
+		return err
+	case dagScopeEntity:
+		return cw.writeEntity(ctx, terminal)
+	default:
+		return cw.writeAll(ctx, terminal)
+	}
+}
+
+// writeBlock writes a block to the car, it returns nil block if the block should not be written again
+func (cw *carWriter) writeBlock(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	// identity blocks are inlined in the cid, no need to send
+	if c.Prefix().MhType == mh.IDENTITY {
+		return nil, nil
+	}
+
+	if _, ok := cw.visited[c]; ok && !cw.dups {
+		return nil, nil
+	}
+	cw.visited[c] = struct{}{}
+
+	blk, err := cw.hs.asset.GetBlock(ctx, cw.root, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cw.car.Put(ctx, c.KeyString(), blk.RawData()); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// writeAll writes the whole DAG of the given cid
+func (cw *carWriter) writeAll(ctx context.Context, c cid.Cid) error {
+	blk, err := cw.writeBlock(ctx, c)
+	if err != nil || blk == nil {
+		return err
+	}
+
+	node, err := legacy.DecodeNode(ctx, blk)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range node.Links() {
+		if err = cw.writeAll(ctx, link.Cid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEntity writes the blocks of the UnixFS entity of the given cid,
+// the whole DAG for files, the HAMT shards for sharded directories and only the block for others
+func (cw *carWriter) writeEntity(ctx context.Context, c cid.Cid) error {
+	blk, err := cw.writeBlock(ctx, c)
+	if err != nil || blk == nil {
+		return err
+	}
+
+	if c.Prefix().Codec != cid.DagProtobuf {
+		return nil
+	}
+
+	node, err := dag.DecodeProtobufBlock(blk)
+	if err != nil {
+		return err
+	}
+
+	fsNode, err := unixfs.FSNodeFromBytes(node.(*dag.ProtoNode).Data())
+	if err != nil {
+		// not a UnixFS node
+		return nil
+	}
+
+	switch fsNode.Type() {
+	case unixfs.TFile, unixfs.TRaw:
+		for _, link := range node.Links() {
+			if err = cw.writeAll(ctx, link.Cid); err != nil {
+				return err
+			}
+		}
+	case unixfs.THAMTShard:
+		// links which name only contains the bucket prefix point to the sub shards
+		prefixLen := len(fmt.Sprintf("%X", fsNode.Fanout()-1))
+		for _, link := range node.Links() {
+			if len(link.Name) != prefixLen {
+				continue
+			}
+			if err = cw.writeEntity(ctx, link.Cid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
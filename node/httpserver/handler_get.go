@@ -3,6 +3,7 @@ package httpserver
 import (
 	"bytes"
 	"crypto"
+	"encoding/base64"
 	"encoding/gob"
 	"encoding/hex"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"mime"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Filecoin-Titan/titan/api/types"
 	titanrsa "github.com/Filecoin-Titan/titan/node/rsa"
@@ -35,6 +37,10 @@ func (hs *HttpServer) getHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rw := &recordResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	defer hs.recordDownload(r, ticket, rw, time.Now())
+	w = rw
+
 	respFormat, formatParams, err := customResponseFormat(r)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("processing the Accept header error: %s", err.Error()), http.StatusBadRequest)
@@ -47,7 +53,7 @@ func (hs *HttpServer) getHandler(w http.ResponseWriter, r *http.Request) {
 	case formatRaw:
 		hs.serveRawBlock(w, r, ticket)
 	case formatCar:
-		hs.serveCar(w, r, ticket, formatParams["version"])
+		hs.serveCar(w, r, ticket, formatParams)
 	case formatTar:
 		hs.serveTAR(w, r, ticket)
 	case formatDagJSON, formatDagCbor:
@@ -69,6 +75,14 @@ func (hs *HttpServer) verifyCredentials(w http.ResponseWriter, r *http.Request)
 		return nil, err
 	}
 
+	// the browsers can not carry the credentials in request body
+	if len(data) == 0 {
+		data, err = credentialsFromToken(w, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	buffer := bytes.NewBuffer(data)
 	dec := gob.NewDecoder(buffer)
 
@@ -110,6 +124,25 @@ func (hs *HttpServer) verifyCredentials(w http.ResponseWriter, r *http.Request)
 	return credentials, nil
 }
 
+// credentialsFromToken returns the encoded credentials from the url query or the cookie,
+// the token of url query is kept in the cookie for the subdomain requests
+func credentialsFromToken(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	token := r.URL.Query().Get(tokenQueryParam)
+	if token != "" {
+		if _, ok := subdomainRoot(r); ok {
+			setTokenCookie(w, r, token)
+		}
+	} else if cookie, err := r.Cookie(tokenCookieName); err == nil {
+		token = cookie.Value
+	}
+
+	if token == "" {
+		return nil, fmt.Errorf("credentials not found")
+	}
+
+	return base64.RawURLEncoding.DecodeString(token)
+}
+
 // customResponseFormat checks the request's Accept header and query parameters to determine the desired response format
 func customResponseFormat(r *http.Request) (mediaType string, params map[string]string, err error) {
 	if formatParam := r.URL.Query().Get("format"); formatParam != "" {
@@ -134,18 +167,21 @@ func customResponseFormat(r *http.Request) (mediaType string, params map[string]
 	// Browsers and other user agents will send Accept header with generic types like:
 	// Accept:text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8
 	// We only care about explciit, vendor-specific content-types.
-	for _, accept := range r.Header.Values("Accept") {
-		// respond to the very first ipld content type
-		if strings.HasPrefix(accept, "application/vnd.ipld") ||
-			strings.HasPrefix(accept, "application/x-tar") ||
-			strings.HasPrefix(accept, "application/json") ||
-			strings.HasPrefix(accept, "application/cbor") ||
-			strings.HasPrefix(accept, "application/vnd.ipfs") {
-			mediatype, params, err := mime.ParseMediaType(accept)
-			if err != nil {
-				return "", nil, err
+	for _, header := range r.Header.Values("Accept") {
+		for _, accept := range strings.Split(header, ",") {
+			accept = strings.TrimSpace(accept)
+			// respond to the very first ipld content type
+			if strings.HasPrefix(accept, "application/vnd.ipld") ||
+				strings.HasPrefix(accept, "application/x-tar") ||
+				strings.HasPrefix(accept, "application/json") ||
+				strings.HasPrefix(accept, "application/cbor") ||
+				strings.HasPrefix(accept, "application/vnd.ipfs") {
+				mediatype, params, err := mime.ParseMediaType(accept)
+				if err != nil {
+					return "", nil, err
+				}
+				return mediatype, params, nil
 			}
-			return mediatype, params, nil
 		}
 	}
 	return "", nil, nil
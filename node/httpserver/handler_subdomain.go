@@ -0,0 +1,169 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multibase"
+)
+
+const (
+	// subdomainNamespace is the namespace label of the subdomain gateway, {cid}.ipfs.{gateway-host}
+	subdomainNamespace = "ipfs"
+	// maxDNSLabelLength is the max length of a DNS label
+	maxDNSLabelLength = 63
+	// tokenQueryParam is the url query which carries the credentials for the browsers
+	tokenQueryParam = "token"
+	// tokenCookieName is the cookie which keeps the credentials in the origin of the asset
+	tokenCookieName = "titan-token"
+)
+
+type contextKey string
+
+// subdomainContextKey is the context key of the root CID in a subdomain request
+const subdomainContextKey = contextKey("subdomain")
+
+// parseSubdomainHost parses the host of a subdomain gateway request,
+// it returns the CID label and the gateway host, {cid}.ipfs.{gateway-host}
+func parseSubdomainHost(host string) (cidLabel string, gatewayHost string, ok bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	labels := strings.SplitN(host, ".", 3)
+	if len(labels) < 3 || labels[1] != subdomainNamespace || labels[0] == "" || labels[2] == "" {
+		return "", "", false
+	}
+
+	return labels[0], labels[2], true
+}
+
+// isDomainHost checks whether the host is a domain name which supports the subdomain gateway
+func isDomainHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if host == "" || host == "localhost" || net.ParseIP(host) != nil {
+		return false
+	}
+	return strings.Contains(host, ".")
+}
+
+// toDNSLabel converts the CID to the case-insensitive base32 CIDv1 which fits in a DNS label
+func toDNSLabel(c cid.Cid) (string, error) {
+	label, err := cid.NewCidV1(c.Type(), c.Hash()).StringOfBase(multibase.Base32)
+	if err != nil {
+		return "", err
+	}
+
+	if len(label) > maxDNSLabelLength {
+		return "", fmt.Errorf("cid %s is too long for a DNS label", label)
+	}
+	return label, nil
+}
+
+// subdomainURL returns the url of the subdomain gateway for the given CID and path
+func subdomainURL(r *http.Request, c cid.Cid, gatewayHost, p string) (string, error) {
+	label, err := toDNSLabel(c)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	u := *r.URL
+	u.Scheme = scheme
+	u.Host = fmt.Sprintf("%s.%s.%s", label, subdomainNamespace, gatewayHost)
+	u.Path = p
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// serveSubdomain serves the requests of the subdomain gateway, every asset has its own origin
+func (hs *HttpServer) serveSubdomain(w http.ResponseWriter, r *http.Request, cidLabel, gatewayHost string) {
+	root, err := cid.Decode(cidLabel)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid cid %s: %s", cidLabel, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// the DNS labels are case-insensitive, redirect to the canonical base32 CIDv1
+	label, err := toDNSLabel(root)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if label != cidLabel {
+		u, err := subdomainURL(r, root, gatewayHost, r.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Redirect(w, r, u, http.StatusMovedPermanently)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), subdomainContextKey, root)
+	r = r.Clone(ctx)
+	r.URL.Path = ipfsPathPrefix + "/" + root.String() + r.URL.Path
+	r.URL.RawPath = ""
+
+	hs.handler(w, r)
+}
+
+// redirectToSubdomain redirects the browser requests of the path gateway to the subdomain gateway,
+// so that every asset is isolated in its own origin. It returns true if the request has been redirected.
+func (hs *HttpServer) redirectToSubdomain(w http.ResponseWriter, r *http.Request) bool {
+	// only the browsers carry the credentials in url query, the other clients carry them in request body
+	if r.Method != http.MethodGet || r.URL.Query().Get(tokenQueryParam) == "" || !isDomainHost(r.Host) {
+		return false
+	}
+
+	segments := strings.SplitN(strings.TrimPrefix(r.URL.Path, ipfsPathPrefix+"/"), "/", 2)
+	root, err := cid.Decode(segments[0])
+	if err != nil {
+		return false
+	}
+
+	p := "/"
+	if len(segments) > 1 {
+		p += segments[1]
+	}
+
+	u, err := subdomainURL(r, root, r.Host, p)
+	if err != nil {
+		log.Debugf("can not redirect to subdomain: %s", err.Error())
+		return false
+	}
+
+	http.Redirect(w, r, u, http.StatusMovedPermanently)
+	return true
+}
+
+// subdomainRoot returns the root CID of the subdomain request
+func subdomainRoot(r *http.Request) (cid.Cid, bool) {
+	root, ok := r.Context().Value(subdomainContextKey).(cid.Cid)
+	return root, ok
+}
+
+// setTokenCookie keeps the credentials token in a host-only cookie,
+// so that the sub-resources of the asset can be loaded from the same origin without the token in url
+func setTokenCookie(w http.ResponseWriter, r *http.Request, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
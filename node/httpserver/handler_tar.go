@@ -92,5 +92,5 @@ func (hs *HttpServer) serveTAR(w http.ResponseWriter, r *http.Request, credentia
 		return
 	}
 
-	// TODO: limit rate and report to scheduler
+	// TODO: limit rate
 }
@@ -24,6 +24,9 @@ func (hs *HttpServer) serveUnixFS(w http.ResponseWriter, r *http.Request, creden
 	contentPath := path.New(r.URL.Path)
 	resolvedPath, err := hs.resolvePath(ctx, contentPath, root)
 	if err != nil {
+		if hs.serveRedirects(w, r, credentials, root) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("can not resolved path: %s", err.Error()), http.StatusBadRequest)
 		return
 	}
@@ -41,10 +41,14 @@ func (hs *HttpServer) serveFile(w http.ResponseWriter, r *http.Request, credenti
 	name := addContentDispositionHeader(w, r, contentPath)
 
 	// Prepare size value for Content-Length HTTP header (set inside of http.ServeContent)
-	size, err := file.Size()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("cannot serve files with unknown sizes: %s", err.Error()), http.StatusBadGateway)
-		return
+	size, ok := hs.cache.getFileSize(root, resolvedPath.Cid())
+	if !ok {
+		size, err = file.Size()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("cannot serve files with unknown sizes: %s", err.Error()), http.StatusBadGateway)
+			return
+		}
+		hs.cache.addFileSize(root, resolvedPath.Cid(), size)
 	}
 
 	if size == 0 {
@@ -10,6 +10,7 @@ import (
 	"github.com/ipfs/go-blockservice"
 	"github.com/ipfs/go-cid"
 	bsfetcher "github.com/ipfs/go-fetcher/impl/blockservice"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	"github.com/ipfs/go-libipfs/files"
 	dag "github.com/ipfs/go-merkledag"
 	ipfspath "github.com/ipfs/go-path"
@@ -28,11 +29,19 @@ type HttpServer struct {
 	scheduler          api.Scheduler
 	privateKey         *rsa.PrivateKey
 	schedulerPublicKey *rsa.PublicKey
+	cache              *resolvedCache
+	recorder           *downloadRecorder
 }
 
 // NewHttpServer creates a new HttpServer with the given Asset, Scheduler, and RSA private key.
 func NewHttpServer(asset Asset, scheduler api.Scheduler, privateKey *rsa.PrivateKey) *HttpServer {
-	hs := &HttpServer{asset: asset, scheduler: scheduler, privateKey: privateKey}
+	hs := &HttpServer{asset: asset, scheduler: scheduler, privateKey: privateKey, cache: newResolvedCache()}
+	asset.OnDeleteAsset(hs.cache.removeAsset)
+
+	if scheduler != nil {
+		hs.recorder = newDownloadRecorder(scheduler)
+		go hs.recorder.run()
+	}
 
 	return hs
 }
@@ -43,7 +52,23 @@ func (hs *HttpServer) SetSchedulerPublicKey(publicKey *rsa.PublicKey) {
 }
 
 // resolvePath resolves an IPFS path to a ResolvedPath, given the asset CID.
+// The resolved result is cached until the asset is deleted.
 func (hs *HttpServer) resolvePath(ctx context.Context, p path.Path, asset cid.Cid) (path.Resolved, error) {
+	if cp, ok := hs.cache.getPath(asset, p.String()); ok {
+		return path.NewResolvedPath(ipfspath.Path(p.String()), cp.node, cp.root, cp.remainder), nil
+	}
+
+	resolved, err := hs.resolvePathWithBlockStore(ctx, p, &readOnlyBlockStore{hs, asset})
+	if err != nil {
+		return nil, err
+	}
+
+	hs.cache.addPath(asset, p.String(), &cachedPath{node: resolved.Cid(), root: resolved.Root(), remainder: resolved.Remainder()})
+	return resolved, nil
+}
+
+// resolvePathWithBlockStore resolves an IPFS path to a ResolvedPath, loading the blocks from the given block store.
+func (hs *HttpServer) resolvePathWithBlockStore(ctx context.Context, p path.Path, bs blockstore.Blockstore) (path.Resolved, error) {
 	if _, ok := p.(path.Resolved); ok {
 		return p.(path.Resolved), nil
 	}
@@ -56,7 +81,7 @@ func (hs *HttpServer) resolvePath(ctx context.Context, p path.Path, asset cid.Ci
 		return nil, fmt.Errorf("unsupported path namespace: %s", p.Namespace())
 	}
 
-	fetcherFactory := bsfetcher.NewFetcherConfig(blockservice.New(&readOnlyBlockStore{hs, asset}, nil))
+	fetcherFactory := bsfetcher.NewFetcherConfig(blockservice.New(bs, nil))
 	fetcherFactory.PrototypeChooser = dagpb.AddSupportToChooser(func(lnk ipldprime.Link, lnkCtx ipldprime.LinkContext) (ipldprime.NodePrototype, error) {
 		if tlnkNd, ok := lnkCtx.LinkNode.(schema.TypedLinkNode); ok {
 			return tlnkNd.LinkTargetNodePrototype(), nil
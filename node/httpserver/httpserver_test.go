@@ -2,13 +2,28 @@ package httpserver
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Filecoin-Titan/titan/api"
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/Filecoin-Titan/titan/node/asset"
 	"github.com/Filecoin-Titan/titan/node/asset/fetcher"
 	"github.com/Filecoin-Titan/titan/node/asset/storage"
+	"github.com/google/uuid"
 	"github.com/ipfs/go-cid"
+	ipldformat "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-libipfs/blocks"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs"
 	"github.com/ipfs/interface-go-ipfs-core/path"
+	carv2 "github.com/ipld/go-car/v2"
 )
 
 func TestGateway(t *testing.T) {
@@ -42,7 +57,7 @@ func TestResolvePath(t *testing.T) {
 		return
 	}
 
-	hs := &HttpServer{asset: mgr}
+	hs := NewHttpServer(mgr, nil, nil)
 
 	resolvePath, err := hs.resolvePath(context.Background(), path.New(p), assetCID)
 	if err != nil {
@@ -52,3 +67,714 @@ func TestResolvePath(t *testing.T) {
 
 	t.Logf("root: %s, cid: %s, rest:%v", resolvePath.Root().String(), resolvePath.Cid().String(), resolvePath.Remainder())
 }
+
+// memoryAsset is an in-memory Asset implementation for tests
+type memoryAsset struct {
+	root           cid.Cid
+	blocks         map[cid.Cid]blocks.Block
+	gets           int
+	deleteHandlers []func(root cid.Cid)
+}
+
+func (ma *memoryAsset) GetAsset(root cid.Cid) (io.ReadSeekCloser, error) {
+	return nil, fmt.Errorf("not implement")
+}
+
+func (ma *memoryAsset) AssetExists(root cid.Cid) (bool, error) {
+	return root.Equals(ma.root), nil
+}
+
+func (ma *memoryAsset) HasBlock(ctx context.Context, root, block cid.Cid) (bool, error) {
+	_, ok := ma.blocks[block]
+	return ok, nil
+}
+
+func (ma *memoryAsset) GetBlock(ctx context.Context, root, block cid.Cid) (blocks.Block, error) {
+	ma.gets++
+	blk, ok := ma.blocks[block]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", block.String())
+	}
+	return blk, nil
+}
+
+func (ma *memoryAsset) GetBlockSize(ctx context.Context, root, block cid.Cid) (int, error) {
+	blk, ok := ma.blocks[block]
+	if !ok {
+		return 0, fmt.Errorf("block %s not found", block.String())
+	}
+	return len(blk.RawData()), nil
+}
+
+func (ma *memoryAsset) OnDeleteAsset(handler func(root cid.Cid)) {
+	ma.deleteHandlers = append(ma.deleteHandlers, handler)
+}
+
+func (ma *memoryAsset) deleteAsset(root cid.Cid) {
+	for _, handler := range ma.deleteHandlers {
+		handler(root)
+	}
+}
+
+func (ma *memoryAsset) add(node ipldformat.Node) cid.Cid {
+	ma.blocks[node.Cid()] = node
+	return node.Cid()
+}
+
+// newFileNode creates a UnixFS file node linked to the given leaves
+func newFileNode(t *testing.T, leaves ...*dag.RawNode) *dag.ProtoNode {
+	fsNode := unixfs.NewFSNode(unixfs.TFile)
+	node := &dag.ProtoNode{}
+	for _, leaf := range leaves {
+		fsNode.AddBlockSize(uint64(len(leaf.RawData())))
+		if err := node.AddNodeLink("", leaf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := fsNode.GetBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.SetData(data)
+	return node
+}
+
+// newTestDAG creates the DAG: dir{a.txt: file[hello, world], dup.txt: file[dup, dup]}
+func newTestDAG(t *testing.T) (*memoryAsset, map[string]cid.Cid) {
+	ma := &memoryAsset{blocks: make(map[cid.Cid]blocks.Block)}
+	cids := make(map[string]cid.Cid)
+
+	hello := dag.NewRawNode([]byte("hello "))
+	world := dag.NewRawNode([]byte("world"))
+	dup := dag.NewRawNode([]byte("dup"))
+	cids["hello"] = ma.add(hello)
+	cids["world"] = ma.add(world)
+	cids["dup"] = ma.add(dup)
+
+	fileA := newFileNode(t, hello, world)
+	fileDup := newFileNode(t, dup, dup)
+	cids["a.txt"] = ma.add(fileA)
+	cids["dup.txt"] = ma.add(fileDup)
+
+	dir := dag.NodeWithData(unixfs.FolderPBData())
+	if err := dir.AddNodeLink("a.txt", fileA); err != nil {
+		t.Fatal(err)
+	}
+	if err := dir.AddNodeLink("dup.txt", fileDup); err != nil {
+		t.Fatal(err)
+	}
+	cids["dir"] = ma.add(dir)
+	ma.root = cids["dir"]
+
+	return ma, cids
+}
+
+func TestServeCar(t *testing.T) {
+	ma, cids := newTestDAG(t)
+	hs := NewHttpServer(ma, nil, nil)
+	root := cids["dir"].String()
+
+	tests := []struct {
+		name        string
+		path        string
+		accept      string
+		status      int
+		contentType string
+		blocks      []string
+	}{
+		{
+			name:        "default params",
+			path:        "/ipfs/" + root,
+			accept:      "application/vnd.ipld.car",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=n",
+			blocks:      []string{"dir", "a.txt", "hello", "world", "dup.txt", "dup"},
+		},
+		{
+			name:        "dfs order without duplicates",
+			path:        "/ipfs/" + root,
+			accept:      "application/vnd.ipld.car; version=1; order=dfs; dups=n",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=n",
+			blocks:      []string{"dir", "a.txt", "hello", "world", "dup.txt", "dup"},
+		},
+		{
+			name:        "dfs order with duplicates",
+			path:        "/ipfs/" + root,
+			accept:      "application/vnd.ipld.car; version=1; order=dfs; dups=y",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=y",
+			blocks:      []string{"dir", "a.txt", "hello", "world", "dup.txt", "dup", "dup"},
+		},
+		{
+			name:        "unknown order answered with dfs",
+			path:        "/ipfs/" + root,
+			accept:      "application/vnd.ipld.car; version=1; order=unk",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=n",
+			blocks:      []string{"dir", "a.txt", "hello", "world", "dup.txt", "dup"},
+		},
+		{
+			name:        "accept list",
+			path:        "/ipfs/" + root,
+			accept:      "application/vnd.ipld.car; version=1; order=dfs; dups=y, application/vnd.ipld.raw",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=y",
+			blocks:      []string{"dir", "a.txt", "hello", "world", "dup.txt", "dup", "dup"},
+		},
+		{
+			name:        "format query params",
+			path:        "/ipfs/" + root + "?format=car&car-dups=y",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=y",
+			blocks:      []string{"dir", "a.txt", "hello", "world", "dup.txt", "dup", "dup"},
+		},
+		{
+			name:        "dag-scope block",
+			path:        "/ipfs/" + root + "/a.txt?dag-scope=block",
+			accept:      "application/vnd.ipld.car",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=n",
+			blocks:      []string{"dir", "a.txt"},
+		},
+		{
+			name:        "dag-scope entity of file",
+			path:        "/ipfs/" + root + "/a.txt?dag-scope=entity",
+			accept:      "application/vnd.ipld.car",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=n",
+			blocks:      []string{"dir", "a.txt", "hello", "world"},
+		},
+		{
+			name:        "dag-scope entity of directory",
+			path:        "/ipfs/" + root + "?dag-scope=entity",
+			accept:      "application/vnd.ipld.car",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=n",
+			blocks:      []string{"dir"},
+		},
+		{
+			name:        "dag-scope all of sub path",
+			path:        "/ipfs/" + root + "/dup.txt?dag-scope=all",
+			accept:      "application/vnd.ipld.car; version=1; dups=y",
+			status:      http.StatusOK,
+			contentType: "application/vnd.ipld.car; version=1; order=dfs; dups=y",
+			blocks:      []string{"dir", "dup.txt", "dup", "dup"},
+		},
+		{
+			name:   "unsupported version",
+			path:   "/ipfs/" + root,
+			accept: "application/vnd.ipld.car; version=3",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unsupported order",
+			path:   "/ipfs/" + root,
+			accept: "application/vnd.ipld.car; version=1; order=bfs",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unsupported dups",
+			path:   "/ipfs/" + root,
+			accept: "application/vnd.ipld.car; version=1; dups=x",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unsupported dag-scope",
+			path:   "/ipfs/" + root + "?dag-scope=subtree",
+			accept: "application/vnd.ipld.car",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "dag-scope with car version 2",
+			path:   "/ipfs/" + root + "?dag-scope=block",
+			accept: "application/vnd.ipld.car; version=2",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			respFormat, formatParams, err := customResponseFormat(r)
+			if err != nil {
+				t.Fatalf("customResponseFormat error: %s", err.Error())
+			}
+			if respFormat != formatCar {
+				t.Fatalf("expect format %s, got %s", formatCar, respFormat)
+			}
+
+			hs.serveCar(w, r, &types.Credentials{AssetCID: root}, formatParams)
+
+			if w.Code != tt.status {
+				t.Fatalf("expect status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("expect content type %s, got %s", tt.contentType, contentType)
+			}
+
+			reader, err := carv2.NewBlockReader(w.Body)
+			if err != nil {
+				t.Fatalf("NewBlockReader error: %s", err.Error())
+			}
+			if len(reader.Roots) != 1 || !reader.Roots[0].Equals(cids["dir"]) {
+				t.Errorf("expect roots [%s], got %v", root, reader.Roots)
+			}
+
+			var got []cid.Cid
+			for {
+				blk, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("read block error: %s", err.Error())
+				}
+				got = append(got, blk.Cid())
+			}
+
+			if len(got) != len(tt.blocks) {
+				t.Fatalf("expect %d blocks, got %d", len(tt.blocks), len(got))
+			}
+			for i, name := range tt.blocks {
+				if !got[i].Equals(cids[name]) {
+					t.Errorf("block %d expect %s(%s), got %s", i, name, cids[name].String(), got[i].String())
+				}
+			}
+		})
+	}
+}
+
+func TestServeCarEtag(t *testing.T) {
+	ma, cids := newTestDAG(t)
+	hs := NewHttpServer(ma, nil, nil)
+	root := cids["dir"].String()
+
+	etag := func(path, accept string) string {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		_, formatParams, _ := customResponseFormat(r)
+		hs.serveCar(w, r, &types.Credentials{AssetCID: root}, formatParams)
+		return w.Header().Get("Etag")
+	}
+
+	noDups := etag("/ipfs/"+root, "application/vnd.ipld.car; version=1; dups=n")
+	dups := etag("/ipfs/"+root, "application/vnd.ipld.car; version=1; dups=y")
+	block := etag("/ipfs/"+root+"?dag-scope=block", "application/vnd.ipld.car; version=1; dups=n")
+
+	if !strings.HasPrefix(noDups, `W/"`) {
+		t.Errorf("expect weak etag, got %s", noDups)
+	}
+	if noDups == dups || noDups == block {
+		t.Errorf("expect different etags for different car params, got %s %s %s", noDups, dups, block)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/ipfs/"+root, nil)
+	r.Header.Set("Accept", "application/vnd.ipld.car; version=1; dups=n")
+	r.Header.Set("If-None-Match", noDups)
+	w := httptest.NewRecorder()
+	_, formatParams, _ := customResponseFormat(r)
+	hs.serveCar(w, r, &types.Credentials{AssetCID: root}, formatParams)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expect status %d, got %d", http.StatusNotModified, w.Code)
+	}
+}
+
+func TestResolvePathCache(t *testing.T) {
+	ma, cids := newTestDAG(t)
+	hs := NewHttpServer(ma, nil, nil)
+	p := path.New("/ipfs/" + cids["dir"].String() + "/a.txt")
+
+	resolved, err := hs.resolvePath(context.Background(), p, ma.root)
+	if err != nil {
+		t.Fatalf("resolvePath error: %s", err.Error())
+	}
+	if !resolved.Cid().Equals(cids["a.txt"]) {
+		t.Fatalf("expect %s, got %s", cids["a.txt"].String(), resolved.Cid().String())
+	}
+	if hits, misses := hs.cache.stats(); hits != 0 || misses != 1 {
+		t.Errorf("expect 0 hits and 1 miss, got %d hits and %d misses", hits, misses)
+	}
+
+	gets := ma.gets
+	cached, err := hs.resolvePath(context.Background(), p, ma.root)
+	if err != nil {
+		t.Fatalf("resolvePath error: %s", err.Error())
+	}
+	if !cached.Cid().Equals(resolved.Cid()) || !cached.Root().Equals(resolved.Root()) || cached.Remainder() != resolved.Remainder() {
+		t.Errorf("expect cached path %s, got %s", resolved.String(), cached.String())
+	}
+	if ma.gets != gets {
+		t.Errorf("expect no block loaded for cached path, got %d", ma.gets-gets)
+	}
+	if hits, misses := hs.cache.stats(); hits != 1 || misses != 1 {
+		t.Errorf("expect 1 hit and 1 miss, got %d hits and %d misses", hits, misses)
+	}
+
+	hs.cache.addFileSize(ma.root, resolved.Cid(), 11)
+	if size, ok := hs.cache.getFileSize(ma.root, resolved.Cid()); !ok || size != 11 {
+		t.Errorf("expect cached size 11, got %d", size)
+	}
+
+	ma.deleteAsset(ma.root)
+
+	if _, ok := hs.cache.getPath(ma.root, p.String()); ok {
+		t.Errorf("expect path cache invalidated after delete asset")
+	}
+	if _, ok := hs.cache.getFileSize(ma.root, resolved.Cid()); ok {
+		t.Errorf("expect size cache invalidated after delete asset")
+	}
+}
+
+func TestBlockStoreGetSize(t *testing.T) {
+	ma, cids := newTestDAG(t)
+	hs := NewHttpServer(ma, nil, nil)
+	bs := &readOnlyBlockStore{hs, ma.root}
+
+	size, err := bs.GetSize(context.Background(), cids["hello"])
+	if err != nil {
+		t.Fatalf("GetSize error: %s", err.Error())
+	}
+	if size != len("hello ") {
+		t.Errorf("expect size %d, got %d", len("hello "), size)
+	}
+}
+
+func TestParseSubdomainHost(t *testing.T) {
+	tests := []struct {
+		host        string
+		cidLabel    string
+		gatewayHost string
+		ok          bool
+	}{
+		{host: "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi.ipfs.edge.titan.io", cidLabel: "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi", gatewayHost: "edge.titan.io", ok: true},
+		{host: "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi.ipfs.localhost:8080", cidLabel: "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi", gatewayHost: "localhost", ok: true},
+		{host: "edge.titan.io", ok: false},
+		{host: "abc.ipns.edge.titan.io", ok: false},
+		{host: "127.0.0.1:1234", ok: false},
+		{host: ".ipfs.edge.titan.io", ok: false},
+	}
+
+	for _, tt := range tests {
+		cidLabel, gatewayHost, ok := parseSubdomainHost(tt.host)
+		if ok != tt.ok || cidLabel != tt.cidLabel || gatewayHost != tt.gatewayHost {
+			t.Errorf("host %s: expect (%s, %s, %v), got (%s, %s, %v)", tt.host, tt.cidLabel, tt.gatewayHost, tt.ok, cidLabel, gatewayHost, ok)
+		}
+	}
+}
+
+func TestSubdomainCanonicalRedirect(t *testing.T) {
+	ma, cids := newTestDAG(t)
+	hs := NewHttpServer(ma, nil, nil)
+	handler := hs.NewHandler(http.NotFoundHandler())
+
+	v0 := cid.NewCidV0(cids["dir"].Hash())
+	r := httptest.NewRequest(http.MethodGet, "http://"+strings.ToLower(v0.String())+".ipfs.edge.titan.io/a.txt", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expect status %d for lower case CIDv0, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	label, err := toDNSLabel(v0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "http://"+v0.String()+".ipfs.edge.titan.io/a.txt", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("expect status %d, got %d", http.StatusMovedPermanently, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "http://"+label+".ipfs.edge.titan.io/a.txt" {
+		t.Errorf("unexpected location %s", location)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "http://edge.titan.io/ipfs/"+v0.String()+"/a.txt?token=abc", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("expect status %d, got %d", http.StatusMovedPermanently, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "http://"+label+".ipfs.edge.titan.io/a.txt?token=abc" {
+		t.Errorf("unexpected location %s", location)
+	}
+}
+
+func TestRedirectRules(t *testing.T) {
+	file := `
+# comment
+/old-page /new-page
+/blog/:year/:slug /posts/:year-:slug 302
+/app/* /app/index.html 200
+/docs/* https://docs.titan.io/:splat 307
+/* /404.html 404
+`
+	rules, err := parseRedirects(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("parseRedirects error: %s", err.Error())
+	}
+	if len(rules) != 5 {
+		t.Fatalf("expect 5 rules, got %d", len(rules))
+	}
+
+	tests := []struct {
+		path   string
+		to     string
+		status int
+	}{
+		{path: "/old-page", to: "/new-page", status: http.StatusMovedPermanently},
+		{path: "/old-page/", to: "/new-page", status: http.StatusMovedPermanently},
+		{path: "/blog/2023/hello", to: "/posts/2023-hello", status: http.StatusFound},
+		{path: "/app/settings/profile", to: "/app/index.html", status: http.StatusOK},
+		{path: "/docs/api/v0", to: "https://docs.titan.io/api/v0", status: http.StatusTemporaryRedirect},
+		{path: "/blog/2023", to: "/404.html", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		var to string
+		var status int
+		for _, rule := range rules {
+			if target, ok := rule.match(tt.path); ok {
+				to, status = target, rule.status
+				break
+			}
+		}
+		if to != tt.to || status != tt.status {
+			t.Errorf("path %s: expect %s %d, got %s %d", tt.path, tt.to, tt.status, to, status)
+		}
+	}
+
+	invalids := []string{
+		"/only-from",
+		"no-slash /to",
+		"/from /to 200 extra",
+		"/from /to abc",
+		"/from /to 500",
+		"/from https://titan.io 200",
+	}
+	for _, invalid := range invalids {
+		if _, err := parseRedirects(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expect error for rule %s", invalid)
+		}
+	}
+}
+
+func TestServeRedirects(t *testing.T) {
+	ma := &memoryAsset{blocks: make(map[cid.Cid]blocks.Block)}
+	index := dag.NodeWithData(unixfs.FilePBData([]byte("<html>app</html>"), 16))
+	notFound := dag.NodeWithData(unixfs.FilePBData([]byte("not found"), 9))
+	redirects := []byte("/old /index.html 301\n/app/* /index.html 200\n/* /404.html 404\n")
+	redirectsFile := dag.NodeWithData(unixfs.FilePBData(redirects, uint64(len(redirects))))
+
+	dir := dag.NodeWithData(unixfs.FolderPBData())
+	for name, node := range map[string]*dag.ProtoNode{"index.html": index, "404.html": notFound, redirectsFileName: redirectsFile} {
+		ma.add(node)
+		if err := dir.AddNodeLink(name, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ma.root = ma.add(dir)
+
+	hs := NewHttpServer(ma, nil, nil)
+	credentials := &types.Credentials{AssetCID: ma.root.String()}
+
+	tests := []struct {
+		name      string
+		path      string
+		subdomain bool
+		status    int
+		body      string
+		location  string
+	}{
+		{name: "existing file", path: "/index.html", subdomain: true, status: http.StatusOK, body: "<html>app</html>"},
+		{name: "spa fallback", path: "/app/settings", subdomain: true, status: http.StatusOK, body: "<html>app</html>"},
+		{name: "redirect", path: "/old", subdomain: true, status: http.StatusMovedPermanently, location: "/index.html"},
+		{name: "custom not found", path: "/missing", subdomain: true, status: http.StatusNotFound, body: "not found"},
+		{name: "path gateway ignores redirects", path: "/app/settings", subdomain: false, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ipfs/"+ma.root.String()+tt.path, nil)
+			if tt.subdomain {
+				r = r.WithContext(context.WithValue(r.Context(), subdomainContextKey, ma.root))
+			}
+			w := httptest.NewRecorder()
+
+			hs.serveUnixFS(w, r, credentials)
+
+			if w.Code != tt.status {
+				t.Fatalf("expect status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("expect body %s, got %s", tt.body, w.Body.String())
+			}
+			if tt.location != "" && w.Header().Get("Location") != tt.location {
+				t.Errorf("expect location %s, got %s", tt.location, w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+// recordScheduler is a scheduler which records the submitted download records
+type recordScheduler struct {
+	api.SchedulerStub
+	submitted [][]*types.DownloadHistory
+	err       error
+}
+
+func (rs *recordScheduler) SubmitDownloadRecords(ctx context.Context, records []*types.DownloadHistory) error {
+	if rs.err != nil {
+		return rs.err
+	}
+	rs.submitted = append(rs.submitted, records)
+	return nil
+}
+
+func TestDownloadRecorder(t *testing.T) {
+	scheduler := &recordScheduler{err: fmt.Errorf("scheduler offline")}
+	dr := newDownloadRecorder(scheduler)
+
+	total := maxDownloadRecordsPerReport + 10
+	for i := 0; i < total; i++ {
+		dr.add(&types.DownloadHistory{ID: fmt.Sprintf("%d", i), AssetCID: "asset"})
+	}
+
+	// the records are kept when the scheduler is unavailable
+	dr.report()
+	if len(dr.records) != total {
+		t.Fatalf("expect %d pending records, got %d", total, len(dr.records))
+	}
+
+	scheduler.err = nil
+	dr.report()
+
+	if len(dr.records) != 0 {
+		t.Errorf("expect no pending records, got %d", len(dr.records))
+	}
+	if len(scheduler.submitted) != 2 {
+		t.Fatalf("expect 2 reports, got %d", len(scheduler.submitted))
+	}
+	if len(scheduler.submitted[0]) != maxDownloadRecordsPerReport || len(scheduler.submitted[1]) != 10 {
+		t.Errorf("unexpected report sizes %d %d", len(scheduler.submitted[0]), len(scheduler.submitted[1]))
+	}
+	if scheduler.submitted[0][0].ID != "0" || scheduler.submitted[1][9].ID != fmt.Sprintf("%d", total-1) {
+		t.Errorf("expect records reported in order")
+	}
+}
+
+// dedupScheduler stores the download records by ID like the scheduler, the response of the first submission is lost
+type dedupScheduler struct {
+	api.SchedulerStub
+	stored    map[string]*types.DownloadHistory
+	submitted int
+}
+
+func (ds *dedupScheduler) SubmitDownloadRecords(ctx context.Context, records []*types.DownloadHistory) error {
+	// the records are sent over JSON-RPC
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	var received []*types.DownloadHistory
+	if err := json.Unmarshal(data, &received); err != nil {
+		return err
+	}
+
+	for _, record := range received {
+		if record.ID == "" {
+			record.ID = uuid.NewString()
+		}
+		if _, ok := ds.stored[record.ID]; !ok {
+			ds.stored[record.ID] = record
+		}
+	}
+
+	ds.submitted++
+	if ds.submitted == 1 {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestDownloadRecorderResubmit(t *testing.T) {
+	scheduler := &dedupScheduler{stored: make(map[string]*types.DownloadHistory)}
+	hs := &HttpServer{recorder: newDownloadRecorder(scheduler)}
+	credentials := &types.Credentials{ID: "credential", AssetCID: "asset", NodeID: "node"}
+
+	r := httptest.NewRequest(http.MethodGet, "/ipfs/bafkqaaa", nil)
+	for i := 0; i < 3; i++ {
+		rw := &recordResponseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+		hs.recordDownload(r, credentials, rw, time.Now())
+	}
+
+	// the batch is restored after the timeout and submitted again
+	hs.recorder.report()
+	hs.recorder.report()
+
+	if scheduler.submitted != 2 {
+		t.Fatalf("expect 2 submissions, got %d", scheduler.submitted)
+	}
+	if len(scheduler.stored) != 3 {
+		t.Errorf("expect 3 stored records, got %d", len(scheduler.stored))
+	}
+}
+
+func TestRecordResponseWriterFlush(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	var w http.ResponseWriter = &recordResponseWriter{ResponseWriter: recorder, status: http.StatusOK}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("expect recordResponseWriter to be a http.Flusher")
+	}
+
+	flusher.Flush()
+	if !recorder.Flushed {
+		t.Error("expect the wrapped writer to be flushed")
+	}
+}
+
+func TestRecordDownload(t *testing.T) {
+	scheduler := &recordScheduler{}
+	hs := &HttpServer{recorder: newDownloadRecorder(scheduler)}
+	credentials := &types.Credentials{ID: "credential", AssetCID: "asset", NodeID: "node"}
+
+	r := httptest.NewRequest(http.MethodGet, "/ipfs/bafkqaaa/a.txt", nil)
+	r.RemoteAddr = "10.0.0.1:5678"
+	rw := &recordResponseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	rw.Write([]byte("hello")) //nolint:errcheck
+	hs.recordDownload(r, credentials, rw, time.Now().Add(-time.Second))
+
+	rw = &recordResponseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	http.Error(rw, "not found", http.StatusNotFound)
+	hs.recordDownload(r, credentials, rw, time.Now())
+
+	if len(hs.recorder.records) != 2 {
+		t.Fatalf("expect 2 records, got %d", len(hs.recorder.records))
+	}
+
+	record := hs.recorder.records[0]
+	if record.CredentialID != "credential" || record.AssetCID != "asset" || record.BlockCID != "bafkqaaa" ||
+		record.BlockSize != 5 || record.ClientIP != "10.0.0.1" || record.Status != types.DownloadStatusSucceeded || record.Duration < 1000 {
+		t.Errorf("unexpected record %#v", record)
+	}
+
+	if failed := hs.recorder.records[1]; failed.Status != types.DownloadStatusFailed || failed.FailedReason == "" {
+		t.Errorf("unexpected failed record %#v", failed)
+	}
+}
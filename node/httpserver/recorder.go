@@ -0,0 +1,197 @@
+package httpserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api"
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// reportDownloadRecordsInterval is the interval of reporting download records to the scheduler
+	reportDownloadRecordsInterval = time.Minute
+	// reportDownloadRecordsTimeout is the timeout of a report
+	reportDownloadRecordsTimeout = 30 * time.Second
+	// maxDownloadRecordsPerReport is the max number of records in a report
+	maxDownloadRecordsPerReport = 500
+	// maxPendingDownloadRecords is the max number of records waiting for report, the oldest records are dropped
+	maxPendingDownloadRecords = 10000
+)
+
+// downloadRecorder records the gateway responses and reports them to the scheduler in batches
+type downloadRecorder struct {
+	scheduler api.Scheduler
+
+	lk      sync.Mutex
+	records []*types.DownloadHistory
+	notify  chan struct{}
+}
+
+// newDownloadRecorder creates a new downloadRecorder.
+func newDownloadRecorder(scheduler api.Scheduler) *downloadRecorder {
+	return &downloadRecorder{
+		scheduler: scheduler,
+		records:   make([]*types.DownloadHistory, 0),
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// run reports the download records periodically or when the records are enough for a report
+func (dr *downloadRecorder) run() {
+	ticker := time.NewTicker(reportDownloadRecordsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-dr.notify:
+		}
+
+		dr.report()
+	}
+}
+
+// add adds a download record to the pending records
+func (dr *downloadRecorder) add(record *types.DownloadHistory) {
+	dr.lk.Lock()
+	defer dr.lk.Unlock()
+
+	dr.records = append(dr.records, record)
+	if len(dr.records) > maxPendingDownloadRecords {
+		dr.records = dr.records[len(dr.records)-maxPendingDownloadRecords:]
+	}
+
+	if len(dr.records) >= maxDownloadRecordsPerReport {
+		select {
+		case dr.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// take removes the oldest records from the pending records for a report
+func (dr *downloadRecorder) take() []*types.DownloadHistory {
+	dr.lk.Lock()
+	defer dr.lk.Unlock()
+
+	count := len(dr.records)
+	if count > maxDownloadRecordsPerReport {
+		count = maxDownloadRecordsPerReport
+	}
+
+	records := dr.records[:count:count]
+	dr.records = dr.records[count:]
+	return records
+}
+
+// restore puts back the records which failed to report
+func (dr *downloadRecorder) restore(records []*types.DownloadHistory) {
+	dr.lk.Lock()
+	defer dr.lk.Unlock()
+
+	dr.records = append(records, dr.records...)
+	if len(dr.records) > maxPendingDownloadRecords {
+		dr.records = dr.records[len(dr.records)-maxPendingDownloadRecords:]
+	}
+}
+
+// report reports the pending records to the scheduler
+func (dr *downloadRecorder) report() {
+	for {
+		records := dr.take()
+		if len(records) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), reportDownloadRecordsTimeout)
+		err := dr.scheduler.SubmitDownloadRecords(ctx, records)
+		cancel()
+
+		if err != nil {
+			log.Errorf("submit %d download records error: %s", len(records), err.Error())
+			dr.restore(records)
+			return
+		}
+
+		if len(records) < maxDownloadRecordsPerReport {
+			return
+		}
+	}
+}
+
+// recordResponseWriter records the status and the bytes of a response
+type recordResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// WriteHeader records the status of the response
+func (rw *recordResponseWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the bytes of the response
+func (rw *recordResponseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Flush sends the buffered data of the response to the client if the wrapped writer supports it
+func (rw *recordResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// recordDownload records a gateway response which is authorized by the credentials
+func (hs *HttpServer) recordDownload(r *http.Request, credentials *types.Credentials, rw *recordResponseWriter, startTime time.Time) {
+	if hs.recorder == nil {
+		return
+	}
+
+	endTime := time.Now()
+	record := &types.DownloadHistory{
+		ID:           uuid.NewString(),
+		CredentialID: credentials.ID,
+		NodeID:       credentials.NodeID,
+		BlockCID:     cidFromPath(r.URL.Path),
+		AssetCID:     credentials.AssetCID,
+		BlockSize:    int(rw.size),
+		Duration:     endTime.Sub(startTime).Milliseconds(),
+		Status:       types.DownloadStatusSucceeded,
+		ClientIP:     clientIP(r),
+		CreatedTime:  startTime,
+		CompleteTime: endTime,
+	}
+
+	if rw.status >= http.StatusBadRequest {
+		record.Status = types.DownloadStatusFailed
+		record.FailedReason = http.StatusText(rw.status)
+	}
+
+	hs.recorder.add(record)
+}
+
+// cidFromPath returns the CID of the path, path=/ipfs/{cid}[/{path}]
+func cidFromPath(p string) string {
+	segments := strings.SplitN(strings.TrimPrefix(p, ipfsPathPrefix+"/"), "/", 2)
+	return segments[0]
+}
+
+// clientIP returns the ip of the client
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
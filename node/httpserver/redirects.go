@@ -0,0 +1,233 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-libipfs/files"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+const (
+	// redirectsFileName is the name of the redirects file in the root of the asset
+	redirectsFileName = "_redirects"
+	// maxRedirectsFileSize is the max size of the redirects file
+	maxRedirectsFileSize = 64 * 1024
+	// splatPlaceholder is the placeholder of the splat in the redirect target
+	splatPlaceholder = ":splat"
+)
+
+// redirectedContextKey marks the request which has been rewritten by a redirect rule
+const redirectedContextKey = contextKey("redirected")
+
+// redirectRule is a rule of the _redirects file, from to [status]
+type redirectRule struct {
+	from   string
+	to     string
+	status int
+}
+
+// parseRedirects parses the rules of a _redirects file
+func parseRedirects(r io.Reader) ([]*redirectRule, error) {
+	rules := make([]*redirectRule, 0)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: invalid rule %s", line, text)
+		}
+
+		rule := &redirectRule{from: fields[0], to: fields[1], status: http.StatusMovedPermanently}
+		if !strings.HasPrefix(rule.from, "/") {
+			return nil, fmt.Errorf("line %d: from %s must start with /", line, rule.from)
+		}
+
+		if !strings.HasPrefix(rule.to, "/") && !strings.HasPrefix(rule.to, "http://") && !strings.HasPrefix(rule.to, "https://") {
+			return nil, fmt.Errorf("line %d: to %s must be a path or url", line, rule.to)
+		}
+
+		if len(fields) == 3 {
+			status, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid status %s", line, fields[2])
+			}
+			rule.status = status
+		}
+
+		switch rule.status {
+		case http.StatusOK, http.StatusNotFound, http.StatusGone, http.StatusUnavailableForLegalReasons:
+			if !strings.HasPrefix(rule.to, "/") {
+				return nil, fmt.Errorf("line %d: status %d only support path", line, rule.status)
+			}
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return nil, fmt.Errorf("line %d: not support status %d", line, rule.status)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// match checks whether the path matches the rule, it returns the target with the placeholders replaced
+func (rule *redirectRule) match(p string) (string, bool) {
+	fromSegments := strings.Split(strings.TrimSuffix(rule.from, "/"), "/")
+	pathSegments := strings.Split(strings.TrimSuffix(p, "/"), "/")
+
+	placeholders := make(map[string]string)
+	for i, segment := range fromSegments {
+		if segment == "*" && i == len(fromSegments)-1 {
+			splat := ""
+			if i < len(pathSegments) {
+				splat = strings.Join(pathSegments[i:], "/")
+			}
+			placeholders[splatPlaceholder] = splat
+			return replacePlaceholders(rule.to, placeholders), true
+		}
+
+		if i >= len(pathSegments) {
+			return "", false
+		}
+
+		if strings.HasPrefix(segment, ":") {
+			placeholders[segment] = pathSegments[i]
+			continue
+		}
+
+		if segment != pathSegments[i] {
+			return "", false
+		}
+	}
+
+	if len(fromSegments) != len(pathSegments) {
+		return "", false
+	}
+	return replacePlaceholders(rule.to, placeholders), true
+}
+
+// replacePlaceholders replaces the placeholders in the target
+func replacePlaceholders(to string, placeholders map[string]string) string {
+	for name, value := range placeholders {
+		to = strings.ReplaceAll(to, name, value)
+	}
+	return to
+}
+
+// loadRedirects loads the rules of the _redirects file in the root of the subdomain asset
+func (hs *HttpServer) loadRedirects(ctx context.Context, root, asset cid.Cid) ([]*redirectRule, error) {
+	resolved, err := hs.resolvePath(ctx, path.New(fmt.Sprintf("%s/%s/%s", ipfsPathPrefix, root.String(), redirectsFileName)), asset)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := hs.getUnixFsNode(ctx, resolved, asset)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Close() //nolint:errcheck // ignore error
+
+	file, ok := node.(files.File)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a file", redirectsFileName)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxRedirectsFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxRedirectsFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", redirectsFileName, maxRedirectsFileSize)
+	}
+
+	return parseRedirects(bytes.NewReader(data))
+}
+
+// serveRedirects handles the request which path can not be resolved with the _redirects file,
+// it only works in the subdomain gateway which isolates the origin of the asset.
+// It returns true if the request has been handled.
+func (hs *HttpServer) serveRedirects(w http.ResponseWriter, r *http.Request, credentials *types.Credentials, asset cid.Cid) bool {
+	root, ok := subdomainRoot(r)
+	if !ok || r.Context().Value(redirectedContextKey) != nil {
+		return false
+	}
+
+	rules, err := hs.loadRedirects(r.Context(), root, asset)
+	if err != nil {
+		log.Debugf("load redirects of %s: %s", root.String(), err.Error())
+		return false
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, fmt.Sprintf("%s/%s", ipfsPathPrefix, root.String()))
+	if p == "" {
+		p = "/"
+	}
+
+	for _, rule := range rules {
+		to, ok := rule.match(p)
+		if !ok {
+			continue
+		}
+
+		if rule.status >= http.StatusMultipleChoices && rule.status < http.StatusBadRequest {
+			http.Redirect(w, r, to, rule.status)
+			return true
+		}
+
+		// rewrite the request to the target in the same asset
+		rr := r.Clone(context.WithValue(r.Context(), redirectedContextKey, true))
+		rr.URL.Path = fmt.Sprintf("%s/%s%s", ipfsPathPrefix, root.String(), to)
+		rr.URL.RawPath = ""
+		hs.serveUnixFS(&statusResponseWriter{ResponseWriter: w, status: rule.status}, rr, credentials)
+		return true
+	}
+
+	return false
+}
+
+// statusResponseWriter replaces the 200 status of the response with the status of the redirect rule
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader writes the status of the redirect rule instead of 200
+func (sw *statusResponseWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+
+	if code == http.StatusOK {
+		code = sw.status
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the data with the status of the redirect rule
+func (sw *statusResponseWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
@@ -16,8 +16,11 @@ func NewBlockFetcherFromIPFS(cfg *config.CandidateCfg) fetcher.BlockFetcher {
 }
 
 // NewTCPServer returns a new TCP server instance.
-func NewTCPServer(lc fx.Lifecycle, cfg *config.CandidateCfg, schedulerAPI api.Scheduler) *candidate.TCPServer {
-	srv := candidate.NewTCPServer(cfg, schedulerAPI)
+func NewTCPServer(lc fx.Lifecycle, cfg *config.CandidateCfg, schedulerAPI api.Scheduler) (*candidate.TCPServer, error) {
+	srv, err := candidate.NewTCPServer(cfg, schedulerAPI)
+	if err != nil {
+		return nil, err
+	}
 
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
@@ -27,5 +30,5 @@ func NewTCPServer(lc fx.Lifecycle, cfg *config.CandidateCfg, schedulerAPI api.Sc
 		OnStop: srv.Stop,
 	})
 
-	return srv
+	return srv, nil
 }
@@ -1,14 +1,20 @@
 package modules
 
 import (
+	"context"
+	"crypto/rsa"
+
+	"github.com/Filecoin-Titan/titan/api"
 	"github.com/Filecoin-Titan/titan/node/asset"
 	"github.com/Filecoin-Titan/titan/node/asset/fetcher"
 	"github.com/Filecoin-Titan/titan/node/asset/storage"
 	"github.com/Filecoin-Titan/titan/node/config"
 	"github.com/Filecoin-Titan/titan/node/device"
 	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
+	"github.com/Filecoin-Titan/titan/node/modules/helpers"
 	datasync "github.com/Filecoin-Titan/titan/node/sync"
 	"github.com/Filecoin-Titan/titan/node/validation"
+	"go.uber.org/fx"
 	"golang.org/x/time/rate"
 )
 
@@ -19,6 +25,21 @@ func NewDevice(bandwidthUP, bandwidthDown int64) func(nodeID dtypes.NodeID, inte
 	}
 }
 
+// NewStatsReporter creates a new instance of device.StatsReporter which reports the resource usage of the device to the scheduler until the node stops.
+func NewStatsReporter(mctx helpers.MetricsCtx, lc fx.Lifecycle, dev *device.Device, schedulerAPI api.Scheduler) *device.StatsReporter {
+	r := device.NewStatsReporter(dev, schedulerAPI)
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			r.Start(ctx)
+			return nil
+		},
+	})
+
+	return r
+}
+
 // NewRateLimiter creates a new rate limiter based on the given device's bandwidth limits.
 func NewRateLimiter(device *device.Device) *rate.Limiter {
 	return rate.NewLimiter(rate.Limit(device.GetBandwidthUp()), int(device.GetBandwidthUp()))
@@ -42,12 +63,12 @@ func NewBlockFetcherFromCandidate(cfg *config.EdgeCfg) fetcher.BlockFetcher {
 	return fetcher.NewCandidateFetcher(cfg.FetchBlockTimeout, cfg.FetchBlockRetry)
 }
 
-// NewDataSync creates a new instance of datasync.DataSync with the given asset.Manager.
-func NewDataSync(assetMgr *asset.Manager) *datasync.DataSync {
-	return datasync.NewDataSync(assetMgr)
+// NewDataSync creates a new instance of datasync.DataSync with the given asset.Manager and scheduler api.
+func NewDataSync(assetMgr *asset.Manager, schedulerAPI api.Scheduler) *datasync.DataSync {
+	return datasync.NewDataSync(assetMgr, schedulerAPI)
 }
 
-// NewNodeValidation creates a new instance of validation.Validation with the given asset.Manager and device.Device.
-func NewNodeValidation(assetMgr *asset.Manager, device *device.Device) *validation.Validation {
-	return validation.NewValidation(assetMgr, device)
+// NewNodeValidation creates a new instance of validation.Validation with the given asset.Manager, device.Device and the private key of the node.
+func NewNodeValidation(assetMgr *asset.Manager, device *device.Device, privateKey *rsa.PrivateKey) *validation.Validation {
+	return validation.NewValidation(assetMgr, device, privateKey)
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/Filecoin-Titan/titan/node/repo"
 	"github.com/Filecoin-Titan/titan/node/scheduler/assets"
 	"github.com/Filecoin-Titan/titan/node/scheduler/db"
+	"github.com/Filecoin-Titan/titan/node/scheduler/sync"
 	"github.com/Filecoin-Titan/titan/node/scheduler/validation"
 	"github.com/Filecoin-Titan/titan/node/sqldb"
 	"github.com/filecoin-project/pubsub"
@@ -20,6 +21,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/Filecoin-Titan/titan/node/common"
+	"github.com/Filecoin-Titan/titan/node/scheduler/nat"
 	"github.com/Filecoin-Titan/titan/node/scheduler/node"
 	"github.com/jmoiron/sqlx"
 )
@@ -64,6 +66,7 @@ type StorageManagerParams struct {
 	NodeManger *node.Manager
 	dtypes.GetSchedulerConfigFunc
 	*db.SQLDB
+	PubSub *pubsub.PubSub
 }
 
 // NewStorageManager creates a new storage manager instance
@@ -75,10 +78,11 @@ func NewStorageManager(params StorageManagerParams) *assets.Manager {
 		ds      = params.MetadataDS
 		cfgFunc = params.GetSchedulerConfigFunc
 		sdb     = params.SQLDB
+		p       = params.PubSub
 	)
 
 	ctx := helpers.LifecycleCtx(mctx, lc)
-	m := assets.NewManager(nodeMgr, ds, cfgFunc, sdb)
+	m := assets.NewManager(nodeMgr, ds, cfgFunc, sdb, p)
 
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
@@ -91,9 +95,29 @@ func NewStorageManager(params StorageManagerParams) *assets.Manager {
 	return m
 }
 
+// NewSchedulerDataSync creates a new asset view sync instance of the scheduler
+func NewSchedulerDataSync(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *node.Manager, configFunc dtypes.GetSchedulerConfigFunc, p *pubsub.PubSub) *sync.DataSync {
+	ds := sync.NewDataSync(m, configFunc, p)
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			ds.Start(ctx)
+			return nil
+		},
+	})
+
+	return ds
+}
+
 // NewValidation creates a new validation manager instance
-func NewValidation(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *node.Manager, configFunc dtypes.GetSchedulerConfigFunc, p *pubsub.PubSub) *validation.Manager {
-	v := validation.NewManager(m, configFunc, p)
+func NewValidation(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *node.Manager, cfg *config.SchedulerCfg, configFunc dtypes.GetSchedulerConfigFunc, p *pubsub.PubSub) (*validation.Manager, error) {
+	beacon, err := validation.NewRandomnessBeacon(cfg.RandomnessBeaconURL, cfg.RandomnessBeaconPublicKey, cfg.RandomnessBeaconSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	v := validation.NewManager(m, configFunc, p, beacon)
 
 	ctx := helpers.LifecycleCtx(mctx, lc)
 	lc.Append(fx.Hook{
@@ -104,7 +128,26 @@ func NewValidation(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *node.Manager, co
 		OnStop: v.Stop,
 	})
 
-	return v
+	return v, nil
+}
+
+// NewNATManager creates a new NAT manager instance
+func NewNATManager(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *node.Manager, cfg *config.SchedulerCfg, configFunc dtypes.GetSchedulerConfigFunc) (*nat.Manager, error) {
+	n, err := nat.NewManager(m, cfg, configFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go n.Start(ctx)
+			return nil
+		},
+		OnStop: n.Stop,
+	})
+
+	return n, nil
 }
 
 // NewSetSchedulerConfigFunc creates a function to set the scheduler config
@@ -17,13 +17,35 @@ func (s *Scheduler) NodeRemoveAssetResult(ctx context.Context, resultInfo types.
 	// update node info
 	node := s.NodeManager.GetNode(nodeID)
 	if node != nil {
-		node.DiskUsage = resultInfo.DiskUsage
+		node.SetDiskUsage(resultInfo.DiskUsage)
 		node.Blocks = resultInfo.BlocksCount
 	}
 
 	return nil
 }
 
+// SubmitSyncResult updates the replica records and the asset view of the caller by the result of the asset view sync.
+func (s *Scheduler) SubmitSyncResult(ctx context.Context, result *types.SyncResult) error {
+	nodeID := handler.GetNodeID(ctx)
+	if result == nil || (len(result.ExtraAssets) == 0 && len(result.LostAssets) == 0) {
+		return nil
+	}
+
+	log.Infof("node %s sync result, extra assets:%d, lost assets:%d", nodeID, len(result.ExtraAssets), len(result.LostAssets))
+
+	return s.AssetManager.ReconcileReplicas(nodeID, result)
+}
+
+// SyncNodeAssetView syncs the assets of the node with its asset view immediately, the result is not applied if dryRun is true.
+func (s *Scheduler) SyncNodeAssetView(ctx context.Context, nodeID string, dryRun bool) (*types.SyncResult, error) {
+	return s.DataSync.SyncNode(ctx, nodeID, dryRun)
+}
+
+// GetAssetViewSyncStatus returns the status of the last asset view sync of the node.
+func (s *Scheduler) GetAssetViewSyncStatus(ctx context.Context, nodeID string) (*types.NodeSyncStatus, error) {
+	return s.DataSync.GetSyncStatus(nodeID)
+}
+
 // RePullFailedAssets retries the pull process for a list of failed assets
 func (s *Scheduler) RePullFailedAssets(ctx context.Context, hashes []types.AssetHash) error {
 	return s.AssetManager.RestartPullAssets(hashes)
@@ -128,6 +150,10 @@ func (s *Scheduler) PullAsset(ctx context.Context, info *types.PullAssetReq) err
 		return xerrors.Errorf("expiration %s less than now(%v)", info.Expiration.String(), time.Now())
 	}
 
+	if _, err := types.ParseLabelSelector(info.NodeSelector); err != nil {
+		return xerrors.Errorf("node selector %s err:%s", info.NodeSelector, err.Error())
+	}
+
 	return s.AssetManager.CreateAssetPullTask(info)
 }
 
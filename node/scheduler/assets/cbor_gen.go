@@ -26,7 +26,7 @@ func (t *AssetPullingInfo) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{175}); err != nil {
+	if _, err := cw.Write([]byte{176}); err != nil {
 		return err
 	}
 
@@ -254,6 +254,29 @@ func (t *AssetPullingInfo) MarshalCBOR(w io.Writer) error {
 		}
 	}
 
+	// t.NodeSelector (string) (string)
+	if len("NodeSelector") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"NodeSelector\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("NodeSelector"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("NodeSelector")); err != nil {
+		return err
+	}
+
+	if len(t.NodeSelector) > cbg.MaxLength {
+		return xerrors.Errorf("Value in field t.NodeSelector was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.NodeSelector))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string(t.NodeSelector)); err != nil {
+		return err
+	}
+
 	// t.CandidateReplicas (int64) (int64)
 	if len("CandidateReplicas") > cbg.MaxLength {
 		return xerrors.Errorf("Value in field \"CandidateReplicas\" was too long")
@@ -644,6 +667,17 @@ func (t *AssetPullingInfo) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.EdgeReplicas = int64(extraI)
 			}
+			// t.NodeSelector (string) (string)
+		case "NodeSelector":
+
+			{
+				sval, err := cbg.ReadString(cr)
+				if err != nil {
+					return err
+				}
+
+				t.NodeSelector = string(sval)
+			}
 			// t.CandidateReplicas (int64) (int64)
 		case "CandidateReplicas":
 			{
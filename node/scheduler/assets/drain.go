@@ -0,0 +1,187 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+const drainCheckInterval = 5 * time.Minute // Interval to replenish the replicas of the assets on the draining nodes
+
+// SetNodeMaintenance puts the node in or out of maintenance, the node receives no new replicas or validations in maintenance
+func (m *Manager) SetNodeMaintenance(nodeID string, enable bool) error {
+	state := types.NodeStateNormal
+	if enable {
+		state = types.NodeStateMaintenance
+	}
+
+	return m.nodeMgr.SetNodeState(nodeID, state)
+}
+
+// DrainNode drains the node, the node receives no new replicas or validations and its replicas are re-created on the other nodes
+func (m *Manager) DrainNode(nodeID string) error {
+	if err := m.nodeMgr.SetNodeState(nodeID, types.NodeStateDraining); err != nil {
+		return err
+	}
+
+	go m.drainNodes()
+
+	return nil
+}
+
+// GetNodeDrainStatus returns the drain progress of the node
+func (m *Manager) GetNodeDrainStatus(nodeID string) (*types.NodeDrainStatus, error) {
+	state, err := m.LoadNodeState(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	draining, err := m.loadDrainingNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	status, _, err := m.checkDrain(nodeID, draining)
+	if err != nil {
+		return nil, err
+	}
+	status.State = state
+
+	return status, nil
+}
+
+// drainCheck periodically replenishes the replicas of the assets on the draining nodes
+func (m *Manager) drainCheck(ctx context.Context) {
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.drainNodes()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drainNodes replenishes the replicas of the assets which do not have enough replicas other than the draining nodes
+func (m *Manager) drainNodes() {
+	draining, err := m.loadDrainingNodes()
+	if err != nil {
+		log.Errorf("drainNodes loadDrainingNodes err:%s", err.Error())
+		return
+	}
+
+	for nodeID := range draining {
+		status, pending, err := m.checkDrain(nodeID, draining)
+		if err != nil {
+			log.Errorf("drainNodes checkDrain %s err:%s", nodeID, err.Error())
+			continue
+		}
+
+		if status.Drained {
+			log.Infof("node %s is drained", nodeID)
+			continue
+		}
+
+		log.Infof("node %s is draining, pending assets:%d/%d", nodeID, status.PendingAssets, status.Assets)
+		if len(status.StalledAssets) > 0 {
+			log.Warnf("node %s is draining, %d assets can not be replenished now", nodeID, len(status.StalledAssets))
+		}
+
+		for _, hash := range pending {
+			m.repairAssetReplicas(hash)
+		}
+	}
+}
+
+// checkDrain returns the drain status of the node and the hashes of the assets which do not have enough replicas on the other nodes,
+// the replicas on the draining nodes are not counted
+func (m *Manager) checkDrain(nodeID string, draining map[string]struct{}) (*types.NodeDrainStatus, []string, error) {
+	hashes, err := m.LoadAssetHashesOfNodes([]string{nodeID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	status := &types.NodeDrainStatus{NodeID: nodeID, State: types.NodeStateDraining, StalledAssets: make(map[string]string)}
+	pending := make([]string, 0)
+	repairs := m.pendingRepairs()
+	candidateReplicas := m.GetCandidateReplicaCount() + seedReplicaCount
+
+	for _, hash := range hashes {
+		record, err := m.LoadAssetRecord(hash)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		replicas, err := m.LoadAssetReplicas(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		status.Assets++
+		if !needsReplenish(nodeID, record, replicas, draining, candidateReplicas) {
+			continue
+		}
+
+		pending = append(pending, hash)
+		if reason := stalledReason(record, repairs[hash]); reason != "" {
+			status.StalledAssets[record.CID] = reason
+		}
+	}
+
+	status.PendingAssets = len(pending)
+	status.Drained = status.PendingAssets == 0
+
+	return status, pending, nil
+}
+
+// needsReplenish checks whether the asset has fewer replicas than the record needs on the nodes other than the node and the draining nodes
+func needsReplenish(nodeID string, record *types.AssetRecord, replicas []*types.ReplicaInfo, draining map[string]struct{}, candidateReplicas int) bool {
+	candidates, edges := 0, 0
+	for _, r := range replicas {
+		if _, ok := draining[r.NodeID]; ok || r.NodeID == nodeID || r.Status != types.ReplicaStatusSucceeded {
+			continue
+		}
+
+		if r.IsCandidate {
+			candidates++
+		} else {
+			edges++
+		}
+	}
+
+	return candidates < candidateReplicas || edges < int(record.NeedEdgeReplica)
+}
+
+// stalledReason returns why the pending asset can not be replenished now, it is empty if the asset can be replenished,
+// the repair is the reason of the failed repair of the asset if any
+func stalledReason(record *types.AssetRecord, repair string) string {
+	if record.State != string(Servicing) {
+		return fmt.Sprintf("asset state is %s", record.State)
+	}
+
+	return repair
+}
+
+// loadDrainingNodes returns the draining nodes of the scheduler
+func (m *Manager) loadDrainingNodes() (map[string]struct{}, error) {
+	nodeIDs, err := m.LoadNodesOfState(types.NodeStateDraining, m.nodeMgr.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]struct{}, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		out[nodeID] = struct{}{}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+func TestNeedsReplenish(t *testing.T) {
+	record := &types.AssetRecord{NeedEdgeReplica: 2, State: string(Servicing)}
+	draining := map[string]struct{}{"node": {}, "draining": {}}
+
+	replica := func(nodeID string, isCandidate bool, status types.ReplicaStatus) *types.ReplicaInfo {
+		return &types.ReplicaInfo{NodeID: nodeID, IsCandidate: isCandidate, Status: status}
+	}
+
+	tests := []struct {
+		name     string
+		replicas []*types.ReplicaInfo
+		expect   bool
+	}{
+		{
+			name: "enough replicas on the other nodes",
+			replicas: []*types.ReplicaInfo{
+				replica("node", false, types.ReplicaStatusSucceeded),
+				replica("c1", true, types.ReplicaStatusSucceeded),
+				replica("e1", false, types.ReplicaStatusSucceeded),
+				replica("e2", false, types.ReplicaStatusSucceeded),
+			},
+			expect: false,
+		},
+		{
+			name: "the replicas on the draining nodes are not counted",
+			replicas: []*types.ReplicaInfo{
+				replica("c1", true, types.ReplicaStatusSucceeded),
+				replica("e1", false, types.ReplicaStatusSucceeded),
+				replica("draining", false, types.ReplicaStatusSucceeded),
+			},
+			expect: true,
+		},
+		{
+			name: "the replicas in pulling are not counted",
+			replicas: []*types.ReplicaInfo{
+				replica("c1", true, types.ReplicaStatusSucceeded),
+				replica("e1", false, types.ReplicaStatusSucceeded),
+				replica("e2", false, types.ReplicaStatusPulling),
+			},
+			expect: true,
+		},
+		{
+			name: "not enough candidate replicas",
+			replicas: []*types.ReplicaInfo{
+				replica("node", true, types.ReplicaStatusSucceeded),
+				replica("e1", false, types.ReplicaStatusSucceeded),
+				replica("e2", false, types.ReplicaStatusSucceeded),
+			},
+			expect: true,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := needsReplenish("node", record, tt.replicas, draining, 1); got != tt.expect {
+			t.Errorf("%s: expect %v, got %v", tt.name, tt.expect, got)
+		}
+	}
+}
+
+func TestStalledReason(t *testing.T) {
+	if reason := stalledReason(&types.AssetRecord{State: string(Servicing)}, ""); reason != "" {
+		t.Errorf("expect a servicing asset not stalled, got %s", reason)
+	}
+
+	if reason := stalledReason(&types.AssetRecord{State: string(Servicing)}, "load replicas err"); reason != "load replicas err" {
+		t.Errorf("expect the reason of the failed repair, got %s", reason)
+	}
+
+	if reason := stalledReason(&types.AssetRecord{State: string(EdgesFailed)}, ""); reason != "asset state is EdgesFailed" {
+		t.Errorf("expect the asset stalled by its state, got %s", reason)
+	}
+}
@@ -34,6 +34,7 @@ type AssetPullingInfo struct {
 	CandidateReplicas int64
 	CreatedAt         int64
 	Expiration        int64
+	NodeSelector      string
 
 	EdgeReplicaSucceeds      []string
 	EdgeReplicaFailures      []string
@@ -54,6 +55,7 @@ func (state *AssetPullingInfo) ToAssetRecord() *types.AssetRecord {
 		State:                 state.State.String(),
 		NeedCandidateReplicas: state.CandidateReplicas,
 		Expiration:            time.Unix(state.Expiration, 0),
+		NodeSelector:          state.NodeSelector,
 	}
 }
 
@@ -68,6 +70,7 @@ func assetPullingInfoFrom(info *types.AssetRecord) *AssetPullingInfo {
 		Blocks:            info.TotalBlocks,
 		CandidateReplicas: info.NeedCandidateReplicas,
 		Expiration:        info.Expiration.Unix(),
+		NodeSelector:      info.NodeSelector,
 	}
 
 	for _, r := range info.ReplicaInfos {
@@ -4,11 +4,14 @@ import (
 	"context"
 	"crypto"
 	"database/sql"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/filecoin-project/go-statemachine"
+	"github.com/filecoin-project/pubsub"
 	"github.com/ipfs/go-datastore"
 
 	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
@@ -35,9 +38,16 @@ const (
 	maxRetryCount    = 3    // TODO Select
 	maxNodeDiskUsage = 95.0 // If the node disk size is greater than this value, pulling will not continue
 
+	minReplicaReputation = 20.0 // Nodes with a lower reputation are not selected to pull replicas
+
 	numAssetBuckets = 128 // Number of asset buckets in assets view
+
+	repairRetryInterval = 5 * time.Minute // Interval to retry the repairs of the assets which can not be replenished
 )
 
+// errReplenishNotNeeded is returned if the asset has enough replicas
+var errReplenishNotNeeded = xerrors.New("Asset do not need to be replenish")
+
 // Manager manages asset replicas
 type Manager struct {
 	nodeMgr            *node.Manager // node manager
@@ -47,7 +57,11 @@ type Manager struct {
 	lock               sync.Mutex
 	apTickers          map[string]*assetTicker       // timeout timer for asset pulling
 	config             dtypes.GetSchedulerConfigFunc // scheduler config
+	notify             *pubsub.PubSub
 	*db.SQLDB
+
+	repairLock sync.Mutex
+	repairs    map[string]string // The assets which are waiting to repair their replicas, hash to the reason
 }
 
 type assetTicker struct {
@@ -72,13 +86,15 @@ func (t *assetTicker) run(job func() error) {
 }
 
 // NewManager returns a new AssetManager instance
-func NewManager(nodeManager *node.Manager, ds datastore.Batching, configFunc dtypes.GetSchedulerConfigFunc, sdb *db.SQLDB) *Manager {
+func NewManager(nodeManager *node.Manager, ds datastore.Batching, configFunc dtypes.GetSchedulerConfigFunc, sdb *db.SQLDB, p *pubsub.PubSub) *Manager {
 	m := &Manager{
 		nodeMgr:            nodeManager,
 		earliestExpiration: time.Now(),
 		apTickers:          make(map[string]*assetTicker),
+		repairs:            make(map[string]string),
 		config:             configFunc,
 		SQLDB:              sdb,
+		notify:             p,
 	}
 
 	m.stateMachineWait.Add(1)
@@ -94,6 +110,8 @@ func (m *Manager) Start(ctx context.Context) {
 	}
 	go m.assetExpirationCheck(ctx)
 	go m.assetPullProgressCheck(ctx)
+	go m.drainCheck(ctx)
+	go m.repairCheck(ctx)
 }
 
 // Terminate stops the asset state machine
@@ -211,6 +229,7 @@ func (m *Manager) CreateAssetPullTask(info *types.PullAssetReq) error {
 			CreatedAt:         time.Now().Unix(),
 			Expiration:        info.Expiration.Unix(),
 			CandidateReplicas: m.GetCandidateReplicaCount(),
+			NodeSelector:      info.NodeSelector,
 		})
 	}
 
@@ -230,6 +249,12 @@ func (m *Manager) replenishAssetReplicas(assetRecord *types.AssetRecord, info *t
 		return xerrors.Errorf("asset %s load replicas err: %s", assetRecord.CID, err.Error())
 	}
 
+	// the replicas on the draining nodes are replenished on the other nodes
+	draining, err := m.loadDrainingNodes()
+	if err != nil {
+		return xerrors.Errorf("load draining nodes err: %s", err.Error())
+	}
+
 	rInfo := ReplenishReplicas{
 		ID:                info.CID,
 		Hash:              AssetHash(info.Hash),
@@ -241,6 +266,7 @@ func (m *Manager) replenishAssetReplicas(assetRecord *types.AssetRecord, info *t
 		Size:              assetRecord.TotalSize,
 		Blocks:            assetRecord.TotalBlocks,
 		State:             SeedSelect,
+		NodeSelector:      info.NodeSelector,
 	}
 
 	for _, r := range replicaInfos {
@@ -248,6 +274,10 @@ func (m *Manager) replenishAssetReplicas(assetRecord *types.AssetRecord, info *t
 			continue
 		}
 
+		if _, ok := draining[r.NodeID]; ok {
+			continue
+		}
+
 		if r.IsCandidate {
 			rInfo.CandidateReplicaSucceeds = append(rInfo.CandidateReplicaSucceeds, r.NodeID)
 		} else {
@@ -260,7 +290,7 @@ func (m *Manager) replenishAssetReplicas(assetRecord *types.AssetRecord, info *t
 		return m.assetStateMachines.Send(AssetHash(info.Hash), rInfo)
 	}
 
-	return xerrors.New("Asset do not need to be replenish")
+	return errReplenishNotNeeded
 }
 
 // RestartPullAssets restarts asset pulls
@@ -282,11 +312,143 @@ func (m *Manager) RemoveReplica(cid, hash, nodeID string) error {
 		return err
 	}
 
+	m.publishReplicaChange(nodeID, cid, "Removed")
+
 	go m.requestAssetDeletion(nodeID, cid)
 
 	return nil
 }
 
+// ReconcileReplicas updates the replica records and the asset view of the node by the result of the asset view sync,
+// the node no longer holds the extra and lost assets, so the assets which lose a succeeded replica are replenished
+func (m *Manager) ReconcileReplicas(nodeID string, result *types.SyncResult) error {
+	cids := append(append([]string{}, result.ExtraAssets...), result.LostAssets...)
+	for _, cid := range cids {
+		hash, err := cidutil.CIDToHash(cid)
+		if err != nil {
+			log.Errorf("ReconcileReplicas %s CIDToHash err:%s", cid, err.Error())
+			continue
+		}
+
+		if err := m.removeAssetFromView(nodeID, cid); err != nil {
+			log.Errorf("ReconcileReplicas %s removeAssetFromView err:%s", nodeID, err.Error())
+		}
+
+		replicas, err := m.LoadAssetReplicas(hash)
+		if err != nil {
+			return xerrors.Errorf("asset %s load replicas err: %s", cid, err.Error())
+		}
+
+		lost := false
+		for _, r := range replicas {
+			if r.NodeID == nodeID {
+				lost = r.Status == types.ReplicaStatusSucceeded
+				break
+			}
+		}
+
+		if err := m.DeleteAssetReplica(hash, nodeID); err != nil {
+			return xerrors.Errorf("asset %s delete replica of node %s err: %s", cid, nodeID, err.Error())
+		}
+
+		m.publishReplicaChange(nodeID, cid, "Removed")
+
+		if lost {
+			m.repairAssetReplicas(hash)
+		}
+	}
+
+	return nil
+}
+
+// repairAssetReplicas replenishes the replicas of the asset to the number of the asset record,
+// the asset is queued to repair later if it is not in servicing or the replenishment fails
+func (m *Manager) repairAssetReplicas(hash string) {
+	record, err := m.LoadAssetRecord(hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// the asset is removed
+			m.removeRepair(hash)
+			return
+		}
+
+		log.Errorf("repairAssetReplicas %s LoadAssetRecord err:%s", hash, err.Error())
+		m.queueRepair(hash, err.Error())
+		return
+	}
+
+	if record.State != string(Servicing) {
+		m.queueRepair(hash, fmt.Sprintf("asset state is %s", record.State))
+		return
+	}
+
+	info := &types.PullAssetReq{
+		CID:          record.CID,
+		Hash:         record.Hash,
+		Replicas:     record.NeedEdgeReplica,
+		ServerID:     string(record.ServerID),
+		Expiration:   record.Expiration,
+		NodeSelector: record.NodeSelector,
+	}
+
+	if err := m.replenishAssetReplicas(record, info); err != nil && !errors.Is(err, errReplenishNotNeeded) {
+		log.Warnf("repairAssetReplicas %s err:%s", record.CID, err.Error())
+		m.queueRepair(hash, err.Error())
+		return
+	}
+
+	m.removeRepair(hash)
+}
+
+// queueRepair queues the asset to repair its replicas later
+func (m *Manager) queueRepair(hash, reason string) {
+	m.repairLock.Lock()
+	defer m.repairLock.Unlock()
+
+	if _, ok := m.repairs[hash]; !ok {
+		log.Infof("asset %s is queued to repair, %s", hash, reason)
+	}
+	m.repairs[hash] = reason
+}
+
+// removeRepair removes the asset from the repair queue
+func (m *Manager) removeRepair(hash string) {
+	m.repairLock.Lock()
+	defer m.repairLock.Unlock()
+
+	delete(m.repairs, hash)
+}
+
+// pendingRepairs returns the assets which are waiting to repair their replicas, hash to the reason
+func (m *Manager) pendingRepairs() map[string]string {
+	m.repairLock.Lock()
+	defer m.repairLock.Unlock()
+
+	out := make(map[string]string, len(m.repairs))
+	for hash, reason := range m.repairs {
+		out[hash] = reason
+	}
+
+	return out
+}
+
+// repairCheck periodically retries the repairs of the queued assets
+func (m *Manager) repairCheck(ctx context.Context) {
+	ticker := time.NewTicker(repairRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for hash := range m.pendingRepairs() {
+				m.repairAssetReplicas(hash)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // RemoveAsset removes an asset
 func (m *Manager) RemoveAsset(cid, hash string) error {
 	cInfos, err := m.LoadAssetReplicas(hash)
@@ -331,7 +493,7 @@ func (m *Manager) updateAssetPullResults(nodeID string, result *types.PullResult
 	if nodeInfo != nil {
 		isCandidate = nodeInfo.Type == types.NodeCandidate
 		// update node info
-		nodeInfo.DiskUsage = result.DiskUsage
+		nodeInfo.SetDiskUsage(result.DiskUsage)
 		defer nodeInfo.SetCurPullingCount(pullingCount)
 	}
 
@@ -377,6 +539,8 @@ func (m *Manager) updateAssetPullResults(nodeID string, result *types.PullResult
 			continue
 		}
 
+		m.publishReplicaChange(nodeID, progress.CID, progress.Status.String())
+
 		if progress.Status == types.ReplicaStatusPulling {
 			pullingCount++
 
@@ -557,8 +721,19 @@ func (m *Manager) GetAssetRecordInfo(cid string) (*types.AssetRecord, error) {
 	return dInfo, err
 }
 
+// publishReplicaChange notifies the subscribers that the replica of the node is added, updated or removed
+func (m *Manager) publishReplicaChange(nodeID, cid, state string) {
+	m.notify.Pub(&types.Event{
+		Topic:    types.EventReplicaChange,
+		Time:     time.Now(),
+		NodeID:   nodeID,
+		AssetCID: cid,
+		State:    state,
+	}, types.EventReplicaChange.String())
+}
+
 // saveReplicaInformation stores replica information for nodes
-func (m *Manager) saveReplicaInformation(nodes map[string]*node.Node, hash string, isCandidate bool) error {
+func (m *Manager) saveReplicaInformation(nodes map[string]*node.Node, cid, hash string, isCandidate bool) error {
 	// save replica info
 	replicaInfos := make([]*types.ReplicaInfo, 0)
 
@@ -571,7 +746,15 @@ func (m *Manager) saveReplicaInformation(nodes map[string]*node.Node, hash strin
 		})
 	}
 
-	return m.BatchSaveReplicas(replicaInfos)
+	if err := m.BatchSaveReplicas(replicaInfos); err != nil {
+		return err
+	}
+
+	for _, info := range replicaInfos {
+		m.publishReplicaChange(info.NodeID, cid, info.Status.String())
+	}
+
+	return nil
 }
 
 // getDownloadSources gets download sources for a given CID
@@ -599,8 +782,8 @@ func (m *Manager) getDownloadSources(cid string, nodes []string) []*types.Candid
 	return sources
 }
 
-// chooseCandidateNodesForAssetReplica selects candidate nodes to pull asset replicas
-func (m *Manager) chooseCandidateNodesForAssetReplica(count int, filterNodes []string) map[string]*node.Node {
+// chooseCandidateNodesForAssetReplica selects candidate nodes matching the label selector to pull asset replicas
+func (m *Manager) chooseCandidateNodesForAssetReplica(count int, filterNodes []string, selector types.LabelSelector) map[string]*node.Node {
 	selectMap := make(map[string]*node.Node)
 	if count <= 0 {
 		return selectMap
@@ -628,7 +811,19 @@ func (m *Manager) chooseCandidateNodesForAssetReplica(count int, filterNodes []s
 			continue
 		}
 
-		if node.DiskUsage > maxNodeDiskUsage {
+		if node.GetDiskUsage() > maxNodeDiskUsage {
+			continue
+		}
+
+		if !node.IsSchedulable() || node.IsQuarantined() {
+			continue
+		}
+
+		if !selector.Matches(node.GetLabels()) {
+			continue
+		}
+
+		if node.GetReputation() < minReplicaReputation {
 			continue
 		}
 
@@ -641,8 +836,8 @@ func (m *Manager) chooseCandidateNodesForAssetReplica(count int, filterNodes []s
 	return selectMap
 }
 
-// chooseEdgeNodesForAssetReplica selects edge nodes to pull asset replicas
-func (m *Manager) chooseEdgeNodesForAssetReplica(count int, filterNodes []string) map[string]*node.Node {
+// chooseEdgeNodesForAssetReplica selects edge nodes matching the label selector to pull asset replicas
+func (m *Manager) chooseEdgeNodesForAssetReplica(count int, filterNodes []string, selector types.LabelSelector) map[string]*node.Node {
 	selectMap := make(map[string]*node.Node)
 	if count <= 0 {
 		return selectMap
@@ -668,7 +863,19 @@ func (m *Manager) chooseEdgeNodesForAssetReplica(count int, filterNodes []string
 			continue
 		}
 
-		if node.DiskUsage > maxNodeDiskUsage {
+		if node.GetDiskUsage() > maxNodeDiskUsage {
+			continue
+		}
+
+		if !node.IsSchedulable() || node.IsQuarantined() {
+			continue
+		}
+
+		if !selector.Matches(node.GetLabels()) {
+			continue
+		}
+
+		if node.GetReputation() < minReplicaReputation {
 			continue
 		}
 
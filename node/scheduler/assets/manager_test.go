@@ -0,0 +1,29 @@
+package assets
+
+import (
+	"testing"
+)
+
+func TestRepairQueue(t *testing.T) {
+	m := &Manager{repairs: make(map[string]string)}
+
+	m.queueRepair("a", "asset state is SeedPulling")
+	m.queueRepair("b", "asset state is EdgesPulling")
+	m.queueRepair("a", "asset state is CandidatesPulling")
+
+	pending := m.pendingRepairs()
+	if len(pending) != 2 {
+		t.Fatalf("expect 2 queued assets, got %d", len(pending))
+	}
+	if pending["a"] != "asset state is CandidatesPulling" {
+		t.Errorf("expect the latest reason, got %s", pending["a"])
+	}
+
+	// the returned assets are a copy, the repairs can be removed while they are retried
+	for hash := range pending {
+		m.removeRepair(hash)
+	}
+	if len(m.pendingRepairs()) != 0 || len(pending) != 2 {
+		t.Error("expect the queue empty and the copy unchanged")
+	}
+}
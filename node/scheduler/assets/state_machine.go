@@ -2,8 +2,11 @@ package assets
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"time"
 
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/filecoin-project/go-statemachine"
 	"golang.org/x/xerrors"
 )
@@ -91,6 +94,7 @@ func (m *Manager) plan(events []statemachine.Event, state *AssetPullingInfo) (fu
 		}
 	}
 
+	prevState := state.State
 	processed, err := p(events, state)
 	if err != nil {
 		return nil, processed, xerrors.Errorf("running planner for state %s failed: %w", state.State, err)
@@ -98,6 +102,16 @@ func (m *Manager) plan(events []statemachine.Event, state *AssetPullingInfo) (fu
 
 	log.Debugf("%s: %s", state.Hash, state.State)
 
+	if state.State != prevState {
+		m.notify.Pub(&types.Event{
+			Topic:    types.EventAssetState,
+			Time:     time.Now(),
+			AssetCID: state.CID,
+			State:    state.State.String(),
+			Message:  fmt.Sprintf("from %s", prevState),
+		}, types.EventAssetState.String())
+	}
+
 	switch state.State {
 	// Happy path
 	case SeedSelect:
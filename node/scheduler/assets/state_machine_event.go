@@ -126,6 +126,7 @@ type AssetStartPulls struct {
 	CreatedAt         int64
 	Expiration        int64
 	CandidateReplicas int // Number of candidate node replicas
+	NodeSelector      string
 }
 
 func (evt AssetStartPulls) apply(state *AssetPullingInfo) {
@@ -136,6 +137,7 @@ func (evt AssetStartPulls) apply(state *AssetPullingInfo) {
 	state.CreatedAt = evt.CreatedAt
 	state.Expiration = evt.Expiration
 	state.CandidateReplicas = int64(seedReplicaCount + evt.CandidateReplicas)
+	state.NodeSelector = evt.NodeSelector
 }
 
 // ReplenishReplicas replenish asset replicas
@@ -152,6 +154,7 @@ type ReplenishReplicas struct {
 	Blocks                   int64
 	EdgeReplicaSucceeds      []string
 	CandidateReplicaSucceeds []string
+	NodeSelector             string
 }
 
 func (evt ReplenishReplicas) applyGlobal(state *AssetPullingInfo) bool {
@@ -167,6 +170,7 @@ func (evt ReplenishReplicas) applyGlobal(state *AssetPullingInfo) bool {
 	state.Blocks = evt.Blocks
 	state.EdgeReplicaSucceeds = evt.EdgeReplicaSucceeds
 	state.CandidateReplicaSucceeds = evt.CandidateReplicaSucceeds
+	state.NodeSelector = evt.NodeSelector
 	return true
 }
 
@@ -3,6 +3,7 @@ package assets
 import (
 	"time"
 
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/filecoin-project/go-statemachine"
 	"golang.org/x/xerrors"
 )
@@ -39,14 +40,19 @@ func (m *Manager) handleSeedSelect(ctx statemachine.Context, info AssetPullingIn
 		return ctx.Send(SkipStep{})
 	}
 
+	selector, err := types.ParseLabelSelector(info.NodeSelector)
+	if err != nil {
+		return ctx.Send(SelectFailed{error: err})
+	}
+
 	// find nodes
-	nodes := m.chooseCandidateNodesForAssetReplica(seedReplicaCount, info.CandidateReplicaSucceeds)
+	nodes := m.chooseCandidateNodesForAssetReplica(seedReplicaCount, info.CandidateReplicaSucceeds, selector)
 	if len(nodes) < 1 {
 		return ctx.Send(SelectFailed{error: xerrors.New("node not found")})
 	}
 
 	// save to db
-	err := m.saveReplicaInformation(nodes, info.Hash.String(), true)
+	err = m.saveReplicaInformation(nodes, info.CID, info.Hash.String(), true)
 	if err != nil {
 		return ctx.Send(SelectFailed{error: err})
 	}
@@ -94,14 +100,19 @@ func (m *Manager) handleCandidatesSelect(ctx statemachine.Context, info AssetPul
 		return ctx.Send(SkipStep{})
 	}
 
+	selector, err := types.ParseLabelSelector(info.NodeSelector)
+	if err != nil {
+		return ctx.Send(SelectFailed{error: err})
+	}
+
 	// find nodes
-	nodes := m.chooseCandidateNodesForAssetReplica(int(needCount), info.CandidateReplicaSucceeds)
+	nodes := m.chooseCandidateNodesForAssetReplica(int(needCount), info.CandidateReplicaSucceeds, selector)
 	if len(nodes) < 1 {
 		return ctx.Send(SelectFailed{error: xerrors.New("node not found")})
 	}
 
 	// save to db
-	err := m.saveReplicaInformation(nodes, info.Hash.String(), true)
+	err = m.saveReplicaInformation(nodes, info.CID, info.Hash.String(), true)
 	if err != nil {
 		return ctx.Send(SelectFailed{error: err})
 	}
@@ -156,14 +167,19 @@ func (m *Manager) handleEdgesSelect(ctx statemachine.Context, info AssetPullingI
 		return ctx.Send(SelectFailed{error: xerrors.New("source node not found")})
 	}
 
+	selector, err := types.ParseLabelSelector(info.NodeSelector)
+	if err != nil {
+		return ctx.Send(SelectFailed{error: err})
+	}
+
 	// find nodes
-	nodes := m.chooseEdgeNodesForAssetReplica(int(needCount), info.EdgeReplicaSucceeds)
+	nodes := m.chooseEdgeNodesForAssetReplica(int(needCount), info.EdgeReplicaSucceeds, selector)
 	if len(nodes) < 1 {
 		return ctx.Send(SelectFailed{error: xerrors.New("node not found")})
 	}
 
 	// save to db
-	err := m.saveReplicaInformation(nodes, info.Hash.String(), false)
+	err = m.saveReplicaInformation(nodes, info.CID, info.Hash.String(), false)
 	if err != nil {
 		return ctx.Send(SelectFailed{error: err})
 	}
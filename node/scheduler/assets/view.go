@@ -7,6 +7,8 @@ import (
 	"hash/fnv"
 	"sort"
 
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/cidutil"
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
 )
@@ -36,6 +38,12 @@ func (m *Manager) removeAssetFromView(nodeID string, assetCID string) error {
 			return err
 		}
 		delete(bucketHashes, bucketNumber)
+	} else {
+		hash, err := computeBucketHash(assetHashes)
+		if err != nil {
+			return err
+		}
+		bucketHashes[bucketNumber] = hash
 	}
 
 	if len(bucketHashes) == 0 {
@@ -100,6 +108,95 @@ func (m *Manager) addAssetToView(nodeID string, assetCID string) error {
 	return m.SaveBucket(bucketID, assetHashes)
 }
 
+// GetAssetRangeHashes returns the hashes of the non-empty sub-ranges of the range in the node's asset view, the key is the index of the sub-range
+func (m *Manager) GetAssetRangeHashes(nodeID string, r types.AssetRange) (map[uint32]string, error) {
+	if r.Level >= types.AssetRangeMaxLevel {
+		return nil, xerrors.Errorf("range level %d is the max level", r.Level)
+	}
+
+	assetHashes, err := m.loadAssetsOfRange(nodeID, r)
+	if err != nil {
+		return nil, err
+	}
+
+	subRanges := make(map[uint32][]string)
+	for _, h := range assetHashes {
+		key, err := determineAssetKey(h)
+		if err != nil {
+			return nil, err
+		}
+
+		index := r.SubRange(key).Index
+		subRanges[index] = append(subRanges[index], h)
+	}
+
+	out := make(map[uint32]string, len(subRanges))
+	for index, hashes := range subRanges {
+		hash, err := computeBucketHash(hashes)
+		if err != nil {
+			return nil, err
+		}
+		out[index] = hash
+	}
+
+	return out, nil
+}
+
+// GetAssetListForRange returns the CIDs of the assets in the range of the node's asset view
+func (m *Manager) GetAssetListForRange(nodeID string, r types.AssetRange) ([]string, error) {
+	assetHashes, err := m.loadAssetsOfRange(nodeID, r)
+	if err != nil {
+		return nil, err
+	}
+
+	cids := make([]string, 0, len(assetHashes))
+	for _, h := range assetHashes {
+		c, err := cidutil.HashToCID(h)
+		if err != nil {
+			return nil, err
+		}
+		cids = append(cids, c)
+	}
+
+	return cids, nil
+}
+
+// loadAssetsOfRange returns the sorted asset hashes in the range of the node's asset view
+func (m *Manager) loadAssetsOfRange(nodeID string, r types.AssetRange) ([]string, error) {
+	assetHashes, err := m.LoadBucket(fmt.Sprintf("%s:%d", nodeID, r.Bucket))
+	if err != nil {
+		return nil, xerrors.Errorf("load bucket error %w", err)
+	}
+
+	out := make([]string, 0, len(assetHashes))
+	for _, h := range assetHashes {
+		key, err := determineAssetKey(h)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Contains(key) {
+			out = append(out, h)
+		}
+	}
+
+	return out, nil
+}
+
+// determineAssetKey calculates the key of an asset hash to locate the range in its bucket
+func determineAssetKey(assetHash string) (uint32, error) {
+	mh, err := hex.DecodeString(assetHash)
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New32a()
+	if _, err := h.Write(mh); err != nil {
+		return 0, err
+	}
+	return h.Sum32() / numAssetBuckets, nil
+}
+
 // determineBucketNumber calculates the bucket number for a given CID
 func determineBucketNumber(c cid.Cid) uint32 {
 	h := fnv.New32a()
@@ -133,11 +230,19 @@ func computeBucketHash(hashes []string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// calculateOverallHash computes the top hash for a given map of bucket hashes
+// calculateOverallHash computes the top hash for a given map of bucket hashes in the order of the bucket number
 func calculateOverallHash(hashes map[uint32]string) (string, error) {
+	bucketNumbers := make([]uint32, 0, len(hashes))
+	for bucketNumber := range hashes {
+		bucketNumbers = append(bucketNumbers, bucketNumber)
+	}
+	sort.Slice(bucketNumbers, func(i, j int) bool {
+		return bucketNumbers[i] < bucketNumbers[j]
+	})
+
 	hash := sha256.New()
-	for _, h := range hashes {
-		if cs, err := hex.DecodeString(h); err != nil {
+	for _, bucketNumber := range bucketNumbers {
+		if cs, err := hex.DecodeString(hashes[bucketNumber]); err != nil {
 			return "", err
 		} else if _, err := hash.Write(cs); err != nil {
 			return "", err
@@ -56,9 +56,9 @@ func (n *SQLDB) BatchSaveReplicas(infos []*types.ReplicaInfo) error {
 // SaveAssetRecord inserts or updates asset record information
 func (n *SQLDB) SaveAssetRecord(info *types.AssetRecord) error {
 	query := fmt.Sprintf(
-		`INSERT INTO %s (hash, cid, state, edge_replicas, candidate_replicas, expiration, total_size, total_blocks, scheduler_sid, end_time) 
-				VALUES (:hash, :cid, :state, :edge_replicas, :candidate_replicas, :expiration, :total_size, :total_blocks, :scheduler_sid, NOW()) 
-				ON DUPLICATE KEY UPDATE total_size=VALUES(total_size), total_blocks=VALUES(total_blocks), state=VALUES(state), edge_replicas=VALUES(edge_replicas), candidate_replicas=VALUES(candidate_replicas), end_time=NOW()`, assetRecordTable)
+		`INSERT INTO %s (hash, cid, state, edge_replicas, candidate_replicas, expiration, total_size, total_blocks, scheduler_sid, node_selector, end_time) 
+				VALUES (:hash, :cid, :state, :edge_replicas, :candidate_replicas, :expiration, :total_size, :total_blocks, :scheduler_sid, :node_selector, NOW()) 
+				ON DUPLICATE KEY UPDATE total_size=VALUES(total_size), total_blocks=VALUES(total_blocks), state=VALUES(state), edge_replicas=VALUES(edge_replicas), candidate_replicas=VALUES(candidate_replicas), node_selector=VALUES(node_selector), end_time=NOW()`, assetRecordTable)
 
 	_, err := n.db.NamedExec(query, info)
 	return err
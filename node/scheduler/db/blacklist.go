@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+// SaveNodeBlacklistEntry inserts or updates a ban or quarantine of a node
+func (n *SQLDB) SaveNodeBlacklistEntry(entry *types.NodeBlacklistEntry) error {
+	query := fmt.Sprintf(`INSERT INTO %s (node_id, type, reason, automatic, expiration, created_time)
+		VALUES (:node_id, :type, :reason, :automatic, :expiration, NOW())
+		ON DUPLICATE KEY UPDATE reason=VALUES(reason), automatic=VALUES(automatic), expiration=VALUES(expiration), created_time=NOW()`, nodeBlacklistTable)
+
+	_, err := n.db.NamedExec(query, entry)
+	return err
+}
+
+// DeleteNodeBlacklistEntry removes the ban or quarantine of a node
+func (n *SQLDB) DeleteNodeBlacklistEntry(nodeID string, t types.NodeBlacklistType) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE node_id=? AND type=?`, nodeBlacklistTable)
+	_, err := n.db.Exec(query, nodeID, t)
+	return err
+}
+
+// LoadNodeBlacklistEntry loads the unexpired ban or quarantine of a node, it returns sql.ErrNoRows if there is none
+func (n *SQLDB) LoadNodeBlacklistEntry(nodeID string, t types.NodeBlacklistType) (*types.NodeBlacklistEntry, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE node_id=? AND type=? AND expiration>NOW()`, nodeBlacklistTable)
+
+	var out types.NodeBlacklistEntry
+	if err := n.db.Get(&out, query, nodeID, t); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// LoadNodeBlacklistEntries loads the unexpired bans and quarantines of the nodes
+func (n *SQLDB) LoadNodeBlacklistEntries() ([]*types.NodeBlacklistEntry, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE expiration>NOW() ORDER BY created_time DESC`, nodeBlacklistTable)
+
+	var out []*types.NodeBlacklistEntry
+	if err := n.db.Select(&out, query); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// DeleteExpiredNodeBlacklistEntries removes the expired bans and quarantines
+func (n *SQLDB) DeleteExpiredNodeBlacklistEntries() error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE expiration<=NOW()`, nodeBlacklistTable)
+	_, err := n.db.Exec(query)
+	return err
+}
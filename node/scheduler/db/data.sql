@@ -26,6 +26,9 @@ CREATE TABLE `node_info` (
     `blocks`             BIGINT       DEFAULT 0,
     `disk_usage`         FLOAT        DEFAULT 0,
     `scheduler_sid`      VARCHAR(128) NOT NULL,
+    `quit_count`         INT          DEFAULT 0,
+    `reputation`         FLOAT        DEFAULT 62.5,
+    `state`              TINYINT      DEFAULT 0,
     PRIMARY KEY (`node_id`)
 ) ENGINE=InnoDB COMMENT='Node information';
 
@@ -41,26 +44,77 @@ CREATE TABLE `validation_result` (
     `bandwidth`     FLOAT        DEFAULT 0,
     `start_time`    DATETIME     DEFAULT NULL,
     `end_time`      DATETIME     DEFAULT NULL,
+    `beacon_round`  BIGINT       DEFAULT 0,
+    `candidate_id`  VARCHAR(128) DEFAULT '',
+    `validation_type` TINYINT    DEFAULT 0,
     KEY `round_node` (`round_id`, `node_id`)
 ) ENGINE=InnoDB COMMENT='Validation results';
 
+CREATE TABLE `validation_round` (
+    `round_id`      VARCHAR(128) NOT NULL,
+    `scheduler_sid` VARCHAR(128) NOT NULL,
+    `seed`          BIGINT       DEFAULT 0,
+    `beacon_round`  BIGINT       DEFAULT 0,
+    `pairings`      MEDIUMTEXT,
+    `start_time`    DATETIME     DEFAULT CURRENT_TIMESTAMP,
+    `ended`         BOOLEAN      DEFAULT false,
+    PRIMARY KEY (`round_id`),
+    KEY `idx_scheduler_time` (`scheduler_sid`, `start_time`)
+) ENGINE=InnoDB COMMENT='Validation rounds';
+
 -- Block download information table
 CREATE TABLE `block_download_info` (
     `id`             VARCHAR(64)  NOT NULL UNIQUE,
+    `credential_id`  VARCHAR(128) DEFAULT '',
     `block_cid`      VARCHAR(128) NOT NULL,
     `node_id`        VARCHAR(128) NOT NULL,
-    `carfile_cid`    VARCHAR(128) NOT NULL,
-    `block_size`     INT(20)      DEFAULT 0,
-    `speed`          INT(20)      DEFAULT 0,
+    `asset_cid`      VARCHAR(128) NOT NULL,
+    `block_size`     BIGINT       DEFAULT 0,
+    `speed`          BIGINT       DEFAULT 0,
+    `duration`       BIGINT       DEFAULT 0,
     `reward`         INT(20)      DEFAULT 0,
     `status`         TINYINT      DEFAULT 0,
     `failed_reason`  VARCHAR(128) DEFAULT '',
-    `client_ip`      VARCHAR(18)  NOT NULL,
+    `client_ip`      VARCHAR(64)  NOT NULL,
     `created_time`   DATETIME     DEFAULT CURRENT_TIMESTAMP,
     `complete_time`  DATETIME,
-    PRIMARY KEY (`id`)
+    PRIMARY KEY (`id`),
+    KEY `idx_node_id` (`node_id`),
+    KEY `idx_asset_cid` (`asset_cid`),
+    KEY `idx_created_time` (`created_time`)
 ) ENGINE=InnoDB COMMENT='Block download information';
 
+-- Credentials issued to users
+CREATE TABLE `credential_info` (
+    `id`            VARCHAR(64)  NOT NULL UNIQUE,
+    `node_id`       VARCHAR(128) NOT NULL,
+    `asset_cid`     VARCHAR(128) NOT NULL,
+    `client_id`     VARCHAR(128) DEFAULT '',
+    `limit_rate`    BIGINT       DEFAULT 0,
+    `valid_time`    BIGINT       NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_valid_time` (`valid_time`)
+) ENGINE=InnoDB COMMENT='Credentials issued to users';
+
+-- User proofs of work table
+CREATE TABLE `proof_of_work` (
+    `id`              BIGINT       NOT NULL AUTO_INCREMENT,
+    `ticket_id`       VARCHAR(64)  NOT NULL,
+    `client_id`       VARCHAR(128) DEFAULT '',
+    `node_id`         VARCHAR(128) NOT NULL,
+    `asset_cid`       VARCHAR(128) NOT NULL,
+    `download_speed`  BIGINT       DEFAULT 0,
+    `download_size`   BIGINT       DEFAULT 0,
+    `start_time`      DATETIME(3)  NOT NULL,
+    `end_time`        DATETIME(3)  NOT NULL,
+    `status`          TINYINT      DEFAULT 0,
+    `created_time`    DATETIME     DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_ticket_start` (`ticket_id`, `start_time`),
+    KEY `idx_node_id` (`node_id`),
+    KEY `idx_created_time` (`created_time`)
+) ENGINE=InnoDB COMMENT='User proofs of work';
+
 -- Node register information table
 CREATE TABLE `node_register_info` (
 	`node_id`     VARCHAR(128)  NOT NULL UNIQUE,
@@ -92,6 +146,7 @@ CREATE TABLE `asset_record` (
 	`candidate_replicas` TINYINT      DEFAULT 0 ,
 	`state`              VARCHAR(128) NOT NULL DEFAULT '',
     `expiration`         DATETIME     NOT NULL,
+    `node_selector`      VARCHAR(256) DEFAULT '',
     `created_time`       DATETIME     DEFAULT CURRENT_TIMESTAMP,
 	`end_time`           DATETIME     DEFAULT CURRENT_TIMESTAMP,
     `scheduler_sid`      VARCHAR(128) NOT NULL,
@@ -106,6 +161,7 @@ CREATE TABLE `edge_update_info` (
     `version`      VARCHAR(32)  NOT NULL,
     `hash`         VARCHAR(128) NOT NULL,
 	`download_url` VARCHAR(128) NOT NULL,
+    `node_selector` VARCHAR(256) DEFAULT '',
     `update_time`  DATETIME     DEFAULT CURRENT_TIMESTAMP,
 	PRIMARY KEY (`node_type`)
 ) ENGINE=InnoDB COMMENT='edge update info';
@@ -114,9 +170,22 @@ CREATE TABLE `edge_update_info` (
 CREATE TABLE `validators` (
     `node_id`       VARCHAR(128) NOT NULL,
     `scheduler_sid` VARCHAR(128) NOT NULL,
+    `elected_time`  DATETIME     DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (`node_id`)
 ) ENGINE=InnoDB COMMENT='validators';
 
+CREATE TABLE `election_record` (
+    `id`            BIGINT       NOT NULL AUTO_INCREMENT,
+    `scheduler_sid` VARCHAR(128) NOT NULL,
+    `reason`        VARCHAR(32)  DEFAULT '',
+    `seed`          BIGINT       DEFAULT 0,
+    `candidates`    MEDIUMTEXT,
+    `validators`    TEXT,
+    `created_time`  DATETIME     DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_scheduler_time` (`scheduler_sid`, `created_time`)
+) ENGINE=InnoDB COMMENT='validator election records';
+
 CREATE TABLE `asset_view` (
     `node_id`       VARCHAR(128) NOT NULL UNIQUE,
     `top_hash`      VARCHAR(128) NOT NULL,
@@ -128,4 +197,54 @@ CREATE TABLE `bucket` (
     `bucket_id`    VARCHAR(128) NOT NULL UNIQUE,
     `asset_hashes` BLOB         NOT NULL,
     PRIMARY KEY (`bucket_id`)
-) ENGINE=InnoDB COMMENT='bucket';
\ No newline at end of file
+) ENGINE=InnoDB COMMENT='bucket';
+
+CREATE TABLE `node_label` (
+    `node_id`     VARCHAR(128) NOT NULL,
+    `label_key`   VARCHAR(64)  NOT NULL,
+    `label_value` VARCHAR(128) NOT NULL,
+    PRIMARY KEY (`node_id`, `label_key`),
+    KEY `idx_label` (`label_key`, `label_value`)
+) ENGINE=InnoDB COMMENT='node labels';
+
+CREATE TABLE `node_blacklist` (
+    `node_id`      VARCHAR(128) NOT NULL,
+    `type`         TINYINT      NOT NULL,
+    `reason`       VARCHAR(256) DEFAULT '',
+    `automatic`    BOOLEAN      DEFAULT false,
+    `expiration`   DATETIME     NOT NULL,
+    `created_time` DATETIME     DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`node_id`, `type`)
+) ENGINE=InnoDB COMMENT='node ban list and quarantine';
+
+CREATE TABLE `node_stats` (
+    `node_id`        VARCHAR(128) NOT NULL,
+    `resolution`     INT          NOT NULL,
+    `time`           DATETIME     NOT NULL,
+    `samples`        INT          DEFAULT 0,
+    `cpu_usage`      FLOAT        DEFAULT 0,
+    `memory_usage`   FLOAT        DEFAULT 0,
+    `disk_usage`     FLOAT        DEFAULT 0,
+    `bandwidth_up`   FLOAT        DEFAULT 0,
+    `bandwidth_down` FLOAT        DEFAULT 0,
+    PRIMARY KEY (`node_id`, `resolution`, `time`),
+    KEY `idx_resolution_time` (`resolution`, `time`)
+) ENGINE=InnoDB COMMENT='node resource stats, averaged in the time buckets of the resolution';
+
+CREATE TABLE `node_nat_history` (
+    `id`            INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `node_id`       VARCHAR(128) NOT NULL,
+    `nat_type`      VARCHAR(32)  NOT NULL,
+    `external_addr` VARCHAR(64)  DEFAULT '',
+    `created_time`  DATETIME     DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_node_time` (`node_id`, `created_time`)
+) ENGINE=InnoDB COMMENT='node nat type history';
+
+CREATE TABLE `nat_punch_stats` (
+    `nat_type`     VARCHAR(32) NOT NULL,
+    `attempts`     BIGINT      DEFAULT 0,
+    `successes`    BIGINT      DEFAULT 0,
+    `updated_time` DATETIME    DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`nat_type`)
+) ENGINE=InnoDB COMMENT='hole punch statistics of the edges by nat type';
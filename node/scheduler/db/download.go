@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+// SaveDownloadRecords inserts the download records, the records already saved are ignored.
+func (n *SQLDB) SaveDownloadRecords(records []*types.DownloadHistory) error {
+	query := fmt.Sprintf(`INSERT IGNORE INTO %s (id, credential_id, block_cid, node_id, asset_cid, block_size, speed, duration, status, failed_reason, client_ip, created_time, complete_time)
+		VALUES (:id, :credential_id, :block_cid, :node_id, :asset_cid, :block_size, :speed, :duration, :status, :failed_reason, :client_ip, :created_time, :complete_time)`, blockDownloadTable)
+	_, err := n.db.NamedExec(query, records)
+
+	return err
+}
+
+// LoadDownloadRecords load the download records of the node or asset within the time range.
+func (n *SQLDB) LoadDownloadRecords(nodeID, assetCID string, startTime, endTime time.Time, cursor, count int) (*types.ListDownloadRecordRsp, error) {
+	conditions := []string{"created_time between ? and ?"}
+	args := []interface{}{startTime, endTime}
+
+	if nodeID != "" {
+		conditions = append(conditions, "node_id=?")
+		args = append(args, nodeID)
+	}
+
+	if assetCID != "" {
+		conditions = append(conditions, "asset_cid=?")
+		args = append(args, assetCID)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT count(id) FROM %s WHERE %s`, blockDownloadTable, where)
+	if err := n.db.Get(&total, countSQL, args...); err != nil {
+		return nil, err
+	}
+
+	if count > loadDownloadRecordsLimit {
+		count = loadDownloadRecordsLimit
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE %s ORDER BY created_time DESC LIMIT ?,?`, blockDownloadTable, where)
+
+	var out []types.DownloadHistory
+	if err := n.db.Select(&out, query, append(args, cursor, count)...); err != nil {
+		return nil, err
+	}
+
+	return &types.ListDownloadRecordRsp{Data: out, Total: total}, nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
+)
+
+// SaveElectionRecord saves the audit record of a validator election
+func (n *SQLDB) SaveElectionRecord(record *types.ElectionRecord) error {
+	query := fmt.Sprintf(`INSERT INTO %s (scheduler_sid, reason, seed, candidates, validators, created_time) 
+		VALUES (:scheduler_sid, :reason, :seed, :candidates, :validators, NOW())`, electionRecordTable)
+
+	_, err := n.db.NamedExec(query, record)
+	return err
+}
+
+// LoadElectionRecords loads the election records of the scheduler, the latest first
+func (n *SQLDB) LoadElectionRecords(serverID dtypes.ServerID, cursor, count int) (*types.ListElectionRecordsRsp, error) {
+	res := new(types.ListElectionRecordsRsp)
+
+	if count > loadElectionRecordsLimit {
+		count = loadElectionRecordsLimit
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE scheduler_sid=? ORDER BY id DESC LIMIT ? OFFSET ?`, electionRecordTable)
+	err := n.db.Select(&res.Records, query, serverID, count, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := fmt.Sprintf(`SELECT count(*) FROM %s WHERE scheduler_sid=?`, electionRecordTable)
+	err = n.db.Get(&res.Total, countQuery, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// LoadLatestElectionTime loads the time of the latest election of the scheduler
+func (n *SQLDB) LoadLatestElectionTime(serverID dtypes.ServerID) (time.Time, error) {
+	var createdTime time.Time
+	query := fmt.Sprintf(`SELECT created_time FROM %s WHERE scheduler_sid=? ORDER BY id DESC LIMIT 1`, electionRecordTable)
+	err := n.db.Get(&createdTime, query, serverID)
+
+	return createdTime, err
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+// SaveNodeNATType updates the NAT type of the node, and adds a history record if the NAT type or the external ip changes
+func (n *SQLDB) SaveNodeNATType(record *types.NodeNATRecord) (bool, error) {
+	last, err := n.loadLastNodeNATRecord(record.NodeID)
+	if err != nil {
+		return false, err
+	}
+
+	if last != nil && last.NATType == record.NATType && last.ExternalAddr == record.ExternalAddr {
+		return false, nil
+	}
+
+	tx, err := n.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveNodeNATType Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`INSERT INTO %s (node_id, nat_type, external_addr, created_time) VALUES (?, ?, ?, NOW())`, nodeNATHistoryTable)
+	if _, err := tx.Exec(query, record.NodeID, record.NATType, record.ExternalAddr); err != nil {
+		return false, err
+	}
+
+	uQuery := fmt.Sprintf(`UPDATE %s SET nat_type=? WHERE node_id=?`, nodeInfoTable)
+	if _, err := tx.Exec(uQuery, record.NATType, record.NodeID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// loadLastNodeNATRecord loads the latest NAT type record of the node, it is nil if there is none
+func (n *SQLDB) loadLastNodeNATRecord(nodeID string) (*types.NodeNATRecord, error) {
+	records, err := n.LoadNodeNATHistory(nodeID, 1)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+
+	return records[0], nil
+}
+
+// LoadNodeNATHistory loads the NAT type records of the node, the latest first
+func (n *SQLDB) LoadNodeNATHistory(nodeID string, limit int) ([]*types.NodeNATRecord, error) {
+	query := fmt.Sprintf(`SELECT node_id, nat_type, external_addr, created_time FROM %s WHERE node_id=? ORDER BY created_time DESC, id DESC LIMIT ?`, nodeNATHistoryTable)
+
+	var out []*types.NodeNATRecord
+	if err := n.db.Select(&out, query, nodeID, limit); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// DeleteNodeNATHistoryBefore removes the NAT type records older than the time, the latest record of every node is kept
+func (n *SQLDB) DeleteNodeNATHistoryBefore(t time.Time) error {
+	query := fmt.Sprintf(`DELETE h FROM %s h JOIN (SELECT node_id, MAX(id) AS id FROM %s GROUP BY node_id) l
+		ON h.node_id=l.node_id WHERE h.created_time<? AND h.id<l.id`, nodeNATHistoryTable, nodeNATHistoryTable)
+	_, err := n.db.Exec(query, t)
+	return err
+}
+
+// AddNATPunchResult counts a hole punch to an edge with the NAT type
+func (n *SQLDB) AddNATPunchResult(natType string, success bool) error {
+	successes := 0
+	if success {
+		successes = 1
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (nat_type, attempts, successes) VALUES (?, 1, ?)
+		ON DUPLICATE KEY UPDATE attempts=attempts+1, successes=successes+VALUES(successes)`, natPunchStatsTable)
+	_, err := n.db.Exec(query, natType, successes)
+	return err
+}
+
+// LoadNATPunchStats loads the hole punch statistics of the NAT types
+func (n *SQLDB) LoadNATPunchStats() ([]*types.NatPunchStats, error) {
+	query := fmt.Sprintf(`SELECT nat_type, attempts, successes FROM %s`, natPunchStatsTable)
+
+	var out []*types.NatPunchStats
+	if err := n.db.Select(&out, query); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
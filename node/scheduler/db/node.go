@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"encoding/gob"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api"
@@ -33,7 +34,7 @@ func (n *SQLDB) LoadTimeoutNodes(timeoutHour int, serverID dtypes.ServerID) ([]s
 
 // UpdateNodesQuitted updates the status of a list of nodes as quitted.
 func (n *SQLDB) UpdateNodesQuitted(nodeIDs []string) error {
-	uQuery := fmt.Sprintf(`UPDATE %s SET quitted=? WHERE node_id in (?) `, nodeInfoTable)
+	uQuery := fmt.Sprintf(`UPDATE %s SET quitted=?, quit_count=quit_count+1 WHERE node_id in (?) `, nodeInfoTable)
 	query, args, err := sqlx.In(uQuery, true, nodeIDs)
 	if err != nil {
 		return err
@@ -70,7 +71,16 @@ func (n *SQLDB) UpdatePortMapping(nodeID, port string) error {
 
 // SaveValidationResultInfos inserts validation result information.
 func (n *SQLDB) SaveValidationResultInfos(infos []*types.ValidationResultInfo) error {
-	query := fmt.Sprintf(`INSERT INTO %s (round_id, node_id, validator_id, status, cid) VALUES (:round_id, :node_id, :validator_id, :status, :cid)`, validationResultTable)
+	query := fmt.Sprintf(`INSERT INTO %s (round_id, node_id, validator_id, status, cid, beacon_round, validation_type, start_time) VALUES (:round_id, :node_id, :validator_id, :status, :cid, :beacon_round, :validation_type, NOW())`, validationResultTable)
+	_, err := n.db.NamedExec(query, infos)
+
+	return err
+}
+
+// SaveStorageProofResults inserts the results of the storage proofs, they are finished when saved.
+func (n *SQLDB) SaveStorageProofResults(infos []*types.ValidationResultInfo) error {
+	query := fmt.Sprintf(`INSERT INTO %s (round_id, node_id, validator_id, status, cid, block_number, beacon_round, candidate_id, validation_type, start_time, end_time) 
+		VALUES (:round_id, :node_id, :validator_id, :status, :cid, :block_number, :beacon_round, :candidate_id, :validation_type, :start_time, NOW())`, validationResultTable)
 	_, err := n.db.NamedExec(query, infos)
 
 	return err
@@ -92,11 +102,38 @@ func (n *SQLDB) UpdateValidationResultInfo(info *types.ValidationResultInfo) err
 		return err
 	}
 
-	query := fmt.Sprintf(`UPDATE %s SET status=:status, end_time=NOW() WHERE round_id=:round_id AND node_id=:node_id`, validationResultTable)
+	query := fmt.Sprintf(`UPDATE %s SET status=:status, candidate_id=:candidate_id, end_time=NOW() WHERE round_id=:round_id AND node_id=:node_id`, validationResultTable)
 	_, err := n.db.NamedExec(query, info)
 	return err
 }
 
+// LoadCandidateBlockOffenders loads the candidates which return wrong reference blocks at least minCount times since the given time.
+func (n *SQLDB) LoadCandidateBlockOffenders(since time.Time, minCount int) ([]string, error) {
+	query := fmt.Sprintf(`SELECT candidate_id FROM %s WHERE status=? AND candidate_id!='' AND end_time>=? GROUP BY candidate_id HAVING COUNT(*)>=?`, validationResultTable)
+
+	var nodeIDs []string
+	err := n.db.Select(&nodeIDs, query, types.ValidationStatusCandidateBlockErr, since, minCount)
+	return nodeIDs, err
+}
+
+// LoadValidationFailedNodes loads the nodes in the list which fail the validation since the given time.
+func (n *SQLDB) LoadValidationFailedNodes(nodeIDs []string, since time.Time) ([]string, error) {
+	if len(nodeIDs) == 0 {
+		return nil, nil
+	}
+
+	sQuery := fmt.Sprintf(`SELECT DISTINCT node_id FROM %s WHERE node_id IN (?) AND status=? AND end_time>=?`, validationResultTable)
+	query, args, err := sqlx.In(sQuery, nodeIDs, types.ValidationStatusValidateFail, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	query = n.db.Rebind(query)
+	err = n.db.Select(&out, query, args...)
+	return out, err
+}
+
 // UpdateValidationResultsTimeout sets the validation results' status as timeout.
 func (n *SQLDB) UpdateValidationResultsTimeout(roundID string) error {
 	query := fmt.Sprintf(`UPDATE %s SET status=?, end_time=NOW() WHERE round_id=? AND status=?`, validationResultTable)
@@ -104,6 +141,175 @@ func (n *SQLDB) UpdateValidationResultsTimeout(roundID string) error {
 	return err
 }
 
+// LoadValidationResults load the validation results filtered by node, validator, round, status and time range, the latest first.
+func (n *SQLDB) LoadValidationResults(req types.ListValidationResultsReq) (*types.ListValidationResultRsp, error) {
+	var conditions []string
+	var args []interface{}
+
+	if req.NodeID != "" {
+		conditions = append(conditions, "node_id=?")
+		args = append(args, req.NodeID)
+	}
+
+	if req.ValidatorID != "" {
+		conditions = append(conditions, "validator_id=?")
+		args = append(args, req.ValidatorID)
+	}
+
+	if req.RoundID != "" {
+		conditions = append(conditions, "round_id=?")
+		args = append(args, req.RoundID)
+	}
+
+	if len(req.Statuses) > 0 {
+		conditions = append(conditions, "status IN (?)")
+		args = append(args, req.Statuses)
+	}
+
+	if req.StartTime > 0 {
+		conditions = append(conditions, "start_time>=?")
+		args = append(args, time.Unix(req.StartTime, 0))
+	}
+
+	if req.EndTime > 0 {
+		conditions = append(conditions, "start_time<=?")
+		args = append(args, time.Unix(req.EndTime, 0))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	count := req.Count
+	if count > loadValidationResultsLimit || count <= 0 {
+		count = loadValidationResultsLimit
+	}
+
+	res := new(types.ListValidationResultRsp)
+
+	sQuery := fmt.Sprintf("SELECT *, (duration/1e3 * bandwidth) AS `upload_traffic` FROM %s %s ORDER BY start_time DESC LIMIT ? OFFSET ?", validationResultTable, where)
+	query, qArgs, err := sqlx.In(sQuery, append(args, count, req.Cursor)...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.db.Select(&res.ValidationResultInfos, n.db.Rebind(query), qArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	cQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", validationResultTable, where)
+	query, qArgs, err = sqlx.In(cQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.db.Get(&res.Total, n.db.Rebind(query), qArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// LoadValidationSummaries load the validation statistics of the nodes in the time range, the nodes with the lowest success ratio first.
+func (n *SQLDB) LoadValidationSummaries(nodeID string, startTime, endTime time.Time, cursor, count int) (*types.ListValidationSummaryRsp, error) {
+	where := "WHERE start_time BETWEEN ? AND ?"
+	args := []interface{}{startTime, endTime}
+	if nodeID != "" {
+		where += " AND node_id=?"
+		args = append(args, nodeID)
+	}
+
+	if count > loadValidationResultsLimit || count <= 0 {
+		count = loadValidationResultsLimit
+	}
+
+	res := new(types.ListValidationSummaryRsp)
+
+	query := fmt.Sprintf(`SELECT node_id, total, succeeded, failed, timeouts, bandwidth, last_failed_time,
+		COALESCE(succeeded/NULLIF(succeeded+failed+timeouts, 0), 0) AS success_ratio FROM (
+		SELECT node_id, COUNT(*) AS total, SUM(status=?) AS succeeded, SUM(status=?) AS failed, SUM(status=?) AS timeouts,
+		COALESCE(AVG(CASE WHEN status=? AND validation_type=? THEN bandwidth END), 0) AS bandwidth,
+		MAX(CASE WHEN status IN (?,?) THEN end_time END) AS last_failed_time
+		FROM %s %s GROUP BY node_id) s ORDER BY success_ratio ASC, node_id LIMIT ? OFFSET ?`, validationResultTable, where)
+
+	qArgs := []interface{}{
+		types.ValidationStatusSuccess, types.ValidationStatusValidateFail, types.ValidationStatusNodeTimeOut,
+		types.ValidationStatusSuccess, types.ValidationTypeBandwidth,
+		types.ValidationStatusValidateFail, types.ValidationStatusNodeTimeOut,
+	}
+	qArgs = append(append(qArgs, args...), count, cursor)
+
+	err := n.db.Select(&res.Summaries, query, qArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	cQuery := fmt.Sprintf("SELECT COUNT(DISTINCT node_id) FROM %s %s", validationResultTable, where)
+	err = n.db.Get(&res.Total, cQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// SaveValidationRound inserts the state of a validation round.
+func (n *SQLDB) SaveValidationRound(info *types.ValidationRoundInfo) error {
+	query := fmt.Sprintf(`INSERT INTO %s (round_id, scheduler_sid, seed, beacon_round, pairings, start_time) 
+		VALUES (:round_id, :scheduler_sid, :seed, :beacon_round, :pairings, :start_time)`, validationRoundTable)
+	_, err := n.db.NamedExec(query, info)
+
+	return err
+}
+
+// LoadValidationRound load the state of a validation round.
+func (n *SQLDB) LoadValidationRound(roundID string) (*types.ValidationRoundInfo, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE round_id=?", validationRoundTable)
+	var info types.ValidationRoundInfo
+	err := n.db.Get(&info, query, roundID)
+	return &info, err
+}
+
+// LoadUnfinishedValidationRounds load the rounds of the scheduler which are not ended, the latest first.
+func (n *SQLDB) LoadUnfinishedValidationRounds(serverID dtypes.ServerID) ([]*types.ValidationRoundInfo, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE scheduler_sid=? AND ended=false ORDER BY start_time DESC", validationRoundTable)
+	var out []*types.ValidationRoundInfo
+	err := n.db.Select(&out, query, serverID)
+	return out, err
+}
+
+// EndValidationRound sets the round as ended and the results which are not received as timeout.
+func (n *SQLDB) EndValidationRound(roundID string) error {
+	tx, err := n.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("EndValidationRound Rollback err:%s", err.Error())
+		}
+	}()
+
+	rQuery := fmt.Sprintf(`UPDATE %s SET status=?, end_time=NOW() WHERE round_id=? AND status=?`, validationResultTable)
+	_, err = tx.Exec(rQuery, types.ValidationStatusValidatorTimeOut, roundID, types.ValidationStatusCreate)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET ended=true WHERE round_id=?`, validationRoundTable)
+	_, err = tx.Exec(query, roundID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // LoadValidationResultInfos load validation results.
 func (n *SQLDB) LoadValidationResultInfos(startTime, endTime time.Time, pageNumber, pageSize int) (*types.ListValidationResultRsp, error) {
 	// TODO problematic from web
@@ -136,7 +342,7 @@ func (n *SQLDB) LoadValidationResultInfos(startTime, endTime time.Time, pageNumb
 
 // SaveEdgeUpdateConfig inserts edge update information.
 func (n *SQLDB) SaveEdgeUpdateConfig(info *api.EdgeUpdateConfig) error {
-	sqlString := fmt.Sprintf(`INSERT INTO %s (node_type, app_name, version, hash, download_url) VALUES (:node_type, :app_name, :version, :hash, :download_url) ON DUPLICATE KEY UPDATE app_name=:app_name, version=:version, hash=:hash, download_url=:download_url`, edgeUpdateTable)
+	sqlString := fmt.Sprintf(`INSERT INTO %s (node_type, app_name, version, hash, download_url, node_selector) VALUES (:node_type, :app_name, :version, :hash, :download_url, :node_selector) ON DUPLICATE KEY UPDATE app_name=:app_name, version=:version, hash=:hash, download_url=:download_url, node_selector=:node_selector`, edgeUpdateTable)
 	_, err := n.db.NamedExec(sqlString, info)
 	return err
 }
@@ -178,16 +384,28 @@ func (n *SQLDB) UpdateValidators(nodeIDs []string, serverID dtypes.ServerID) err
 		}
 	}()
 
-	// clean old validators
-	dQuery := fmt.Sprintf(`DELETE FROM %s WHERE scheduler_sid=? `, validatorsTable)
-	_, err = tx.Exec(dQuery, serverID)
-	if err != nil {
-		return err
+	// clean old validators, the re-elected validators keep their elected time
+	if len(nodeIDs) > 0 {
+		dQuery, args, err := sqlx.In(fmt.Sprintf(`DELETE FROM %s WHERE scheduler_sid=? AND node_id NOT IN (?)`, validatorsTable), serverID, nodeIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(tx.Rebind(dQuery), args...)
+		if err != nil {
+			return err
+		}
+	} else {
+		dQuery := fmt.Sprintf(`DELETE FROM %s WHERE scheduler_sid=? `, validatorsTable)
+		_, err = tx.Exec(dQuery, serverID)
+		if err != nil {
+			return err
+		}
 	}
 
 	for _, nodeID := range nodeIDs {
-		iQuery := fmt.Sprintf(`INSERT INTO %s (node_id, scheduler_sid) VALUES (?, ?)`, validatorsTable)
-		_, err = tx.Exec(iQuery, nodeID, serverID)
+		iQuery := fmt.Sprintf(`INSERT INTO %s (node_id, scheduler_sid, elected_time) VALUES (?, ?, NOW()) ON DUPLICATE KEY UPDATE scheduler_sid=?`, validatorsTable)
+		_, err = tx.Exec(iQuery, nodeID, serverID, serverID)
 		if err != nil {
 			return err
 		}
@@ -209,6 +427,19 @@ func (n *SQLDB) LoadValidators(serverID dtypes.ServerID) ([]string, error) {
 	return out, nil
 }
 
+// LoadValidatorInfos load validators with their elected time.
+func (n *SQLDB) LoadValidatorInfos(serverID dtypes.ServerID) ([]*types.ValidatorInfo, error) {
+	sQuery := fmt.Sprintf(`SELECT node_id, elected_time FROM %s WHERE scheduler_sid=?`, validatorsTable)
+
+	var out []*types.ValidatorInfo
+	err := n.db.Select(&out, sQuery, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
 // IsValidator Determine whether the node is a validator
 func (n *SQLDB) IsValidator(nodeID string) (bool, error) {
 	var count int64
@@ -308,6 +539,180 @@ func (n *SQLDB) LoadNodeOnlineDuration(nodeID string) (int, error) {
 	return t, nil
 }
 
+// LoadNodeReputation load reputation of node.
+func (n *SQLDB) LoadNodeReputation(nodeID string) (float64, error) {
+	var reputation float64
+
+	query := fmt.Sprintf(`SELECT reputation FROM %s WHERE node_id=?`, nodeInfoTable)
+	if err := n.db.Get(&reputation, query, nodeID); err != nil {
+		return reputation, err
+	}
+
+	return reputation, nil
+}
+
+// LoadNodeState load the service state of a node.
+func (n *SQLDB) LoadNodeState(nodeID string) (types.NodeState, error) {
+	var state types.NodeState
+
+	query := fmt.Sprintf(`SELECT state FROM %s WHERE node_id=?`, nodeInfoTable)
+	if err := n.db.Get(&state, query, nodeID); err != nil {
+		return state, err
+	}
+
+	return state, nil
+}
+
+// UpdateNodeState update the service state of a node.
+func (n *SQLDB) UpdateNodeState(nodeID string, state types.NodeState) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=? WHERE node_id=?`, nodeInfoTable)
+	_, err := n.db.Exec(query, state, nodeID)
+	return err
+}
+
+// LoadNodesOfState load the nodes of the scheduler in the service state.
+func (n *SQLDB) LoadNodesOfState(state types.NodeState, serverID dtypes.ServerID) ([]string, error) {
+	var out []string
+
+	query := fmt.Sprintf(`SELECT node_id FROM %s WHERE state=? AND scheduler_sid=?`, nodeInfoTable)
+	if err := n.db.Select(&out, query, state, serverID); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SaveNodeLabels inserts or updates the labels of a node.
+func (n *SQLDB) SaveNodeLabels(nodeID string, labels map[string]string) error {
+	tx, err := n.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveNodeLabels Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`INSERT INTO %s (node_id, label_key, label_value) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE label_value=VALUES(label_value)`, nodeLabelTable)
+	for key, value := range labels {
+		if _, err := tx.Exec(query, nodeID, key, value); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteNodeLabels delete the labels of a node with the keys.
+func (n *SQLDB) DeleteNodeLabels(nodeID string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(`DELETE FROM %s WHERE node_id=? AND label_key IN (?)`, nodeLabelTable), nodeID, keys)
+	if err != nil {
+		return err
+	}
+
+	query = n.db.Rebind(query)
+	_, err = n.db.Exec(query, args...)
+	return err
+}
+
+// LoadNodeLabels load the labels of a node.
+func (n *SQLDB) LoadNodeLabels(nodeID string) (map[string]string, error) {
+	labels, err := n.LoadLabelsOfNodes([]string{nodeID})
+	if err != nil {
+		return nil, err
+	}
+
+	out := labels[nodeID]
+	if out == nil {
+		out = make(map[string]string)
+	}
+
+	return out, nil
+}
+
+// LoadLabelsOfNodes load the labels of the nodes, the key of the map is the node id.
+func (n *SQLDB) LoadLabelsOfNodes(nodeIDs []string) (map[string]map[string]string, error) {
+	out := make(map[string]map[string]string)
+	if len(nodeIDs) == 0 {
+		return out, nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(`SELECT node_id, label_key, label_value FROM %s WHERE node_id IN (?)`, nodeLabelTable), nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		NodeID string `db:"node_id"`
+		Key    string `db:"label_key"`
+		Value  string `db:"label_value"`
+	}
+
+	query = n.db.Rebind(query)
+	if err := n.db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if out[row.NodeID] == nil {
+			out[row.NodeID] = make(map[string]string)
+		}
+		out[row.NodeID][row.Key] = row.Value
+	}
+
+	return out, nil
+}
+
+// LoadReputationFactors load the reputation factors of the nodes of the scheduler, the validations are counted since the given time.
+func (n *SQLDB) LoadReputationFactors(serverID dtypes.ServerID, since time.Time) ([]*types.ReputationFactors, error) {
+	query := fmt.Sprintf(`SELECT n.node_id, n.online_duration, n.quit_count, n.bandwidth_up,
+		COALESCE(TIMESTAMPDIFF(MINUTE, r.create_time, NOW()), 0) AS registered_duration,
+		COALESCE(v.validations, 0) AS validations, COALESCE(v.succeeded, 0) AS succeeded,
+		COALESCE(v.timeouts, 0) AS timeouts, COALESCE(v.bandwidth, 0) AS bandwidth
+		FROM %s n LEFT JOIN %s r ON n.node_id=r.node_id
+		LEFT JOIN (SELECT node_id, SUM(status IN (?,?,?)) AS validations, SUM(status=?) AS succeeded, SUM(status=?) AS timeouts,
+			AVG(CASE WHEN status=? AND validation_type=? THEN bandwidth END) AS bandwidth FROM %s WHERE end_time>=? GROUP BY node_id) v ON n.node_id=v.node_id
+		WHERE n.scheduler_sid=?`, nodeInfoTable, nodeRegisterTable, validationResultTable)
+
+	var out []*types.ReputationFactors
+	err := n.db.Select(&out, query,
+		types.ValidationStatusSuccess, types.ValidationStatusNodeTimeOut, types.ValidationStatusValidateFail,
+		types.ValidationStatusSuccess, types.ValidationStatusNodeTimeOut, types.ValidationStatusSuccess, types.ValidationTypeBandwidth,
+		since, serverID)
+	return out, err
+}
+
+// UpdateNodeReputations update the reputation of the nodes
+func (n *SQLDB) UpdateNodeReputations(reputations map[string]float64) error {
+	tx, err := n.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("UpdateNodeReputations Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET reputation=? WHERE node_id=?`, nodeInfoTable)
+	for nodeID, reputation := range reputations {
+		if _, err := tx.Exec(query, reputation, nodeID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // NodeExists is node exists
 func (n *SQLDB) NodeExists(nodeID string, nodeType types.NodeType) error {
 	var count int
@@ -324,11 +729,13 @@ func (n *SQLDB) NodeExists(nodeID string, nodeType types.NodeType) error {
 	return nil
 }
 
-// LoadNodeInfos load nodes information.
-func (n *SQLDB) LoadNodeInfos(limit, offset int) (*sqlx.Rows, int64, error) {
+// LoadNodeInfos load nodes information, the nodes are filtered by the label selector.
+func (n *SQLDB) LoadNodeInfos(limit, offset int, selector types.LabelSelector) (*sqlx.Rows, int64, error) {
+	where, args := labelSelectorCondition(selector)
+
 	var total int64
-	cQuery := fmt.Sprintf(`SELECT count(node_id) FROM %s`, nodeInfoTable)
-	err := n.db.Get(&total, cQuery)
+	cQuery := fmt.Sprintf(`SELECT count(node_id) FROM %s %s`, nodeInfoTable, where)
+	err := n.db.Get(&total, cQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -337,11 +744,32 @@ func (n *SQLDB) LoadNodeInfos(limit, offset int) (*sqlx.Rows, int64, error) {
 		limit = loadNodeInfosLimit
 	}
 
-	sQuery := fmt.Sprintf(`SELECT * FROM %s order by node_id asc LIMIT ? OFFSET ?`, nodeInfoTable)
-	rows, err := n.db.QueryxContext(context.Background(), sQuery, limit, offset)
+	sQuery := fmt.Sprintf(`SELECT * FROM %s %s order by node_id asc LIMIT ? OFFSET ?`, nodeInfoTable, where)
+	rows, err := n.db.QueryxContext(context.Background(), sQuery, append(args, limit, offset)...)
 	return rows, total, err
 }
 
+// labelSelectorCondition returns the where clause of the node_id matching the label selector, and its arguments
+func labelSelectorCondition(selector types.LabelSelector) (string, []interface{}) {
+	if len(selector) == 0 {
+		return "", nil
+	}
+
+	conditions := make([]string, 0, len(selector))
+	args := make([]interface{}, 0, len(selector)*2)
+	for _, r := range selector {
+		op := "IN"
+		if r.NotEqual {
+			op = "NOT IN"
+		}
+
+		conditions = append(conditions, fmt.Sprintf(`node_id %s (SELECT node_id FROM %s WHERE label_key=? AND label_value=?)`, op, nodeLabelTable))
+		args = append(args, r.Key, r.Value)
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
 // LoadNodeInfo load node information.
 func (n *SQLDB) LoadNodeInfo(nodeID string) (*types.NodeInfo, error) {
 	query := fmt.Sprintf(`SELECT * FROM %s WHERE node_id=?`, nodeInfoTable)
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+func TestLabelSelectorCondition(t *testing.T) {
+	subQuery := "(SELECT node_id FROM " + nodeLabelTable + " WHERE label_key=? AND label_value=?)"
+
+	tests := []struct {
+		name     string
+		selector types.LabelSelector
+		where    string
+		args     []interface{}
+	}{
+		{name: "empty"},
+		{
+			name:     "equal",
+			selector: types.LabelSelector{{Key: "rack", Value: "sz-3"}},
+			where:    "WHERE node_id IN " + subQuery,
+			args:     []interface{}{"rack", "sz-3"},
+		},
+		{
+			name:     "equal and not equal",
+			selector: types.LabelSelector{{Key: "rack", Value: "sz-3"}, {Key: "isp", Value: "ct", NotEqual: true}},
+			where:    "WHERE node_id IN " + subQuery + " AND node_id NOT IN " + subQuery,
+			args:     []interface{}{"rack", "sz-3", "isp", "ct"},
+		},
+	}
+
+	for _, tt := range tests {
+		where, args := labelSelectorCondition(tt.selector)
+		if where != tt.where {
+			t.Errorf("%s: expect %q, got %q", tt.name, tt.where, where)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: expect args %v, got %v", tt.name, tt.args, args)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// SaveCredentials inserts the credentials issued to users.
+func (n *SQLDB) SaveCredentials(infos []*types.Credentials) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, node_id, asset_cid, client_id, limit_rate, valid_time) VALUES (:id, :node_id, :asset_cid, :client_id, :limit_rate, :valid_time)`, credentialTable)
+	_, err := n.db.NamedExec(query, infos)
+
+	return err
+}
+
+// LoadCredentials load the credentials with the given id.
+func (n *SQLDB) LoadCredentials(id string) (*types.Credentials, error) {
+	var info types.Credentials
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id=?", credentialTable)
+	if err := n.db.Get(&info, query, id); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// DeleteExpiredCredentials removes the credentials which expired before the given time.
+func (n *SQLDB) DeleteExpiredCredentials(before time.Time) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE valid_time<?`, credentialTable)
+	_, err := n.db.Exec(query, before.Unix())
+
+	return err
+}
+
+// LoadCredentialTraffic load the bytes of the succeeded downloads reported by the node with the credentials.
+func (n *SQLDB) LoadCredentialTraffic(credentialID string) (int64, error) {
+	var size int64
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(block_size), 0) FROM %s WHERE credential_id=? AND status=?`, blockDownloadTable)
+	err := n.db.Get(&size, query, credentialID, types.DownloadStatusSucceeded)
+
+	return size, err
+}
+
+// SaveProofOfWork inserts a user proof of work, it returns false if the download interval of the proof
+// overlaps a proof already submitted with the same ticket.
+func (n *SQLDB) SaveProofOfWork(info *types.ProofOfWorkInfo) (bool, error) {
+	tx, err := n.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveProofOfWork Rollback err:%s", err.Error())
+		}
+	}()
+
+	// the proofs of the ticket are locked, so the concurrent submissions of the ticket are checked one by one
+	var overlaps int
+	cQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE ticket_id=? AND start_time<? AND end_time>? FOR UPDATE`, proofOfWorkTable)
+	if err := tx.Get(&overlaps, cQuery, info.TicketID, info.EndTime, info.StartTime); err != nil {
+		return false, err
+	}
+
+	if overlaps > 0 {
+		return false, nil
+	}
+
+	query := fmt.Sprintf(`INSERT IGNORE INTO %s (ticket_id, client_id, node_id, asset_cid, download_speed, download_size, start_time, end_time, status)
+		VALUES (:ticket_id, :client_id, :node_id, :asset_cid, :download_speed, :download_size, :start_time, :end_time, :status)`, proofOfWorkTable)
+	result, err := tx.NamedExec(query, info)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, tx.Commit()
+}
+
+// LoadProofsOfTicket load the proofs of work with the given ticket.
+func (n *SQLDB) LoadProofsOfTicket(ticketID string) ([]*types.ProofOfWorkInfo, error) {
+	var out []*types.ProofOfWorkInfo
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE ticket_id=? ORDER BY start_time ASC`, proofOfWorkTable)
+	if err := n.db.Select(&out, query, ticketID); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// UpdateProofOfWorkStatus updates the status of the proof of work with the given id.
+func (n *SQLDB) UpdateProofOfWorkStatus(id int64, status types.ProofOfWorkStatus) error {
+	query := fmt.Sprintf(`UPDATE %s SET status=? WHERE id=?`, proofOfWorkTable)
+	_, err := n.db.Exec(query, status, id)
+
+	return err
+}
+
+// LoadServedTraffic load the traffic served by nodes within the time range, the traffic is aggregated by node.
+func (n *SQLDB) LoadServedTraffic(nodeID string, startTime, endTime time.Time, cursor, count int) (*types.ListServedTrafficRsp, error) {
+	conditions := []string{"status=?", "created_time between ? and ?"}
+	args := []interface{}{types.ProofOfWorkStatusConfirmed, startTime, endTime}
+
+	if nodeID != "" {
+		conditions = append(conditions, "node_id=?")
+		args = append(args, nodeID)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT COUNT(DISTINCT node_id) FROM %s WHERE %s`, proofOfWorkTable, where)
+	if err := n.db.Get(&total, countSQL, args...); err != nil {
+		return nil, err
+	}
+
+	if count > loadServedTrafficLimit {
+		count = loadServedTrafficLimit
+	}
+
+	query := fmt.Sprintf(`SELECT node_id, COUNT(*) AS proof_count, SUM(download_size) AS served_size, CAST(AVG(download_speed) AS SIGNED) AS avg_speed
+		FROM %s WHERE %s GROUP BY node_id ORDER BY served_size DESC LIMIT ?,?`, proofOfWorkTable, where)
+
+	var out []*types.NodeServedTraffic
+	if err := n.db.Select(&out, query, append(args, cursor, count)...); err != nil {
+		return nil, err
+	}
+
+	if len(out) == 0 {
+		return &types.ListServedTrafficRsp{Data: out, Total: total}, nil
+	}
+
+	nodeIDs := make([]string, 0, len(out))
+	for _, traffic := range out {
+		nodeIDs = append(nodeIDs, traffic.NodeID)
+	}
+
+	sQuery := fmt.Sprintf(`SELECT node_id, COALESCE(SUM(block_size), 0) AS reported_size FROM %s
+		WHERE status=? AND created_time between ? and ? AND node_id in (?) GROUP BY node_id`, blockDownloadTable)
+	query, inArgs, err := sqlx.In(sQuery, types.DownloadStatusSucceeded, startTime, endTime, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var reported []*types.NodeServedTraffic
+	if err := n.db.Select(&reported, n.db.Rebind(query), inArgs...); err != nil {
+		return nil, err
+	}
+
+	reportedSizes := make(map[string]int64, len(reported))
+	for _, traffic := range reported {
+		reportedSizes[traffic.NodeID] = traffic.ReportedSize
+	}
+
+	for _, traffic := range out {
+		traffic.ReportedSize = reportedSizes[traffic.NodeID]
+	}
+
+	return &types.ListServedTrafficRsp{Data: out, Total: total}, nil
+}
@@ -27,12 +27,25 @@ const (
 	validatorsTable       = "validators"
 	nodeRegisterTable     = "node_register_info"
 	validationResultTable = "validation_result"
+	validationRoundTable  = "validation_round"
 	assetsViewTable       = "asset_view"
 	bucketTable           = "bucket"
+	blockDownloadTable    = "block_download_info"
+	credentialTable       = "credential_info"
+	proofOfWorkTable      = "proof_of_work"
+	electionRecordTable   = "election_record"
+	nodeLabelTable        = "node_label"
+	nodeBlacklistTable    = "node_blacklist"
+	nodeStatsTable        = "node_stats"
+	nodeNATHistoryTable   = "node_nat_history"
+	natPunchStatsTable    = "nat_punch_stats"
 
 	loadNodeInfosLimit           = 100
 	loadReplicaInfosLimit        = 100
 	loadValidationResultsLimit   = 100
 	loadAssetRecordsLimit        = 100
 	loadExpiredAssetRecordsLimit = 100
+	loadDownloadRecordsLimit     = 100
+	loadServedTrafficLimit       = 100
+	loadElectionRecordsLimit     = 100
 )
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+// SaveNodeStats adds the report to the stats of the node at the resolution, the reports in a time bucket of the resolution are averaged
+func (n *SQLDB) SaveNodeStats(stats *types.NodeStats, resolution time.Duration) error {
+	// samples is updated last, so the averages are computed with the previous count
+	query := fmt.Sprintf(`INSERT INTO %s (node_id, resolution, time, samples, cpu_usage, memory_usage, disk_usage, bandwidth_up, bandwidth_down)
+		VALUES (?, ?, ?, 1, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE cpu_usage=(cpu_usage*samples+VALUES(cpu_usage))/(samples+1), memory_usage=(memory_usage*samples+VALUES(memory_usage))/(samples+1),
+		disk_usage=(disk_usage*samples+VALUES(disk_usage))/(samples+1), bandwidth_up=(bandwidth_up*samples+VALUES(bandwidth_up))/(samples+1),
+		bandwidth_down=(bandwidth_down*samples+VALUES(bandwidth_down))/(samples+1), samples=samples+1`, nodeStatsTable)
+
+	_, err := n.db.Exec(query, stats.NodeID, int64(resolution.Seconds()), stats.Time.Truncate(resolution),
+		stats.CPUUsage, stats.MemoryUsage, stats.DiskUsage, stats.BandwidthUp, stats.BandwidthDown)
+	return err
+}
+
+// LoadNodeStats loads the stats of the node at the resolution in the time range, the stats are averaged in every step
+func (n *SQLDB) LoadNodeStats(nodeID string, resolution time.Duration, from, to time.Time, step time.Duration) ([]*types.NodeStats, error) {
+	seconds := int64(step.Seconds())
+	query := fmt.Sprintf(`SELECT node_id, FROM_UNIXTIME(slot*?) AS time, SUM(cpu_usage*samples)/SUM(samples) AS cpu_usage,
+		SUM(memory_usage*samples)/SUM(samples) AS memory_usage, SUM(disk_usage*samples)/SUM(samples) AS disk_usage,
+		SUM(bandwidth_up*samples)/SUM(samples) AS bandwidth_up, SUM(bandwidth_down*samples)/SUM(samples) AS bandwidth_down
+		FROM (SELECT node_id, FLOOR(UNIX_TIMESTAMP(time)/?) AS slot, samples, cpu_usage, memory_usage, disk_usage, bandwidth_up, bandwidth_down
+			FROM %s WHERE node_id=? AND resolution=? AND time>=? AND time<?) s
+		GROUP BY node_id, slot ORDER BY slot`, nodeStatsTable)
+
+	var out []*types.NodeStats
+	if err := n.db.Select(&out, query, seconds, seconds, nodeID, int64(resolution.Seconds()), from, to); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// DeleteNodeStatsBefore removes the stats at the resolution older than the time
+func (n *SQLDB) DeleteNodeStatsBefore(resolution time.Duration, t time.Time) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE resolution=? AND time<?`, nodeStatsTable)
+	_, err := n.db.Exec(query, int64(resolution.Seconds()), t)
+	return err
+}
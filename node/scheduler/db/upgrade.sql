@@ -0,0 +1,48 @@
+-- Upgrade the tables created by the earlier versions.
+-- The scheduler does not run this file, apply it once by hand before starting the upgraded scheduler, e.g.
+--   vtctldclient ApplySchema --sql-file upgrade.sql titan
+-- New tables are created from data.sql, every column or key added to an existing table goes here.
+
+-- Block download information table
+ALTER TABLE `block_download_info`
+    CHANGE COLUMN `carfile_cid` `asset_cid` VARCHAR(128) NOT NULL,
+    ADD COLUMN `credential_id` VARCHAR(128) DEFAULT '' AFTER `id`,
+    ADD COLUMN `duration` BIGINT DEFAULT 0 AFTER `speed`,
+    MODIFY COLUMN `block_size` BIGINT DEFAULT 0,
+    MODIFY COLUMN `speed` BIGINT DEFAULT 0,
+    MODIFY COLUMN `client_ip` VARCHAR(64) NOT NULL,
+    ADD KEY `idx_node_id` (`node_id`),
+    ADD KEY `idx_asset_cid` (`asset_cid`),
+    ADD KEY `idx_created_time` (`created_time`);
+
+-- Validation results table, the beacon round the seed of the validation is derived from
+ALTER TABLE `validation_result`
+    ADD COLUMN `beacon_round` BIGINT DEFAULT 0 AFTER `end_time`;
+
+-- Validation results table, the candidate which provided the reference blocks
+ALTER TABLE `validation_result`
+    ADD COLUMN `candidate_id` VARCHAR(128) DEFAULT '' AFTER `beacon_round`;
+
+-- Validation results table, bandwidth validation or storage proof
+ALTER TABLE `validation_result`
+    ADD COLUMN `validation_type` TINYINT DEFAULT 0 AFTER `candidate_id`;
+
+-- Node information table, the quits and the reputation of the node, the default reputation is node.DefaultReputation
+ALTER TABLE `node_info`
+    ADD COLUMN `quit_count` INT DEFAULT 0 AFTER `scheduler_sid`,
+    ADD COLUMN `reputation` FLOAT DEFAULT 62.5 AFTER `quit_count`;
+
+-- Validators information table, the time the validator is elected
+ALTER TABLE `validators`
+    ADD COLUMN `elected_time` DATETIME DEFAULT CURRENT_TIMESTAMP AFTER `scheduler_sid`;
+
+-- Node information table, the drain and maintenance state of the node
+ALTER TABLE `node_info`
+    ADD COLUMN `state` TINYINT DEFAULT 0 AFTER `reputation`;
+
+-- Asset record and edge update information tables, the label selector of the nodes
+ALTER TABLE `asset_record`
+    ADD COLUMN `node_selector` VARCHAR(256) DEFAULT '' AFTER `expiration`;
+
+ALTER TABLE `edge_update_info`
+    ADD COLUMN `node_selector` VARCHAR(256) DEFAULT '' AFTER `download_url`;
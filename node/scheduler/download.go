@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/handler"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// maxDownloadRecordsPerSubmit is the max number of download records submitted at once
+const maxDownloadRecordsPerSubmit = 1000
+
+// SubmitDownloadRecords saves the download records of the gateway responses served by the node
+func (s *Scheduler) SubmitDownloadRecords(ctx context.Context, records []*types.DownloadHistory) error {
+	nodeID := handler.GetNodeID(ctx)
+	if s.NodeManager.GetNode(nodeID) == nil {
+		return xerrors.Errorf("node %s not online", nodeID)
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	if len(records) > maxDownloadRecordsPerSubmit {
+		return xerrors.Errorf("too many download records %d, max %d", len(records), maxDownloadRecordsPerSubmit)
+	}
+
+	for _, record := range records {
+		if record.AssetCID == "" {
+			return xerrors.Errorf("download record %s asset cid is empty", record.ID)
+		}
+
+		// the ID generated by the node keeps the records resubmitted after a timeout from being saved twice
+		if record.ID == "" {
+			record.ID = uuid.NewString()
+		}
+
+		// the node can only submit the records of itself
+		record.NodeID = nodeID
+		if record.Duration > 0 {
+			record.Speed = int64(record.BlockSize) * int64(time.Second/time.Millisecond) / record.Duration
+		}
+	}
+
+	if err := s.NodeManager.SaveDownloadRecords(records); err != nil {
+		return err
+	}
+
+	// the reported traffic may confirm the user proofs of work submitted before
+	credentialIDs := make(map[string]struct{})
+	for _, record := range records {
+		if record.CredentialID == "" || record.Status != types.DownloadStatusSucceeded {
+			continue
+		}
+
+		if _, ok := credentialIDs[record.CredentialID]; ok {
+			continue
+		}
+		credentialIDs[record.CredentialID] = struct{}{}
+
+		if err := s.confirmProofsOfTicket(record.CredentialID); err != nil {
+			log.Errorf("confirm proofs of ticket %s err: %s", record.CredentialID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// GetDownloadRecords retrieves the download records filtered by node, asset and time range
+func (s *Scheduler) GetDownloadRecords(ctx context.Context, req types.ListDownloadRecordsReq) (*types.ListDownloadRecordRsp, error) {
+	startTime := time.Unix(req.StartTime, 0)
+	endTime := time.Unix(req.EndTime, 0)
+
+	return s.NodeManager.LoadDownloadRecords(req.NodeID, req.AssetCID, startTime, endTime, req.Cursor, req.Count)
+}
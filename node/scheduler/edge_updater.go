@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/Filecoin-Titan/titan/node/scheduler/db"
 
 	"github.com/Filecoin-Titan/titan/api"
@@ -34,8 +35,39 @@ func (eu *EdgeUpdateManager) GetEdgeUpdateConfigs(ctx context.Context) (map[int]
 	return eu.updateInfos, nil
 }
 
+// GetEdgeUpdateConfigsOfNode returns the map of edge node update information rolled out to the node by the label selectors.
+func (eu *EdgeUpdateManager) GetEdgeUpdateConfigsOfNode(ctx context.Context, nodeID string) (map[int]*api.EdgeUpdateConfig, error) {
+	labels := make(map[string]string)
+	if nodeID != "" {
+		var err error
+		labels, err = eu.db.LoadNodeLabels(nodeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(map[int]*api.EdgeUpdateConfig)
+	for nodeType, info := range eu.updateInfos {
+		selector, err := types.ParseLabelSelector(info.NodeSelector)
+		if err != nil {
+			log.Errorf("edge update config %d node selector err:%s", nodeType, err.Error())
+			continue
+		}
+
+		if selector.Matches(labels) {
+			out[nodeType] = info
+		}
+	}
+
+	return out, nil
+}
+
 // SetEdgeUpdateConfig sets the EdgeUpdateConfig for the given node type.
 func (eu *EdgeUpdateManager) SetEdgeUpdateConfig(ctx context.Context, info *api.EdgeUpdateConfig) error {
+	if _, err := types.ParseLabelSelector(info.NodeSelector); err != nil {
+		return err
+	}
+
 	if eu.updateInfos == nil {
 		eu.updateInfos = make(map[int]*api.EdgeUpdateConfig)
 	}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+	"golang.org/x/xerrors"
+)
+
+const eventBufferSize = 256 // Number of events buffered for a subscriber before the events are dropped
+
+// SubscribeEvents streams the events matching the filter until the context is done
+func (s *Scheduler) SubscribeEvents(ctx context.Context, filter types.EventFilter) (<-chan types.Event, error) {
+	topics := filter.Topics
+	if len(topics) == 0 {
+		topics = types.EventTopicsAll
+	}
+
+	for _, topic := range topics {
+		if !isEventTopic(topic) {
+			return nil, xerrors.Errorf("unknown event topic %s", topic)
+		}
+	}
+
+	out := make(chan types.Event, eventBufferSize)
+
+	// the node topics publish the node only, so every topic has its own subscription to know the topic of the message
+	var wg sync.WaitGroup
+	for _, topic := range topics {
+		sub := s.PubSub.Sub(topic.String())
+
+		wg.Add(1)
+		go func(topic types.EventTopics) {
+			defer wg.Done()
+			// Unsub drains the subscription, the publishers never block on it
+			defer s.PubSub.Unsub(sub)
+
+			for {
+				select {
+				case msg, ok := <-sub:
+					if !ok {
+						return
+					}
+
+					event := toEvent(topic, msg)
+					if event == nil || !filter.Matches(event) {
+						continue
+					}
+
+					select {
+					case out <- *event:
+					default:
+						log.Warnf("subscriber is too slow, drop event %s of node %s asset %s", event.Topic, event.NodeID, event.AssetCID)
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(topic)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+// toEvent converts the message published on the topic to an event
+func toEvent(topic types.EventTopics, msg interface{}) *types.Event {
+	switch m := msg.(type) {
+	case *types.Event:
+		return m
+	case *node.Node:
+		return &types.Event{
+			Topic:  topic,
+			Time:   time.Now(),
+			NodeID: m.NodeID,
+			State:  m.Type.String(),
+		}
+	}
+
+	return nil
+}
+
+func isEventTopic(topic types.EventTopics) bool {
+	for _, t := range types.EventTopicsAll {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -24,8 +24,10 @@ import (
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/Filecoin-Titan/titan/node/common"
 	"github.com/Filecoin-Titan/titan/node/handler"
+	"github.com/Filecoin-Titan/titan/node/scheduler/nat"
 	"github.com/Filecoin-Titan/titan/node/scheduler/node"
 	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/filecoin-project/pubsub"
 	logging "github.com/ipfs/go-log/v2"
 
 	titanrsa "github.com/Filecoin-Titan/titan/node/rsa"
@@ -47,9 +49,11 @@ type Scheduler struct {
 	ValidationMgr          *validation.Manager
 	AssetManager           *assets.Manager
 	DataSync               *sync.DataSync
+	NATManager             *nat.Manager
 	SchedulerCfg           *config.SchedulerCfg
 	SetSchedulerConfigFunc dtypes.SetSchedulerConfigFunc
 	GetSchedulerConfigFunc dtypes.GetSchedulerConfigFunc
+	PubSub                 *pubsub.PubSub
 
 	PrivateKey *rsa.PrivateKey
 }
@@ -84,6 +88,10 @@ func (s *Scheduler) NodeLogin(ctx context.Context, nodeID, sign string) (string,
 		NodeID: nodeID,
 	}
 
+	if err := s.NodeManager.CheckNodeBanned(nodeID); err != nil {
+		return "", err
+	}
+
 	remoteAddr := handler.GetRemoteAddr(ctx)
 	oldNode := s.NodeManager.GetNode(nodeID)
 	if oldNode != nil {
@@ -127,6 +135,10 @@ func (s *Scheduler) nodeConnect(ctx context.Context, opts *types.ConnectOptions,
 	remoteAddr := handler.GetRemoteAddr(ctx)
 	nodeID := handler.GetNodeID(ctx)
 
+	if err := s.NodeManager.CheckNodeBanned(nodeID); err != nil {
+		return err
+	}
+
 	alreadyConnect := true
 
 	cNode := s.NodeManager.GetNode(nodeID)
@@ -176,6 +188,29 @@ func (s *Scheduler) nodeConnect(ctx context.Context, opts *types.ConnectOptions,
 			return xerrors.Errorf("load node online duration %s err : %s", nodeID, err.Error())
 		}
 
+		reputation, err := s.NodeManager.LoadNodeReputation(nodeID)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				return xerrors.Errorf("load node reputation %s err : %s", nodeID, err.Error())
+			}
+			reputation = node.DefaultReputation
+		}
+
+		state, err := s.NodeManager.LoadNodeState(nodeID)
+		if err != nil && err != sql.ErrNoRows {
+			return xerrors.Errorf("load node state %s err : %s", nodeID, err.Error())
+		}
+
+		labels, err := s.NodeManager.LoadNodeLabels(nodeID)
+		if err != nil {
+			return xerrors.Errorf("load node labels %s err : %s", nodeID, err.Error())
+		}
+
+		quarantined, err := s.NodeManager.IsNodeQuarantined(nodeID)
+		if err != nil {
+			return xerrors.Errorf("load node quarantine %s err : %s", nodeID, err.Error())
+		}
+
 		publicKey, err := titanrsa.Pem2PublicKey([]byte(pStr))
 		if err != nil {
 			return xerrors.Errorf("load node port %s err : %s", nodeID, err.Error())
@@ -183,6 +218,10 @@ func (s *Scheduler) nodeConnect(ctx context.Context, opts *types.ConnectOptions,
 
 		// init node info
 		nodeInfo.OnlineDuration = onlineDuration
+		nodeInfo.Reputation = reputation
+		nodeInfo.State = state
+		nodeInfo.Labels = labels
+		nodeInfo.Quarantined = quarantined
 		nodeInfo.PortMapping = port
 		nodeInfo.ExternalIP, _, err = net.SplitHostPort(remoteAddr)
 		if err != nil {
@@ -191,10 +230,13 @@ func (s *Scheduler) nodeConnect(ctx context.Context, opts *types.ConnectOptions,
 
 		cNode.SetPublicKey(publicKey)
 		cNode.SetTCPPort(opts.TcpServerPort)
+		cNode.SetTCPTLS(opts.TcpServerTLS)
+		cNode.SetTCPCertHash(opts.TcpServerCertHash)
 		cNode.SetRemoteAddr(remoteAddr)
 
+		natType := types.NatTypeUnknow
 		if nodeType == types.NodeEdge {
-			natType := s.determineNATType(context.Background(), cNode.API, remoteAddr)
+			natType = s.NATManager.DetectNATType(context.Background(), cNode.API, remoteAddr)
 			nodeInfo.NATType = natType.String()
 		}
 
@@ -205,6 +247,10 @@ func (s *Scheduler) nodeConnect(ctx context.Context, opts *types.ConnectOptions,
 			log.Errorf("nodeConnect err:%s,nodeID:%s", err.Error(), nodeID)
 			return err
 		}
+
+		if nodeType == types.NodeEdge {
+			s.NATManager.RecordNATType(nodeID, nodeInfo.ExternalIP, natType)
+		}
 	}
 
 	s.DataSync.AddNodeToList(nodeID)
@@ -240,6 +286,16 @@ func (s *Scheduler) NodeValidationResult(ctx context.Context, result api.Validat
 	return s.ValidationMgr.HandleResult(vs)
 }
 
+// GetValidatableNodePublicKey returns the public key of the node which is validated by the caller in the round
+func (s *Scheduler) GetValidatableNodePublicKey(ctx context.Context, nodeID, roundID string) (string, error) {
+	validator := handler.GetNodeID(ctx)
+	if s.NodeManager.GetCandidateNode(validator) == nil {
+		return "", xerrors.Errorf("validator %s not online", validator)
+	}
+
+	return s.ValidationMgr.GetValidatableNodePublicKey(validator, nodeID, roundID)
+}
+
 // RegisterNode adds a new node to the scheduler with the specified node ID, public key, and node type
 func (s *Scheduler) RegisterNode(ctx context.Context, pKey string, nodeType types.NodeType) (nodeID string, err error) {
 	nodeID, err = s.NodeManager.NewNodeID(nodeType)
@@ -258,6 +314,61 @@ func (s *Scheduler) UnregisterNode(ctx context.Context, nodeID string) error {
 	return s.db.DeleteNodeInfo(nodeID)
 }
 
+// DrainNode stops assigning new replicas and validations to the node, and re-creates its replicas on the other nodes
+func (s *Scheduler) DrainNode(ctx context.Context, nodeID string) error {
+	return s.AssetManager.DrainNode(nodeID)
+}
+
+// GetNodeDrainStatus returns the drain progress of the node
+func (s *Scheduler) GetNodeDrainStatus(ctx context.Context, nodeID string) (*types.NodeDrainStatus, error) {
+	return s.AssetManager.GetNodeDrainStatus(nodeID)
+}
+
+// SetNodeMaintenance puts the node in or out of maintenance
+func (s *Scheduler) SetNodeMaintenance(ctx context.Context, nodeID string, enable bool) error {
+	return s.AssetManager.SetNodeMaintenance(nodeID, enable)
+}
+
+// BanNode bans the node until the expiration, the node is disconnected and can not login again
+func (s *Scheduler) BanNode(ctx context.Context, nodeID, reason string, expiration time.Time) error {
+	return s.NodeManager.BanNode(nodeID, reason, expiration)
+}
+
+// UnbanNode removes the ban of the node
+func (s *Scheduler) UnbanNode(ctx context.Context, nodeID string) error {
+	return s.NodeManager.UnbanNode(nodeID)
+}
+
+// QuarantineNode quarantines the node until the expiration
+func (s *Scheduler) QuarantineNode(ctx context.Context, nodeID, reason string, expiration time.Time) error {
+	return s.NodeManager.QuarantineNode(nodeID, reason, expiration)
+}
+
+// LiftNodeQuarantine removes the quarantine of the node
+func (s *Scheduler) LiftNodeQuarantine(ctx context.Context, nodeID string) error {
+	return s.NodeManager.LiftQuarantine(nodeID)
+}
+
+// ReportNodeStats saves the resource usage report of the caller
+func (s *Scheduler) ReportNodeStats(ctx context.Context, stats *types.NodeStats) error {
+	nodeID := handler.GetNodeID(ctx)
+	if stats == nil {
+		return xerrors.New("stats is nil")
+	}
+
+	return s.NodeManager.ReportNodeStats(nodeID, stats)
+}
+
+// GetNodeStatsHistory retrieves the resource usage history of the node in the time range averaged in every step
+func (s *Scheduler) GetNodeStatsHistory(ctx context.Context, nodeID string, from, to time.Time, step time.Duration) ([]*types.NodeStats, error) {
+	return s.NodeManager.GetNodeStatsHistory(nodeID, from, to, step)
+}
+
+// GetNodeBlacklist retrieves the unexpired bans and quarantines of the nodes
+func (s *Scheduler) GetNodeBlacklist(ctx context.Context) ([]*types.NodeBlacklistEntry, error) {
+	return s.NodeManager.LoadNodeBlacklistEntries()
+}
+
 // GetOnlineNodeCount returns the count of online nodes for a given node type
 func (s *Scheduler) GetOnlineNodeCount(ctx context.Context, nodeType types.NodeType) (int, error) {
 	if nodeType == types.NodeValidator {
@@ -282,8 +393,12 @@ func (s *Scheduler) GetOnlineNodeCount(ctx context.Context, nodeType types.NodeT
 
 // TriggerElection triggers a single election for validators.
 func (s *Scheduler) TriggerElection(ctx context.Context) error {
-	s.ValidationMgr.StartElection()
-	return nil
+	return s.ValidationMgr.StartElection()
+}
+
+// GetElectionRecords retrieves the audit records of the validator elections.
+func (s *Scheduler) GetElectionRecords(ctx context.Context, cursor int, count int) (*types.ListElectionRecordsRsp, error) {
+	return s.ValidationMgr.GetElectionRecords(cursor, count)
 }
 
 // GetNodeInfo returns information about the specified node.
@@ -293,7 +408,7 @@ func (s *Scheduler) GetNodeInfo(ctx context.Context, nodeID string) (types.NodeI
 
 	info := s.NodeManager.GetNode(nodeID)
 	if info != nil {
-		nodeInfo = *info.NodeInfo
+		nodeInfo = info.Info()
 		nodeInfo.IsOnline = true
 	} else {
 		dbInfo, err := s.NodeManager.LoadNodeInfo(nodeID)
@@ -303,6 +418,15 @@ func (s *Scheduler) GetNodeInfo(ctx context.Context, nodeID string) (types.NodeI
 		}
 
 		nodeInfo = *dbInfo
+		nodeInfo.Labels, err = s.NodeManager.LoadNodeLabels(nodeID)
+		if err != nil {
+			return types.NodeInfo{}, err
+		}
+
+		nodeInfo.Quarantined, err = s.NodeManager.IsNodeQuarantined(nodeID)
+		if err != nil {
+			return types.NodeInfo{}, err
+		}
 	}
 
 	return nodeInfo, nil
@@ -318,6 +442,21 @@ func (s *Scheduler) UpdateNodePort(ctx context.Context, nodeID, port string) err
 	return s.NodeManager.UpdatePortMapping(nodeID, port)
 }
 
+// SetNodeLabels adds or updates the labels of the node.
+func (s *Scheduler) SetNodeLabels(ctx context.Context, nodeID string, labels map[string]string) error {
+	return s.NodeManager.SetNodeLabels(nodeID, labels)
+}
+
+// RemoveNodeLabels removes the labels of the node with the specified keys.
+func (s *Scheduler) RemoveNodeLabels(ctx context.Context, nodeID string, keys []string) error {
+	return s.NodeManager.RemoveNodeLabels(nodeID, keys)
+}
+
+// GetNodeLabels retrieves the labels of the node.
+func (s *Scheduler) GetNodeLabels(ctx context.Context, nodeID string) (map[string]string, error) {
+	return s.NodeManager.LoadNodeLabels(nodeID)
+}
+
 // nodeExists checks if the node with the specified ID exists.
 func (s *Scheduler) nodeExists(nodeID string, nodeType types.NodeType) bool {
 	err := s.NodeManager.NodeExists(nodeID, nodeType)
@@ -343,9 +482,19 @@ func (s *Scheduler) NodeExists(ctx context.Context, nodeID string) error {
 
 // GetNodeList retrieves a list of nodes with pagination.
 func (s *Scheduler) GetNodeList(ctx context.Context, offset int, limit int) (*types.ListNodesRsp, error) {
+	return s.GetNodeListBySelector(ctx, offset, limit, "")
+}
+
+// GetNodeListBySelector retrieves a list of nodes matching the label selector with pagination.
+func (s *Scheduler) GetNodeListBySelector(ctx context.Context, offset int, limit int, selector string) (*types.ListNodesRsp, error) {
 	rsp := &types.ListNodesRsp{Data: make([]types.NodeInfo, 0)}
 
-	rows, total, err := s.NodeManager.LoadNodeInfos(limit, offset)
+	labelSelector, err := types.ParseLabelSelector(selector)
+	if err != nil {
+		return rsp, err
+	}
+
+	rows, total, err := s.NodeManager.LoadNodeInfos(limit, offset, labelSelector)
 	if err != nil {
 		return rsp, err
 	}
@@ -361,6 +510,7 @@ func (s *Scheduler) GetNodeList(ctx context.Context, offset int, limit int) (*ty
 	}
 
 	nodeInfos := make([]types.NodeInfo, 0)
+	nodeIDs := make([]string, 0)
 	for rows.Next() {
 		nodeInfo := &types.NodeInfo{}
 		err = rows.StructScan(nodeInfo)
@@ -375,6 +525,15 @@ func (s *Scheduler) GetNodeList(ctx context.Context, offset int, limit int) (*ty
 		}
 
 		nodeInfos = append(nodeInfos, *nodeInfo)
+		nodeIDs = append(nodeIDs, nodeInfo.NodeID)
+	}
+
+	labels, err := s.NodeManager.LoadLabelsOfNodes(nodeIDs)
+	if err != nil {
+		log.Errorf("get node labels: %v", err)
+	}
+	for i := range nodeInfos {
+		nodeInfos[i].Labels = labels[nodeInfos[i].NodeID]
 	}
 
 	rsp.Data = nodeInfos
@@ -383,6 +542,27 @@ func (s *Scheduler) GetNodeList(ctx context.Context, offset int, limit int) (*ty
 	return rsp, nil
 }
 
+// ValidateNode validates the node immediately.
+func (s *Scheduler) ValidateNode(ctx context.Context, nodeID string) (string, error) {
+	return s.ValidationMgr.ValidateNode(ctx, nodeID)
+}
+
+// ListValidationResults retrieves a list of validation results filtered by node, validator, round, status and time range.
+func (s *Scheduler) ListValidationResults(ctx context.Context, req types.ListValidationResultsReq) (*types.ListValidationResultRsp, error) {
+	return s.NodeManager.LoadValidationResults(req)
+}
+
+// GetValidationSummaries retrieves the validation statistics of the nodes in a time range.
+func (s *Scheduler) GetValidationSummaries(ctx context.Context, req types.ValidationSummaryReq) (*types.ListValidationSummaryRsp, error) {
+	startTime := time.Unix(req.StartTime, 0)
+	endTime := time.Now()
+	if req.EndTime > 0 {
+		endTime = time.Unix(req.EndTime, 0)
+	}
+
+	return s.NodeManager.LoadValidationSummaries(req.NodeID, startTime, endTime, req.Cursor, req.Count)
+}
+
 // GetValidationResults retrieves a list of validation results.
 func (s *Scheduler) GetValidationResults(ctx context.Context, startTime, endTime time.Time, pageNumber, pageSize int) (*types.ListValidationResultRsp, error) {
 	svm, err := s.NodeManager.LoadValidationResultInfos(startTime, endTime, pageNumber, pageSize)
@@ -393,6 +573,11 @@ func (s *Scheduler) GetValidationResults(ctx context.Context, startTime, endTime
 	return svm, nil
 }
 
+// GetValidationChallenge re-derives the seed of the validation from the randomness beacon, and the blocks the node is expected to send
+func (s *Scheduler) GetValidationChallenge(ctx context.Context, roundID, nodeID string) (*types.ValidationChallenge, error) {
+	return s.ValidationMgr.GetValidationChallenge(ctx, roundID, nodeID)
+}
+
 // GetSchedulerPublicKey get server publicKey
 func (s *Scheduler) GetSchedulerPublicKey(ctx context.Context) (string, error) {
 	if s.PrivateKey == nil {
@@ -434,7 +619,7 @@ func (s *Scheduler) GetCandidateDownloadInfos(ctx context.Context, cid string) (
 
 		nodeID := rInfo.NodeID
 		cNode := s.NodeManager.GetCandidateNode(nodeID)
-		if cNode == nil {
+		if cNode == nil || cNode.IsQuarantined() {
 			continue
 		}
 
@@ -453,7 +638,35 @@ func (s *Scheduler) GetCandidateDownloadInfos(ctx context.Context, cid string) (
 	return sources, nil
 }
 
-// GetAssetListForBucket retrieves a list of assets for the specified node's bucket.
-func (s *Scheduler) GetAssetListForBucket(ctx context.Context, nodeID string) ([]string, error) {
-	return nil, nil
+// GetAssetListForBucket retrieves a list of assets for the specified bucket of the caller's asset view.
+func (s *Scheduler) GetAssetListForBucket(ctx context.Context, bucketNumber uint32) ([]string, error) {
+	nodeID := handler.GetNodeID(ctx)
+
+	hashes, err := s.NodeManager.LoadBucket(fmt.Sprintf("%s:%d", nodeID, bucketNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	cids := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		c, err := cidutil.HashToCID(hash)
+		if err != nil {
+			return nil, err
+		}
+		cids = append(cids, c)
+	}
+
+	return cids, nil
+}
+
+// GetAssetRangeHashes retrieves the hashes of the sub-ranges of the range in the caller's asset view.
+func (s *Scheduler) GetAssetRangeHashes(ctx context.Context, r types.AssetRange) (map[uint32]string, error) {
+	nodeID := handler.GetNodeID(ctx)
+	return s.AssetManager.GetAssetRangeHashes(nodeID, r)
+}
+
+// GetAssetListForRange retrieves a list of assets in the range of the caller's asset view.
+func (s *Scheduler) GetAssetListForRange(ctx context.Context, r types.AssetRange) ([]string, error) {
+	nodeID := handler.GetNodeID(ctx)
+	return s.AssetManager.GetAssetListForRange(nodeID, r)
 }
@@ -4,18 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api/client"
 	"github.com/Filecoin-Titan/titan/api/types"
 	cliutil "github.com/Filecoin-Titan/titan/cli/util"
 	"github.com/Filecoin-Titan/titan/node/handler"
-	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+	"github.com/Filecoin-Titan/titan/node/scheduler/nat"
 	"golang.org/x/xerrors"
 )
 
+const maxNATHistoryLimit = 100 // Maximum number of the NAT type records returned at a time
+
 // GetEdgeExternalServiceAddress returns the external service address of an edge node
 func (s *Scheduler) GetEdgeExternalServiceAddress(ctx context.Context, nodeID, schedulerURL string) (string, error) {
 	eNode := s.NodeManager.GetEdgeNode(nodeID)
@@ -59,187 +59,69 @@ func (s *Scheduler) CheckEdgeConnectivity(ctx context.Context, url string) error
 // CheckNetworkConnectivity check tcp or udp network connectivity
 // network is "tcp" or "udp"
 func (s *Scheduler) CheckNetworkConnectivity(ctx context.Context, network, targetURL string) error {
-	switch network {
-	case "tcp":
-		return s.verifyTCPConnectivity(targetURL)
-	case "udp":
-		return s.verifyUDPConnectivity(targetURL)
-	}
-
-	return fmt.Errorf("unknow network %s type", network)
+	return nat.CheckNetworkConnectivity(network, targetURL)
 }
 
-// checks if an edge node is behind a Full Cone NAT
-func (s *Scheduler) detectFullConeNAT(ctx context.Context, schedulerURL, edgeURL string) (bool, error) {
-	schedulerAPI, close, err := client.NewScheduler(context.Background(), schedulerURL, nil)
-	if err != nil {
-		return false, err
-	}
-	defer close()
-
-	if err = schedulerAPI.CheckNetworkConnectivity(context.Background(), "udp", edgeURL); err == nil {
-		return true, nil
-	}
-
-	log.Debugf("check udp connectivity failed: %s", err.Error())
-
-	return false, nil
+// GetNodeNATType re-detects the node's NAT type
+func (s *Scheduler) GetNodeNATType(ctx context.Context, nodeID string) (types.NatType, error) {
+	return s.NATManager.ProbeNode(ctx, nodeID)
 }
 
-// checks if an edge node is behind a Restricted NAT
-func (s *Scheduler) detectRestrictedNAT(ctx context.Context, edgeURL string) (bool, error) {
-	udpPacketConn, err := net.ListenPacket("udp", ":0")
-	if err != nil {
-		return false, err
-	}
-	defer func() {
-		err = udpPacketConn.Close()
-		if err != nil {
-			log.Errorf("udpPacketConn Close err:%s", err.Error())
-		}
-	}()
-
-	httpClient := &http.Client{}
-	edgeAPI, close, err := client.NewEdgeWithHTTPClient(context.Background(), edgeURL, nil, httpClient)
-	if err != nil {
-		return false, err
-	}
-	defer close()
-
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	if _, err := edgeAPI.Version(ctx); err != nil {
-		log.Warnf("detectRestrictedNAT,edge %s PortRestrictedNAT", edgeURL)
-		return false, nil //nolint:nilerr
+// GetNodeNATHistory returns the NAT types detected for the node, the latest first
+func (s *Scheduler) GetNodeNATHistory(ctx context.Context, nodeID string, limit int) ([]*types.NodeNATRecord, error) {
+	if limit <= 0 || limit > maxNATHistoryLimit {
+		limit = maxNATHistoryLimit
 	}
 
-	return true, nil
+	return s.NodeManager.LoadNodeNATHistory(nodeID, limit)
 }
 
-// determines the NAT type of an edge node
-func (s *Scheduler) analyzeEdgeNodeNATType(ctx context.Context, edgeAPI *node.API, edgeAddr string) (types.NatType, error) {
-	if len(s.SchedulerCfg.SchedulerServer1) == 0 {
-		return types.NatTypeUnknow, nil
-	}
-
-	externalAddr, err := edgeAPI.ExternalServiceAddress(ctx, s.SchedulerCfg.SchedulerServer1)
-	if err != nil {
-		return types.NatTypeUnknow, err
-	}
-
-	if externalAddr != edgeAddr {
-		return types.NatTypeSymmetric, nil
-	}
-
-	if err = s.CheckNetworkConnectivity(ctx, "tcp", edgeAddr); err == nil {
-		return types.NatTypeNo, nil
-	}
-
-	log.Debugf("analyzeEdgeNodeNATType error: %s", err.Error())
-
-	if len(s.SchedulerCfg.SchedulerServer2) == 0 {
-		return types.NatTypeUnknow, nil
-	}
-
-	edgeURL := fmt.Sprintf("https://%s/rpc/v0", edgeAddr)
-	isBehindFullConeNAT, err := s.detectFullConeNAT(ctx, s.SchedulerCfg.SchedulerServer2, edgeURL)
-	if err != nil {
-		return types.NatTypeUnknow, err
-	}
-
-	if isBehindFullConeNAT {
-		return types.NatTypeFullCone, nil
-	}
-
-	isBehindRestrictedNAT, err := s.detectRestrictedNAT(ctx, edgeURL)
-	if err != nil {
-		return types.NatTypeUnknow, err
-	}
-
-	if isBehindRestrictedNAT {
-		return types.NatTypeRestricted, nil
-	}
-
-	return types.NatTypePortRestricted, nil
-}
-
-// identifies the NAT type of a node
-func (s *Scheduler) determineNATType(ctx context.Context, edgeAPI *node.API, edgeAddr string) types.NatType {
-	natType, err := s.analyzeEdgeNodeNATType(context.Background(), edgeAPI, edgeAddr)
-	if err != nil {
-		log.Errorf("determineNATType, error:%s", err.Error())
-		natType = types.NatTypeUnknow
-	}
-	return natType
-}
+// NatPunch performs NAT traversal
+func (s *Scheduler) NatPunch(ctx context.Context, target *types.NatPunchReq) error {
+	remoteAddr := handler.GetRemoteAddr(ctx)
+	sourceURL := fmt.Sprintf("https://%s/ping", remoteAddr)
 
-// GetNodeNATType gets the node's NAT type
-func (s *Scheduler) GetNodeNATType(ctx context.Context, nodeID string) (types.NatType, error) {
-	eNode := s.NodeManager.GetEdgeNode(nodeID)
+	eNode := s.NodeManager.GetEdgeNode(target.NodeID)
 	if eNode == nil {
-		return types.NatTypeUnknow, fmt.Errorf("node %s offline or not exist", nodeID)
+		return xerrors.Errorf("edge %s not exist", target.NodeID)
 	}
 
-	return s.determineNATType(ctx, eNode.API, eNode.RemoteAddr()), nil
+	return eNode.UserNATPunch(context.Background(), sourceURL, target)
 }
 
-func (s *Scheduler) verifyTCPConnectivity(targetURL string) error {
-	url, err := url.ParseRequestURI(targetURL)
-	if err != nil {
-		return xerrors.Errorf("parse uri error: %w, url: %s", err, targetURL)
-	}
+// StartNatPunch exchanges the addresses of the user and the edge node, and starts a hole punch between them,
+// the user sends the punch packets to the edge at the start time of the returned session
+func (s *Scheduler) StartNatPunch(ctx context.Context, target *types.NatPunchReq) (*types.NatPunchSession, error) {
+	remoteAddr := handler.GetRemoteAddr(ctx)
 
-	tcpAddr, err := net.ResolveTCPAddr("tcp", url.Host)
-	if err != nil {
-		return xerrors.Errorf("resolve tcp addr %w, host %s", err, url.Host)
+	// the remote address of the request is not the mapping of the UDP socket the user punches from
+	userAddr := target.UserAddr
+	if userAddr == "" {
+		return nil, xerrors.New("user address is required, it is the UDP address of the user observed by the NAT probe service")
 	}
 
-	conn, err := net.DialTCP("tcp", nil, tcpAddr)
+	// the edge only punches to the address of the caller, so it can not be used to flood the others
+	userHost, _, err := net.SplitHostPort(userAddr)
 	if err != nil {
-		return xerrors.Errorf("dial tcp %w, addr %s", err, tcpAddr)
+		return nil, xerrors.Errorf("user address %s: %w", userAddr, err)
 	}
-	defer conn.Close()
-
-	return nil
-}
-
-func (s *Scheduler) verifyUDPConnectivity(targetURL string) error {
-	udpPacketConn, err := net.ListenPacket("udp", ":0")
+	remoteHost, _, err := net.SplitHostPort(remoteAddr)
 	if err != nil {
-		return xerrors.Errorf("list udp %w, url %s", err, targetURL)
+		return nil, xerrors.Errorf("remote address %s: %w", remoteAddr, err)
 	}
-	defer func() {
-		err = udpPacketConn.Close()
-		if err != nil {
-			log.Errorf("udpPacketConn Close err:%s", err.Error())
-		}
-	}()
-
-	httpClient, err := cliutil.NewHTTP3Client(udpPacketConn, true, "")
-	if err != nil {
-		return xerrors.Errorf("new http3 client %w", err)
-	}
-	httpClient.Timeout = 5 * time.Second
-
-	resp, err := httpClient.Get(targetURL)
-	if err != nil {
-		return xerrors.Errorf("http3 client get error: %w, url: %s", err, targetURL)
+	if !net.ParseIP(userHost).Equal(net.ParseIP(remoteHost)) {
+		return nil, xerrors.Errorf("user address %s mismatch the remote address %s", userAddr, remoteAddr)
 	}
-	defer resp.Body.Close()
 
-	return nil
+	return s.NATManager.StartPunch(ctx, target.NodeID, userAddr, time.Duration(target.Timeout)*time.Second)
 }
 
-// NatPunch performs NAT traversal
-func (s *Scheduler) NatPunch(ctx context.Context, target *types.NatPunchReq) error {
-	remoteAddr := handler.GetRemoteAddr(ctx)
-	sourceURL := fmt.Sprintf("https://%s/ping", remoteAddr)
-
-	eNode := s.NodeManager.GetEdgeNode(target.NodeID)
-	if eNode == nil {
-		return xerrors.Errorf("edge %s not exist", target.NodeID)
-	}
+// GetNatPunchResult waits until the hole punch of the session ends, and returns its result
+func (s *Scheduler) GetNatPunchResult(ctx context.Context, sessionID string) (*types.NatPunchResult, error) {
+	return s.NATManager.GetPunchResult(ctx, sessionID)
+}
 
-	return eNode.UserNATPunch(context.Background(), sourceURL, target)
+// GetNatPunchStats returns the hole punch statistics of the NAT types of the edges
+func (s *Scheduler) GetNatPunchStats(ctx context.Context) ([]*types.NatPunchStats, error) {
+	return s.NATManager.GetPunchStats(), nil
 }
@@ -0,0 +1,171 @@
+package nat
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/client"
+	"github.com/Filecoin-Titan/titan/api/types"
+	cliutil "github.com/Filecoin-Titan/titan/cli/util"
+	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+	"golang.org/x/xerrors"
+)
+
+// CheckNetworkConnectivity check tcp or udp network connectivity
+// network is "tcp" or "udp"
+func CheckNetworkConnectivity(network, targetURL string) error {
+	switch network {
+	case "tcp":
+		return verifyTCPConnectivity(targetURL)
+	case "udp":
+		return verifyUDPConnectivity(targetURL)
+	}
+
+	return fmt.Errorf("unknow network %s type", network)
+}
+
+// checks if an edge node is behind a Full Cone NAT
+func detectFullConeNAT(ctx context.Context, schedulerURL, edgeURL string) (bool, error) {
+	schedulerAPI, close, err := client.NewScheduler(context.Background(), schedulerURL, nil)
+	if err != nil {
+		return false, err
+	}
+	defer close()
+
+	if err = schedulerAPI.CheckNetworkConnectivity(context.Background(), "udp", edgeURL); err == nil {
+		return true, nil
+	}
+
+	log.Debugf("check udp connectivity failed: %s", err.Error())
+
+	return false, nil
+}
+
+// checks if an edge node is behind a Restricted NAT
+func detectRestrictedNAT(ctx context.Context, edgeURL string) (bool, error) {
+	udpPacketConn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		err = udpPacketConn.Close()
+		if err != nil {
+			log.Errorf("udpPacketConn Close err:%s", err.Error())
+		}
+	}()
+
+	httpClient := &http.Client{}
+	edgeAPI, close, err := client.NewEdgeWithHTTPClient(context.Background(), edgeURL, nil, httpClient)
+	if err != nil {
+		return false, err
+	}
+	defer close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := edgeAPI.Version(ctx); err != nil {
+		log.Warnf("detectRestrictedNAT,edge %s PortRestrictedNAT", edgeURL)
+		return false, nil //nolint:nilerr
+	}
+
+	return true, nil
+}
+
+// determines the NAT type of an edge node with the other scheduler servers
+func (m *Manager) analyzeEdgeNodeNATType(ctx context.Context, edgeAPI *node.API, edgeAddr string) (types.NatType, error) {
+	if len(m.cfg.SchedulerServer1) == 0 {
+		return types.NatTypeUnknow, nil
+	}
+
+	externalAddr, err := edgeAPI.ExternalServiceAddress(ctx, m.cfg.SchedulerServer1)
+	if err != nil {
+		return types.NatTypeUnknow, err
+	}
+
+	if externalAddr != edgeAddr {
+		return types.NatTypeSymmetric, nil
+	}
+
+	if err = CheckNetworkConnectivity("tcp", edgeAddr); err == nil {
+		return types.NatTypeNo, nil
+	}
+
+	log.Debugf("analyzeEdgeNodeNATType error: %s", err.Error())
+
+	if len(m.cfg.SchedulerServer2) == 0 {
+		return types.NatTypeUnknow, nil
+	}
+
+	edgeURL := fmt.Sprintf("https://%s/rpc/v0", edgeAddr)
+	isBehindFullConeNAT, err := detectFullConeNAT(ctx, m.cfg.SchedulerServer2, edgeURL)
+	if err != nil {
+		return types.NatTypeUnknow, err
+	}
+
+	if isBehindFullConeNAT {
+		return types.NatTypeFullCone, nil
+	}
+
+	isBehindRestrictedNAT, err := detectRestrictedNAT(ctx, edgeURL)
+	if err != nil {
+		return types.NatTypeUnknow, err
+	}
+
+	if isBehindRestrictedNAT {
+		return types.NatTypeRestricted, nil
+	}
+
+	return types.NatTypePortRestricted, nil
+}
+
+func verifyTCPConnectivity(targetURL string) error {
+	url, err := url.ParseRequestURI(targetURL)
+	if err != nil {
+		return xerrors.Errorf("parse uri error: %w, url: %s", err, targetURL)
+	}
+
+	tcpAddr, err := net.ResolveTCPAddr("tcp", url.Host)
+	if err != nil {
+		return xerrors.Errorf("resolve tcp addr %w, host %s", err, url.Host)
+	}
+
+	conn, err := net.DialTCP("tcp", nil, tcpAddr)
+	if err != nil {
+		return xerrors.Errorf("dial tcp %w, addr %s", err, tcpAddr)
+	}
+	defer conn.Close()
+
+	return nil
+}
+
+func verifyUDPConnectivity(targetURL string) error {
+	udpPacketConn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return xerrors.Errorf("list udp %w, url %s", err, targetURL)
+	}
+	defer func() {
+		err = udpPacketConn.Close()
+		if err != nil {
+			log.Errorf("udpPacketConn Close err:%s", err.Error())
+		}
+	}()
+
+	httpClient, err := cliutil.NewHTTP3Client(udpPacketConn, true, "")
+	if err != nil {
+		return xerrors.Errorf("new http3 client %w", err)
+	}
+	httpClient.Timeout = 5 * time.Second
+
+	resp, err := httpClient.Get(targetURL)
+	if err != nil {
+		return xerrors.Errorf("http3 client get error: %w, url: %s", err, targetURL)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package nat
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/lib/natprobe"
+	"github.com/Filecoin-Titan/titan/node/config"
+	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
+	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("nat")
+
+const (
+	reprobeCheckInterval   = time.Minute         // Interval to check the edge nodes which are due to re-detect the NAT type
+	defaultReprobeInterval = time.Hour           // Interval of the NAT type re-detection if it is not configured
+	reprobeConcurrency     = 10                  // Maximum number of the nodes which are re-detected at the same time
+	probeTimeout           = 30 * time.Second    // Timeout of the NAT probe of a node
+	historyCleanInterval   = 24 * time.Hour      // Interval to remove the old NAT type history
+	historyKeepDuration    = 30 * 24 * time.Hour // The NAT type history is kept for this duration
+)
+
+// detection is the last NAT type detection of an online node
+type detection struct {
+	ip   string // The external ip of the node, the port of its connection changes on every reconnect
+	time time.Time
+}
+
+// punchStatsStore keeps the hole punch statistics across the restarts of the scheduler
+type punchStatsStore interface {
+	AddNATPunchResult(natType string, success bool) error
+	LoadNATPunchStats() ([]*types.NatPunchStats, error)
+}
+
+// Manager detects the NAT type of the edge nodes, and re-detects it periodically or when the address of the node changes,
+// it also coordinates the hole punches between the users and the edges
+type Manager struct {
+	nodeMgr *node.Manager
+	cfg     *config.SchedulerCfg
+	config  dtypes.GetSchedulerConfigFunc
+	server  *natprobe.Server // nil if the NAT probe service is not configured
+
+	lock       sync.Mutex
+	detections map[string]*detection
+
+	punchLock  sync.Mutex
+	punches    map[string]*punch
+	punchStats map[string]*types.NatPunchStats // the key is the NAT type of the edge
+	punchStore punchStatsStore
+
+	close chan struct{}
+}
+
+// NewManager returns a new NAT manager, the NAT probe service is started if its addresses are configured
+func NewManager(nodeMgr *node.Manager, cfg *config.SchedulerCfg, configFunc dtypes.GetSchedulerConfigFunc) (*Manager, error) {
+	m := &Manager{
+		nodeMgr:    nodeMgr,
+		cfg:        cfg,
+		config:     configFunc,
+		detections: make(map[string]*detection),
+		punches:    make(map[string]*punch),
+		punchStats: make(map[string]*types.NatPunchStats),
+		punchStore: nodeMgr,
+		close:      make(chan struct{}),
+	}
+
+	if cfg.NATProbePrimaryAddress != "" {
+		if cfg.NATProbeAlternateAddress == "" {
+			return nil, xerrors.New("NATProbeAlternateAddress is required by the NAT probe service")
+		}
+
+		server, err := natprobe.NewServer(cfg.NATProbePrimaryAddress, cfg.NATProbeAlternateAddress)
+		if err != nil {
+			return nil, xerrors.Errorf("start NAT probe service: %w", err)
+		}
+		m.server = server
+
+		log.Infof("NAT probe service listens on %s and %s", cfg.NATProbePrimaryAddress, cfg.NATProbeAlternateAddress)
+	}
+
+	return m, nil
+}
+
+// Start re-detects the NAT type of the online edge nodes
+func (m *Manager) Start(ctx context.Context) {
+	m.loadPunchStats()
+
+	ticker := time.NewTicker(reprobeCheckInterval)
+	defer ticker.Stop()
+
+	cleanTicker := time.NewTicker(historyCleanInterval)
+	defer cleanTicker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.reprobeNodes(ctx)
+		case <-cleanTicker.C:
+			if err := m.nodeMgr.DeleteNodeNATHistoryBefore(time.Now().Add(-historyKeepDuration)); err != nil {
+				log.Errorf("DeleteNodeNATHistoryBefore err:%s", err.Error())
+			}
+		case <-m.close:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the re-detection and the NAT probe service
+func (m *Manager) Stop(ctx context.Context) error {
+	close(m.close)
+
+	if m.server != nil {
+		return m.server.Close()
+	}
+
+	return nil
+}
+
+// DetectNATType determines the NAT type of the edge node, with the NAT probe service if it is configured and supported by the node,
+// otherwise with the other scheduler servers
+func (m *Manager) DetectNATType(ctx context.Context, edgeAPI *node.API, edgeAddr string) types.NatType {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	if m.server != nil && edgeAPI.ProbeNATType != nil {
+		natType, err := edgeAPI.ProbeNATType(ctx, m.cfg.NATProbePrimaryAddress)
+		if err == nil {
+			return natType
+		}
+
+		log.Warnf("probe nat type of %s err:%s, detect it with the scheduler servers", edgeAddr, err.Error())
+	}
+
+	natType, err := m.analyzeEdgeNodeNATType(ctx, edgeAPI, edgeAddr)
+	if err != nil {
+		log.Errorf("determineNATType, error:%s", err.Error())
+		natType = types.NatTypeUnknow
+	}
+	return natType
+}
+
+// RecordNATType saves the NAT type detected for the node at the external ip, the history is recorded if it changes
+func (m *Manager) RecordNATType(nodeID, externalIP string, natType types.NatType) {
+	m.lock.Lock()
+	m.detections[nodeID] = &detection{ip: externalIP, time: time.Now()}
+	m.lock.Unlock()
+
+	changed, err := m.nodeMgr.SaveNodeNATType(&types.NodeNATRecord{NodeID: nodeID, NATType: natType.String(), ExternalAddr: externalIP})
+	if err != nil {
+		log.Errorf("SaveNodeNATType %s err:%s", nodeID, err.Error())
+		return
+	}
+
+	if changed {
+		log.Infof("node event, node %s nat type %s at %s", nodeID, natType.String(), externalIP)
+	}
+}
+
+// ProbeNode re-detects the NAT type of the online edge node and records it
+func (m *Manager) ProbeNode(ctx context.Context, nodeID string) (types.NatType, error) {
+	eNode := m.nodeMgr.GetEdgeNode(nodeID)
+	if eNode == nil {
+		return types.NatTypeUnknow, xerrors.Errorf("node %s offline or not exist", nodeID)
+	}
+
+	natType := m.DetectNATType(ctx, eNode.API, eNode.RemoteAddr())
+
+	eNode.SetNATType(natType.String())
+	m.RecordNATType(nodeID, eNode.ExternalIP, natType)
+
+	return natType, nil
+}
+
+// reprobeNodes re-detects the NAT type of the online edge nodes which are due or whose address changes
+func (m *Manager) reprobeNodes(ctx context.Context) {
+	interval := m.getReprobeInterval()
+	now := time.Now()
+
+	nodeIDs := m.nodeMgr.GetAllEdgeNodes()
+	online := make(map[string]struct{}, len(nodeIDs))
+	due := make([]string, 0)
+
+	m.lock.Lock()
+	for _, nodeID := range nodeIDs {
+		online[nodeID] = struct{}{}
+
+		eNode := m.nodeMgr.GetEdgeNode(nodeID)
+		if eNode == nil {
+			continue
+		}
+
+		d, ok := m.detections[nodeID]
+		if !ok || d.ip != eNode.ExternalIP || now.Sub(d.time) >= interval {
+			due = append(due, nodeID)
+		}
+	}
+
+	// the offline nodes are detected again when they connect
+	for nodeID := range m.detections {
+		if _, ok := online[nodeID]; !ok {
+			delete(m.detections, nodeID)
+		}
+	}
+	m.lock.Unlock()
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, reprobeConcurrency)
+
+	for _, nodeID := range due {
+		limit <- struct{}{}
+		wg.Add(1)
+
+		go func(nodeID string) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+
+			if _, err := m.ProbeNode(ctx, nodeID); err != nil {
+				log.Debugf("reprobe nat type of %s err:%s", nodeID, err.Error())
+			}
+		}(nodeID)
+	}
+	wg.Wait()
+}
+
+// getReprobeInterval returns the interval of the NAT type re-detection
+func (m *Manager) getReprobeInterval() time.Duration {
+	cfg, err := m.config()
+	if err != nil || cfg.NATReprobeInterval <= 0 {
+		return defaultReprobeInterval
+	}
+
+	return time.Duration(cfg.NATReprobeInterval)
+}
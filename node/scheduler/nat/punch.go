@@ -0,0 +1,229 @@
+package nat
+
+import (
+	"context"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	punchStartDelay       = time.Second            // Both parties start to send after the delay, so the user receives the session in time
+	punchInterval         = 200 * time.Millisecond // Interval of the punch packets
+	defaultPunchTimeout   = 10 * time.Second       // Duration of the hole punch if the user does not specify it
+	maxPunchTimeout       = 60 * time.Second       // Maximum duration of the hole punch
+	punchResultKeepTime   = 5 * time.Minute        // The result is kept for the user to query after the hole punch ends
+	punchRPCTimeoutMargin = 10 * time.Second       // The edge has the margin to report the result after the hole punch ends
+	punchAddrTimeout      = 10 * time.Second       // Timeout of the edge to report the mapped address of its service socket
+)
+
+// punch is a hole punch in progress or ended recently
+type punch struct {
+	session *types.NatPunchSession
+	result  *types.NatPunchResult
+	done    chan struct{}
+}
+
+// StartPunch exchanges the observed addresses of the user and the edge, and instructs the edge to punch a hole to the user,
+// the user sends the punch packets to the edge at the start time of the returned session as well
+func (m *Manager) StartPunch(ctx context.Context, nodeID, userAddr string, timeout time.Duration) (*types.NatPunchSession, error) {
+	eNode := m.nodeMgr.GetEdgeNode(nodeID)
+	if eNode == nil {
+		return nil, xerrors.Errorf("edge %s not exist", nodeID)
+	}
+
+	return m.startPunch(ctx, eNode, userAddr, timeout)
+}
+
+// startPunch starts a hole punch between the user and the edge node
+func (m *Manager) startPunch(ctx context.Context, eNode *node.Node, userAddr string, timeout time.Duration) (*types.NatPunchSession, error) {
+	if _, err := net.ResolveUDPAddr("udp", userAddr); err != nil {
+		return nil, xerrors.Errorf("user address %s: %w", userAddr, err)
+	}
+
+	edgeAddr, err := m.edgeServiceAddress(ctx, eNode)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = defaultPunchTimeout
+	}
+	if timeout > maxPunchTimeout {
+		timeout = maxPunchTimeout
+	}
+
+	session := &types.NatPunchSession{
+		ID:          uuid.NewString(),
+		UserAddr:    userAddr,
+		EdgeAddr:    edgeAddr,
+		EdgeNATType: eNode.GetNATType(),
+		StartTime:   time.Now().Add(punchStartDelay),
+		Interval:    punchInterval,
+		Attempts:    int(timeout / punchInterval),
+	}
+
+	p := &punch{
+		session: session,
+		result:  &types.NatPunchResult{SessionID: session.ID, Status: types.NatPunchPending},
+		done:    make(chan struct{}),
+	}
+
+	m.punchLock.Lock()
+	m.punches[session.ID] = p
+	m.punchLock.Unlock()
+
+	go m.runPunch(eNode, p, timeout)
+
+	return session, nil
+}
+
+// edgeServiceAddress returns the address the NAT of the edge maps its UDP service socket to, the hole is punched from that socket.
+// The edge reports it with a request sent to the scheduler from the socket, the remote address of the node is not the mapping of the socket
+func (m *Manager) edgeServiceAddress(ctx context.Context, eNode *node.Node) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, punchAddrTimeout)
+	defer cancel()
+
+	addr, err := eNode.ExternalServiceAddress(ctx, m.cfg.ExternalURL)
+	if err != nil {
+		return "", xerrors.Errorf("service address of edge %s: %w", eNode.NodeID, err)
+	}
+
+	if _, err := net.ResolveUDPAddr("udp", addr); err != nil {
+		return "", xerrors.Errorf("service address %s of edge %s: %w", addr, eNode.NodeID, err)
+	}
+
+	return addr, nil
+}
+
+// runPunch waits for the result of the edge, and counts it to the statistics of the NAT type of the edge
+func (m *Manager) runPunch(eNode *node.Node, p *punch, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), punchStartDelay+timeout+punchRPCTimeoutMargin)
+	defer cancel()
+
+	result, err := eNode.EdgeNATPunch(ctx, p.session)
+	if err != nil {
+		result = &types.NatPunchResult{Status: types.NatPunchFailed, Error: err.Error()}
+	} else if result == nil || result.Status != types.NatPunchSucceeded {
+		result = &types.NatPunchResult{Status: types.NatPunchFailed, Error: "the edge can not reach the user"}
+	}
+	result.SessionID = p.session.ID
+
+	m.punchLock.Lock()
+	p.result = result
+	m.punchLock.Unlock()
+	close(p.done)
+
+	m.addPunchResult(p.session.EdgeNATType, result.Status == types.NatPunchSucceeded)
+
+	log.Debugf("punch %s from edge %s to user %s: %s %s", p.session.ID, p.session.EdgeAddr, p.session.UserAddr, result.Status.String(), result.Error)
+
+	time.AfterFunc(punchResultKeepTime, func() {
+		m.punchLock.Lock()
+		delete(m.punches, p.session.ID)
+		m.punchLock.Unlock()
+	})
+}
+
+// GetPunchResult waits until the hole punch ends or the context is done, and returns its result
+func (m *Manager) GetPunchResult(ctx context.Context, sessionID string) (*types.NatPunchResult, error) {
+	m.punchLock.Lock()
+	p, ok := m.punches[sessionID]
+	m.punchLock.Unlock()
+
+	if !ok {
+		return nil, xerrors.Errorf("punch session %s not exist", sessionID)
+	}
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+	}
+
+	m.punchLock.Lock()
+	defer m.punchLock.Unlock()
+
+	result := *p.result
+	return &result, nil
+}
+
+// GetPunchStats returns the hole punch statistics of the NAT types
+func (m *Manager) GetPunchStats() []*types.NatPunchStats {
+	m.punchLock.Lock()
+	defer m.punchLock.Unlock()
+
+	out := make([]*types.NatPunchStats, 0, len(m.punchStats))
+	for _, stats := range m.punchStats {
+		s := *stats
+		out = append(out, &s)
+	}
+
+	return out
+}
+
+// PunchSuccessRate estimates the success rate of the hole punch to an edge with the NAT type,
+// the edges without NAT are reachable directly
+func (m *Manager) PunchSuccessRate(natType string) float64 {
+	if natType == types.NatTypeNo.String() {
+		return 1
+	}
+
+	m.punchLock.Lock()
+	defer m.punchLock.Unlock()
+
+	// the rate of the NAT type with few attempts is close to 0.5
+	stats, ok := m.punchStats[natType]
+	if !ok {
+		return 0.5
+	}
+
+	return float64(stats.Successes+1) / float64(stats.Attempts+2)
+}
+
+// SortByPunchSuccessRate sorts the edges by the success rate of the hole punch to their NAT types, the highest first,
+// the order of the edges with the same rate is kept
+func (m *Manager) SortByPunchSuccessRate(infos []*types.EdgeDownloadInfo) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		return m.PunchSuccessRate(infos[i].NatType) > m.PunchSuccessRate(infos[j].NatType)
+	})
+}
+
+// addPunchResult counts the hole punch to the statistics of the NAT type
+func (m *Manager) addPunchResult(natType string, success bool) {
+	m.punchLock.Lock()
+	stats, ok := m.punchStats[natType]
+	if !ok {
+		stats = &types.NatPunchStats{NATType: natType}
+		m.punchStats[natType] = stats
+	}
+	stats.Attempts++
+	if success {
+		stats.Successes++
+	}
+	m.punchLock.Unlock()
+
+	if err := m.punchStore.AddNATPunchResult(natType, success); err != nil {
+		log.Errorf("AddNATPunchResult err:%s", err.Error())
+	}
+}
+
+// loadPunchStats loads the hole punch statistics saved before the scheduler restarts
+func (m *Manager) loadPunchStats() {
+	list, err := m.punchStore.LoadNATPunchStats()
+	if err != nil {
+		log.Errorf("LoadNATPunchStats err:%s", err.Error())
+		return
+	}
+
+	m.punchLock.Lock()
+	defer m.punchLock.Unlock()
+
+	for _, stats := range list {
+		m.punchStats[stats.NATType] = stats
+	}
+}
@@ -0,0 +1,270 @@
+package nat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/config"
+	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+)
+
+const testEdgeAddr = "203.0.113.1:1234"
+
+// fakePunchStore keeps the hole punch results in memory
+type fakePunchStore struct {
+	lock    sync.Mutex
+	results map[string][]bool
+}
+
+func (s *fakePunchStore) AddNATPunchResult(natType string, success bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.results[natType] = append(s.results[natType], success)
+	return nil
+}
+
+func (s *fakePunchStore) LoadNATPunchStats() ([]*types.NatPunchStats, error) {
+	return nil, nil
+}
+
+func newTestManager() (*Manager, *fakePunchStore) {
+	store := &fakePunchStore{results: make(map[string][]bool)}
+	m := &Manager{
+		cfg:        &config.SchedulerCfg{},
+		punches:    make(map[string]*punch),
+		punchStats: make(map[string]*types.NatPunchStats),
+		punchStore: store,
+	}
+
+	return m, store
+}
+
+type edgePunchFunc func(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error)
+
+func newTestEdge(natType string, punch edgePunchFunc) *node.Node {
+	n := node.New()
+	n.NodeInfo = &types.NodeInfo{NodeID: "e_test", NATType: natType}
+	n.API = &node.API{
+		ExternalServiceAddress: func(ctx context.Context, schedulerURL string) (string, error) {
+			return testEdgeAddr, nil
+		},
+		EdgeNATPunch: punch,
+	}
+
+	return n
+}
+
+func succeed(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error) {
+	return &types.NatPunchResult{SessionID: session.ID, Status: types.NatPunchSucceeded, PunchedAddr: session.EdgeAddr}, nil
+}
+
+func TestStartPunch(t *testing.T) {
+	m, _ := newTestManager()
+	eNode := newTestEdge(types.NatTypeRestricted.String(), succeed)
+
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		attempts int
+	}{
+		{"default timeout", 0, int(defaultPunchTimeout / punchInterval)},
+		{"timeout", 2 * time.Second, 10},
+		{"timeout is capped", 2 * maxPunchTimeout, int(maxPunchTimeout / punchInterval)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := m.startPunch(context.Background(), eNode, "198.51.100.1:5678", tt.timeout)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if session.EdgeAddr != testEdgeAddr {
+				t.Errorf("expect the mapped address of the service socket %s, got %s", testEdgeAddr, session.EdgeAddr)
+			}
+			if session.UserAddr != "198.51.100.1:5678" || session.EdgeNATType != types.NatTypeRestricted.String() {
+				t.Errorf("unexpected session %+v", session)
+			}
+			if session.Attempts != tt.attempts {
+				t.Errorf("expect %d attempts, got %d", tt.attempts, session.Attempts)
+			}
+		})
+	}
+
+	if _, err := m.startPunch(context.Background(), eNode, "invalid", 0); err == nil {
+		t.Error("expect an error for the invalid user address")
+	}
+
+	eNode.API.ExternalServiceAddress = func(ctx context.Context, schedulerURL string) (string, error) {
+		return "", errors.New("unreachable")
+	}
+	if _, err := m.startPunch(context.Background(), eNode, "198.51.100.1:5678", 0); err == nil {
+		t.Error("expect an error if the edge does not report its service address")
+	}
+}
+
+func TestRunPunch(t *testing.T) {
+	natType := types.NatTypePortRestricted.String()
+
+	tests := []struct {
+		name   string
+		punch  edgePunchFunc
+		expect types.NatPunchStatus
+	}{
+		{"succeeded", succeed, types.NatPunchSucceeded},
+		{
+			"edge error",
+			func(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error) {
+				return nil, errors.New("rpc error")
+			},
+			types.NatPunchFailed,
+		},
+		{
+			"user unreachable",
+			func(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error) {
+				return &types.NatPunchResult{Status: types.NatPunchFailed, Error: "user unreachable"}, nil
+			},
+			types.NatPunchFailed,
+		},
+		{
+			"no result",
+			func(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error) {
+				return nil, nil
+			},
+			types.NatPunchFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, store := newTestManager()
+
+			p := &punch{
+				session: &types.NatPunchSession{ID: "session", EdgeAddr: testEdgeAddr, EdgeNATType: natType},
+				result:  &types.NatPunchResult{SessionID: "session", Status: types.NatPunchPending},
+				done:    make(chan struct{}),
+			}
+			m.runPunch(newTestEdge(natType, tt.punch), p, time.Second)
+
+			select {
+			case <-p.done:
+			default:
+				t.Fatal("expect the punch is done")
+			}
+
+			if p.result.SessionID != "session" || p.result.Status != tt.expect {
+				t.Errorf("expect %s, got %+v", tt.expect.String(), p.result)
+			}
+			if tt.expect == types.NatPunchFailed && p.result.Error == "" {
+				t.Error("expect the error of the failed punch")
+			}
+
+			stats := m.GetPunchStats()
+			if len(stats) != 1 || stats[0].NATType != natType || stats[0].Attempts != 1 {
+				t.Fatalf("unexpected stats %+v", stats)
+			}
+			if succeeded := stats[0].Successes == 1; succeeded != (tt.expect == types.NatPunchSucceeded) {
+				t.Errorf("unexpected successes %d", stats[0].Successes)
+			}
+			if len(store.results[natType]) != 1 {
+				t.Errorf("expect the result is saved, got %v", store.results)
+			}
+		})
+	}
+}
+
+func TestGetPunchResult(t *testing.T) {
+	m, _ := newTestManager()
+
+	if _, err := m.GetPunchResult(context.Background(), "unknown"); err == nil {
+		t.Error("expect an error for the unknown session")
+	}
+
+	release := make(chan struct{})
+	eNode := newTestEdge(types.NatTypeFullCone.String(), func(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error) {
+		<-release
+		return succeed(ctx, session)
+	})
+
+	session, err := m.startPunch(context.Background(), eNode, "198.51.100.1:5678", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pending result is returned if the context is done before the punch ends
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result, err := m.GetPunchResult(ctx, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != types.NatPunchPending {
+		t.Errorf("expect %s, got %s", types.NatPunchPending.String(), result.Status.String())
+	}
+
+	close(release)
+
+	result, err = m.GetPunchResult(context.Background(), session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SessionID != session.ID || result.Status != types.NatPunchSucceeded || result.PunchedAddr != testEdgeAddr {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	// the caller gets a copy of the result
+	result.Status = types.NatPunchFailed
+	if result, _ = m.GetPunchResult(context.Background(), session.ID); result.Status != types.NatPunchSucceeded {
+		t.Error("expect the result is not changed by the caller")
+	}
+}
+
+func TestPunchSuccessRate(t *testing.T) {
+	m, _ := newTestManager()
+	m.punchStats[types.NatTypeSymmetric.String()] = &types.NatPunchStats{NATType: types.NatTypeSymmetric.String(), Attempts: 8, Successes: 1}
+	m.punchStats[types.NatTypeFullCone.String()] = &types.NatPunchStats{NATType: types.NatTypeFullCone.String(), Attempts: 8, Successes: 8}
+
+	tests := []struct {
+		natType string
+		expect  float64
+	}{
+		{types.NatTypeNo.String(), 1},
+		{types.NatTypeRestricted.String(), 0.5}, // no attempts
+		{types.NatTypeSymmetric.String(), 0.2},
+		{types.NatTypeFullCone.String(), 0.9},
+	}
+
+	for _, tt := range tests {
+		if got := m.PunchSuccessRate(tt.natType); got != tt.expect {
+			t.Errorf("%s: expect %v, got %v", tt.natType, tt.expect, got)
+		}
+	}
+}
+
+func TestSortByPunchSuccessRate(t *testing.T) {
+	m, _ := newTestManager()
+	m.punchStats[types.NatTypeSymmetric.String()] = &types.NatPunchStats{NATType: types.NatTypeSymmetric.String(), Attempts: 8, Successes: 1}
+	m.punchStats[types.NatTypeFullCone.String()] = &types.NatPunchStats{NATType: types.NatTypeFullCone.String(), Attempts: 8, Successes: 8}
+
+	infos := []*types.EdgeDownloadInfo{
+		{NodeID: "e_1", NatType: types.NatTypeSymmetric.String()},
+		{NodeID: "e_2", NatType: types.NatTypeRestricted.String()},
+		{NodeID: "e_3", NatType: types.NatTypeFullCone.String()},
+		{NodeID: "e_4", NatType: types.NatTypeNo.String()},
+		{NodeID: "e_5", NatType: types.NatTypeRestricted.String()},
+	}
+	m.SortByPunchSuccessRate(infos)
+
+	expect := []string{"e_4", "e_3", "e_2", "e_5", "e_1"}
+	for i, info := range infos {
+		if info.NodeID != expect[i] {
+			t.Fatalf("expect the order %v, got %s at %d", expect, info.NodeID, i)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package node
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	autoQuarantineReputation  = 20.0           // Nodes with a lower reputation are quarantined automatically
+	autoQuarantineSuccessRate = 0.5            // Nodes with a lower validation success rate are quarantined automatically
+	autoQuarantineValidations = 10             // The success rate is only counted if the node has at least this many validations
+	autoQuarantineDuration    = 24 * time.Hour // The automatic quarantine is renewed while the node is below the thresholds
+)
+
+// BanNode bans the node until the expiration, the online node is disconnected and can not login again
+func (m *Manager) BanNode(nodeID, reason string, expiration time.Time) error {
+	if err := m.saveBlacklistEntry(nodeID, types.NodeBlacklistBan, reason, expiration, false); err != nil {
+		return err
+	}
+
+	if node := m.GetNode(nodeID); node != nil {
+		m.disconnectNode(node)
+	}
+
+	log.Infof("node event, node %s banned until %s: %s", nodeID, expiration.String(), reason)
+	return nil
+}
+
+// UnbanNode removes the ban of the node
+func (m *Manager) UnbanNode(nodeID string) error {
+	return m.DeleteNodeBlacklistEntry(nodeID, types.NodeBlacklistBan)
+}
+
+// QuarantineNode quarantines the node until the expiration, the node stays online but receives no new replicas and serves no downloads
+func (m *Manager) QuarantineNode(nodeID, reason string, expiration time.Time) error {
+	if err := m.saveBlacklistEntry(nodeID, types.NodeBlacklistQuarantine, reason, expiration, false); err != nil {
+		return err
+	}
+
+	if node := m.GetNode(nodeID); node != nil {
+		node.SetQuarantined(true)
+	}
+
+	log.Infof("node event, node %s quarantined until %s: %s", nodeID, expiration.String(), reason)
+	return nil
+}
+
+// LiftQuarantine removes the quarantine of the node
+func (m *Manager) LiftQuarantine(nodeID string) error {
+	if err := m.DeleteNodeBlacklistEntry(nodeID, types.NodeBlacklistQuarantine); err != nil {
+		return err
+	}
+
+	if node := m.GetNode(nodeID); node != nil {
+		node.SetQuarantined(false)
+	}
+
+	return nil
+}
+
+// CheckNodeBanned returns an error with the reason if the node is banned
+func (m *Manager) CheckNodeBanned(nodeID string) error {
+	entry, err := m.LoadNodeBlacklistEntry(nodeID, types.NodeBlacklistBan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	return xerrors.Errorf("node %s is banned until %s: %s", nodeID, entry.Expiration.String(), entry.Reason)
+}
+
+// IsNodeQuarantined returns whether the node is quarantined
+func (m *Manager) IsNodeQuarantined(nodeID string) (bool, error) {
+	_, err := m.LoadNodeBlacklistEntry(nodeID, types.NodeBlacklistQuarantine)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// saveBlacklistEntry checks the node and saves the ban or quarantine of it
+func (m *Manager) saveBlacklistEntry(nodeID string, t types.NodeBlacklistType, reason string, expiration time.Time, automatic bool) error {
+	if !expiration.After(time.Now()) {
+		return xerrors.Errorf("expiration %s has passed", expiration.String())
+	}
+
+	if _, err := m.LoadNodePublicKey(nodeID); err != nil {
+		if err == sql.ErrNoRows {
+			return xerrors.Errorf("node %s not exists", nodeID)
+		}
+		return err
+	}
+
+	return m.SaveNodeBlacklistEntry(&types.NodeBlacklistEntry{
+		NodeID:     nodeID,
+		Type:       t,
+		Reason:     reason,
+		Automatic:  automatic,
+		Expiration: expiration,
+	})
+}
+
+// disconnectNode closes the connection of the online node and removes it from the online nodes
+func (m *Manager) disconnectNode(node *Node) {
+	if node.ClientCloser != nil {
+		node.ClientCloser()
+	}
+
+	if node.Type == types.NodeCandidate {
+		m.deleteCandidateNode(node)
+	} else if node.Type == types.NodeEdge {
+		m.deleteEdgeNode(node)
+	}
+}
+
+// refreshBlacklist applies the unexpired bans and quarantines to the online nodes, and removes the expired ones
+func (m *Manager) refreshBlacklist() {
+	entries, err := m.LoadNodeBlacklistEntries()
+	if err != nil {
+		log.Errorf("LoadNodeBlacklistEntries err:%s", err.Error())
+		return
+	}
+
+	m.applyBlacklist(entries, time.Now())
+
+	if err := m.DeleteExpiredNodeBlacklistEntries(); err != nil {
+		log.Errorf("DeleteExpiredNodeBlacklistEntries err:%s", err.Error())
+	}
+}
+
+// applyBlacklist disconnects the banned nodes and quarantines the online nodes by the entries which are not expired at the time,
+// the quarantines of the other nodes are lifted
+func (m *Manager) applyBlacklist(entries []*types.NodeBlacklistEntry, now time.Time) {
+	banned := make(map[string]struct{})
+	quarantined := make(map[string]struct{})
+	for _, entry := range entries {
+		if !entry.Expiration.After(now) {
+			continue
+		}
+
+		if entry.Type == types.NodeBlacklistBan {
+			banned[entry.NodeID] = struct{}{}
+		} else if entry.Type == types.NodeBlacklistQuarantine {
+			quarantined[entry.NodeID] = struct{}{}
+		}
+	}
+
+	apply := func(key, value interface{}) bool {
+		node := value.(*Node)
+		if node == nil {
+			return true
+		}
+
+		// the node may be banned by another scheduler
+		if _, ok := banned[node.NodeID]; ok {
+			m.disconnectNode(node)
+			return true
+		}
+
+		_, ok := quarantined[node.NodeID]
+		node.SetQuarantined(ok)
+		return true
+	}
+
+	m.edgeNodes.Range(apply)
+	m.candidateNodes.Range(apply)
+}
+
+// autoQuarantine quarantines the node if its reputation or validation success rate is below the thresholds,
+// the quarantine added by the operator is kept
+func (m *Manager) autoQuarantine(f *types.ReputationFactors, reputation float64) {
+	reason := quarantineReason(f, reputation)
+	if reason == "" {
+		return
+	}
+
+	entry, err := m.LoadNodeBlacklistEntry(f.NodeID, types.NodeBlacklistQuarantine)
+	if err != nil && err != sql.ErrNoRows {
+		log.Errorf("LoadNodeBlacklistEntry %s err:%s", f.NodeID, err.Error())
+		return
+	}
+
+	if entry != nil && !entry.Automatic {
+		return
+	}
+
+	expiration := time.Now().Add(autoQuarantineDuration)
+	if err := m.saveBlacklistEntry(f.NodeID, types.NodeBlacklistQuarantine, reason, expiration, true); err != nil {
+		log.Errorf("auto quarantine %s err:%s", f.NodeID, err.Error())
+		return
+	}
+
+	if node := m.GetNode(f.NodeID); node != nil {
+		node.SetQuarantined(true)
+	}
+
+	log.Infof("node event, node %s quarantined automatically until %s: %s", f.NodeID, expiration.String(), reason)
+}
+
+// quarantineReason returns the reason to quarantine the node automatically, it is empty if the node is above the thresholds
+func quarantineReason(f *types.ReputationFactors, reputation float64) string {
+	if reputation < autoQuarantineReputation {
+		return fmt.Sprintf("reputation %.2f is below %.2f", reputation, autoQuarantineReputation)
+	}
+
+	if f.Validations >= autoQuarantineValidations {
+		rate := float64(f.Succeeded) / float64(f.Validations)
+		if rate < autoQuarantineSuccessRate {
+			return fmt.Sprintf("validation success rate %.2f is below %.2f", rate, autoQuarantineSuccessRate)
+		}
+	}
+
+	return ""
+}
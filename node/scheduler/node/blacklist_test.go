@@ -0,0 +1,77 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+func TestQuarantineReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		factors    types.ReputationFactors
+		reputation float64
+		expect     string
+	}{
+		{
+			name:       "above the thresholds",
+			factors:    types.ReputationFactors{Validations: 10, Succeeded: 5},
+			reputation: autoQuarantineReputation,
+		},
+		{
+			name:       "low reputation",
+			reputation: 10,
+			expect:     "reputation 10.00 is below 20.00",
+		},
+		{
+			name:       "low success rate",
+			factors:    types.ReputationFactors{Validations: 10, Succeeded: 4},
+			reputation: 50,
+			expect:     "validation success rate 0.40 is below 0.50",
+		},
+		{
+			name:       "too few validations to count the success rate",
+			factors:    types.ReputationFactors{Validations: autoQuarantineValidations - 1},
+			reputation: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := quarantineReason(&tt.factors, tt.reputation); got != tt.expect {
+			t.Errorf("%s: expect %q, got %q", tt.name, tt.expect, got)
+		}
+	}
+}
+
+func TestApplyBlacklistExpiration(t *testing.T) {
+	m := &Manager{}
+	now := time.Now()
+
+	nodes := make(map[string]*Node)
+	for _, nodeID := range []string{"quarantined", "expired", "lifted"} {
+		node := &Node{NodeInfo: &types.NodeInfo{NodeID: nodeID, Type: types.NodeEdge}}
+		node.SetQuarantined(nodeID == "lifted")
+		m.edgeNodes.Store(nodeID, node)
+		nodes[nodeID] = node
+	}
+
+	m.applyBlacklist([]*types.NodeBlacklistEntry{
+		{NodeID: "quarantined", Type: types.NodeBlacklistQuarantine, Expiration: now.Add(time.Hour)},
+		{NodeID: "expired", Type: types.NodeBlacklistQuarantine, Expiration: now},
+	}, now)
+
+	expect := map[string]bool{"quarantined": true, "expired": false, "lifted": false}
+	for nodeID, quarantined := range expect {
+		if got := nodes[nodeID].IsQuarantined(); got != quarantined {
+			t.Errorf("node %s: expect quarantined %v, got %v", nodeID, quarantined, got)
+		}
+	}
+}
+
+func TestSaveBlacklistEntryExpired(t *testing.T) {
+	m := &Manager{}
+	if err := m.saveBlacklistEntry("node", types.NodeBlacklistQuarantine, "test", time.Now().Add(-time.Second), false); err == nil {
+		t.Error("expect an error for the passed expiration")
+	}
+}
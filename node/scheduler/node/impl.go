@@ -1,7 +1,10 @@
 package node
 
 import (
+	"database/sql"
+
 	"github.com/Filecoin-Titan/titan/api/types"
+	"golang.org/x/xerrors"
 )
 
 // NodesQuit nodes quit and removes their replicas
@@ -44,6 +47,18 @@ func (m *Manager) GetAllCandidateNodes() []string {
 	return out
 }
 
+// GetAllEdgeNodes returns a list of all edge nodes
+func (m *Manager) GetAllEdgeNodes() []string {
+	var out []string
+	m.edgeNodes.Range(func(key, value interface{}) bool {
+		nodeID := key.(string)
+		out = append(out, nodeID)
+		return true
+	})
+
+	return out
+}
+
 // GetNode retrieves a node with the given node ID
 func (m *Manager) GetNode(nodeID string) *Node {
 	edge := m.GetEdgeNode(nodeID)
@@ -119,3 +134,72 @@ func (m *Manager) NodeOnline(node *Node) error {
 
 	return nil
 }
+
+// SetNodeState sets the service state of the node, the state of the online node is updated as well
+func (m *Manager) SetNodeState(nodeID string, state types.NodeState) error {
+	if _, err := m.LoadNodeState(nodeID); err != nil {
+		if err == sql.ErrNoRows {
+			return xerrors.Errorf("node %s not exists", nodeID)
+		}
+		return err
+	}
+
+	if err := m.UpdateNodeState(nodeID, state); err != nil {
+		return err
+	}
+
+	if node := m.GetNode(nodeID); node != nil {
+		node.SetState(state)
+	}
+
+	log.Infof("node event, node %s state: %s", nodeID, state.String())
+	return nil
+}
+
+// SetNodeLabels sets the labels of the node, the labels of the online node are updated as well
+func (m *Manager) SetNodeLabels(nodeID string, labels map[string]string) error {
+	for key, value := range labels {
+		if err := types.ValidateLabel(key, value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := m.LoadNodeState(nodeID); err != nil {
+		if err == sql.ErrNoRows {
+			return xerrors.Errorf("node %s not exists", nodeID)
+		}
+		return err
+	}
+
+	if err := m.SaveNodeLabels(nodeID, labels); err != nil {
+		return err
+	}
+
+	return m.reloadNodeLabels(nodeID)
+}
+
+// RemoveNodeLabels removes the labels of the node with the keys, the labels of the online node are updated as well
+func (m *Manager) RemoveNodeLabels(nodeID string, keys []string) error {
+	if err := m.DeleteNodeLabels(nodeID, keys); err != nil {
+		return err
+	}
+
+	return m.reloadNodeLabels(nodeID)
+}
+
+// reloadNodeLabels loads the labels of the online node from the db
+func (m *Manager) reloadNodeLabels(nodeID string) error {
+	node := m.GetNode(nodeID)
+	if node == nil {
+		return nil
+	}
+
+	labels, err := m.LoadNodeLabels(nodeID)
+	if err != nil {
+		return err
+	}
+
+	node.SetLabels(labels)
+	log.Infof("node event, node %s labels: %v", nodeID, labels)
+	return nil
+}
@@ -29,6 +29,9 @@ const (
 
 	// saveInfoInterval is the interval at which node information is saved during keepalive requests
 	saveInfoInterval = 10 // keepalive saves information every 10 times
+
+	// credentialsRetention is the time the expired credentials are kept for verifying the user proofs of work
+	credentialsRetention = 24 * time.Hour
 )
 
 // Manager is the node manager responsible for managing the online nodes
@@ -74,6 +77,7 @@ func NewManager(sdb *db.SQLDB, serverID dtypes.ServerID, k *rsa.PrivateKey, p *p
 	}
 
 	go nodeManager.run()
+	go nodeManager.startReputationTicker()
 
 	return nodeManager
 }
@@ -92,6 +96,19 @@ func (m *Manager) run() {
 		m.nodesKeepalive(saveInfo)
 		// Check how long a node has been offline
 		m.checkNodesTTL()
+
+		if saveInfo {
+			m.cleanExpiredCredentials()
+			m.refreshBlacklist()
+			m.cleanNodeStats()
+		}
+	}
+}
+
+// cleanExpiredCredentials removes the credentials which expired longer than the retention time
+func (m *Manager) cleanExpiredCredentials() {
+	if err := m.DeleteExpiredCredentials(time.Now().Add(-credentialsRetention)); err != nil {
+		log.Errorf("DeleteExpiredCredentials err:%s", err.Error())
 	}
 }
 
@@ -134,28 +151,28 @@ func (m *Manager) storeCandidateNode(node *Node) {
 
 // deleteEdgeNode removes an edge node from the manager's list of edge nodes
 func (m *Manager) deleteEdgeNode(node *Node) {
-	m.repayEdgeNodeNum(node.nodeNum)
-	m.notify.Pub(node, types.EventNodeOffline.String())
-
 	nodeID := node.NodeID
 	_, loaded := m.edgeNodes.LoadAndDelete(nodeID)
 	if !loaded {
 		return
 	}
 	m.Edges--
+
+	m.repayEdgeNodeNum(node.nodeNum)
+	m.notify.Pub(node, types.EventNodeOffline.String())
 }
 
 // deleteCandidateNode removes a candidate node from the manager's list of candidate nodes
 func (m *Manager) deleteCandidateNode(node *Node) {
-	m.repayCandidateNodeNum(node.nodeNum)
-	m.notify.Pub(node, types.EventNodeOffline.String())
-
 	nodeID := node.NodeID
 	_, loaded := m.candidateNodes.LoadAndDelete(nodeID)
 	if !loaded {
 		return
 	}
 	m.Candidates--
+
+	m.repayCandidateNodeNum(node.nodeNum)
+	m.notify.Pub(node, types.EventNodeOffline.String())
 }
 
 // nodeKeepalive checks if a node has sent a keepalive recently and updates node status accordingly
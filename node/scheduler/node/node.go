@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api"
@@ -29,9 +30,12 @@ type Node struct {
 	token string
 
 	*types.NodeInfo
+	infoLock   sync.RWMutex // Guards the fields of NodeInfo which are updated while the node is online
 	publicKey  *rsa.PublicKey
 	remoteAddr string
 	tcpPort    int
+	tcpTLS     bool
+	tcpCert    string // the fingerprint of the tls certificate of the tcp server
 
 	lastRequestTime time.Time // Node last keepalive time
 	pullingCount    int       // The number of asset waiting and pulling in progress
@@ -51,6 +55,8 @@ type API struct {
 	// edge api
 	ExternalServiceAddress func(ctx context.Context, schedulerURL string) (string, error)
 	UserNATPunch           func(ctx context.Context, sourceURL string, req *types.NatPunchReq) error
+	EdgeNATPunch           func(ctx context.Context, session *types.NatPunchSession) (*types.NatPunchResult, error)
+	ProbeNATType           func(ctx context.Context, probeAddr string) (types.NatType, error)
 	// candidate api
 	GetBlocksOfAsset func(ctx context.Context, assetCID string, randomSeed int64, randomCount int) (map[int]string, error)
 }
@@ -73,6 +79,8 @@ func APIFromEdge(api api.Edge) *API {
 		WaitQuiet:              api.WaitQuiet,
 		ExternalServiceAddress: api.ExternalServiceAddress,
 		UserNATPunch:           api.UserNATPunch,
+		EdgeNATPunch:           api.EdgeNATPunch,
+		ProbeNATType:           api.ProbeNATType,
 	}
 	return a
 }
@@ -125,6 +133,11 @@ func (n *Node) ConnectRPC(addr string, nodeType types.NodeType) error {
 	return xerrors.Errorf("node %s type %d not wrongful", n.NodeID, n.Type)
 }
 
+// IsSchedulable returns whether the node receives new replicas and validations, it is false if the node is in maintenance or draining
+func (n *Node) IsSchedulable() bool {
+	return n.GetState() == types.NodeStateNormal
+}
+
 // SetToken sets the token of the node
 func (n *Node) SetToken(t string) {
 	n.token = t
@@ -155,6 +168,26 @@ func (n *Node) SetTCPPort(port int) {
 	n.tcpPort = port
 }
 
+// SetTCPTLS sets whether the tcp server of the node is wrapped with tls
+func (n *Node) SetTCPTLS(enable bool) {
+	n.tcpTLS = enable
+}
+
+// TCPTLS returns whether the tcp server of the node is wrapped with tls
+func (n *Node) TCPTLS() bool {
+	return n.tcpTLS
+}
+
+// SetTCPCertHash sets the fingerprint of the tls certificate of the tcp server
+func (n *Node) SetTCPCertHash(hash string) {
+	n.tcpCert = hash
+}
+
+// TCPCertHash returns the fingerprint of the tls certificate of the tcp server
+func (n *Node) TCPCertHash() string {
+	return n.tcpCert
+}
+
 // TCPAddr returns the tcp address of the node
 func (n *Node) TCPAddr() string {
 	index := strings.Index(n.remoteAddr, ":")
@@ -204,6 +237,120 @@ func (n *Node) CurPullingCount() int {
 	return n.pullingCount
 }
 
+// Info returns a copy of the node information
+func (n *Node) Info() types.NodeInfo {
+	n.infoLock.RLock()
+	defer n.infoLock.RUnlock()
+
+	return *n.NodeInfo
+}
+
+// GetReputation returns the reputation of the node
+func (n *Node) GetReputation() float64 {
+	n.infoLock.RLock()
+	defer n.infoLock.RUnlock()
+
+	return n.Reputation
+}
+
+// SetReputation sets the reputation of the node
+func (n *Node) SetReputation(reputation float64) {
+	n.infoLock.Lock()
+	defer n.infoLock.Unlock()
+
+	n.Reputation = reputation
+}
+
+// GetState returns the operational state of the node
+func (n *Node) GetState() types.NodeState {
+	n.infoLock.RLock()
+	defer n.infoLock.RUnlock()
+
+	return n.State
+}
+
+// SetState sets the operational state of the node
+func (n *Node) SetState(state types.NodeState) {
+	n.infoLock.Lock()
+	defer n.infoLock.Unlock()
+
+	n.State = state
+}
+
+// GetLabels returns the labels of the node, the map is replaced rather than modified, so it must not be modified by the caller
+func (n *Node) GetLabels() map[string]string {
+	n.infoLock.RLock()
+	defer n.infoLock.RUnlock()
+
+	return n.Labels
+}
+
+// SetLabels sets the labels of the node
+func (n *Node) SetLabels(labels map[string]string) {
+	n.infoLock.Lock()
+	defer n.infoLock.Unlock()
+
+	n.Labels = labels
+}
+
+// IsQuarantined returns whether the node is quarantined
+func (n *Node) IsQuarantined() bool {
+	n.infoLock.RLock()
+	defer n.infoLock.RUnlock()
+
+	return n.Quarantined
+}
+
+// SetQuarantined sets whether the node is quarantined
+func (n *Node) SetQuarantined(quarantined bool) {
+	n.infoLock.Lock()
+	defer n.infoLock.Unlock()
+
+	n.Quarantined = quarantined
+}
+
+// GetDiskUsage returns the disk usage of the node
+func (n *Node) GetDiskUsage() float64 {
+	n.infoLock.RLock()
+	defer n.infoLock.RUnlock()
+
+	return n.DiskUsage
+}
+
+// SetDiskUsage sets the disk usage of the node
+func (n *Node) SetDiskUsage(usage float64) {
+	n.infoLock.Lock()
+	defer n.infoLock.Unlock()
+
+	n.DiskUsage = usage
+}
+
+// SetResourceUsage sets the cpu, memory and disk usage reported by the node
+func (n *Node) SetResourceUsage(cpu, memory, disk float64) {
+	n.infoLock.Lock()
+	defer n.infoLock.Unlock()
+
+	n.CPUUsage = cpu
+	n.MemoryUsage = memory
+	n.DiskUsage = disk
+}
+
+// GetNATType returns the NAT type of the node
+func (n *Node) GetNATType() string {
+	n.infoLock.RLock()
+	defer n.infoLock.RUnlock()
+
+	return n.NATType
+}
+
+// SetNATType sets the NAT type of the node
+func (n *Node) SetNATType(natType string) {
+	n.infoLock.Lock()
+	defer n.infoLock.Unlock()
+
+	n.NATType = natType
+}
+
 // UpdateNodePort updates the node port
 func (n *Node) UpdateNodePort(port string) {
 	n.PortMapping = port
@@ -211,13 +358,21 @@ func (n *Node) UpdateNodePort(port string) {
 
 // Credentials returns the credentials of the node
 func (n *Node) Credentials(cid string, titanRsa *titanrsa.Rsa, privateKey *rsa.PrivateKey) (*types.GatewayCredentials, error) {
-	svc := &types.Credentials{
+	return n.EncryptCredentials(n.NewCredentials(cid), titanRsa, privateKey)
+}
+
+// NewCredentials creates the credentials for accessing the asset of the node
+func (n *Node) NewCredentials(cid string) *types.Credentials {
+	return &types.Credentials{
 		ID:        uuid.NewString(),
 		NodeID:    n.NodeID,
 		AssetCID:  cid,
 		ValidTime: time.Now().Add(10 * time.Hour).Unix(),
 	}
+}
 
+// EncryptCredentials encrypts the credentials with the public key of the node and signs them with the scheduler private key
+func (n *Node) EncryptCredentials(svc *types.Credentials, titanRsa *titanrsa.Rsa, privateKey *rsa.PrivateKey) (*types.GatewayCredentials, error) {
 	b, err := n.encryptCredentials(svc, n.publicKey, titanRsa)
 	if err != nil {
 		return nil, xerrors.Errorf("%s encryptCredentials err:%s", n.NodeID, err.Error())
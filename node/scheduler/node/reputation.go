@@ -0,0 +1,87 @@
+package node
+
+import (
+	"math"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+const (
+	// DefaultReputation is the reputation of a new node, it is the reputation calculated from no history,
+	// so a new node keeps its reputation when the reputation is calculated the first time
+	DefaultReputation = 62.5
+
+	reputationInterval = time.Hour          // Interval of the reputation calculation
+	reputationWindow   = 7 * 24 * time.Hour // The validations in the window are counted
+	maxQuitCount       = 5                  // The quit factor is 0 if the node quits more than maxQuitCount times
+	neutralFactor      = 0.5                // The factor of the node without history
+
+	// weights of the reputation factors, the sum is 1
+	validationWeight = 0.4
+	timeoutWeight    = 0.15
+	bandwidthWeight  = 0.2
+	uptimeWeight     = 0.15
+	quitWeight       = 0.1
+)
+
+// startReputationTicker calculates the reputation of the nodes periodically
+func (m *Manager) startReputationTicker() {
+	ticker := time.NewTicker(reputationInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+		m.updateReputations()
+	}
+}
+
+// updateReputations calculates the reputation of the nodes and saves them
+func (m *Manager) updateReputations() {
+	factors, err := m.LoadReputationFactors(m.ServerID, time.Now().Add(-reputationWindow))
+	if err != nil {
+		log.Errorf("LoadReputationFactors err:%s", err.Error())
+		return
+	}
+
+	reputations := make(map[string]float64, len(factors))
+	for _, f := range factors {
+		reputation := calculateReputation(f)
+		reputations[f.NodeID] = reputation
+
+		if node := m.GetNode(f.NodeID); node != nil {
+			node.SetReputation(reputation)
+		}
+
+		m.autoQuarantine(f, reputation)
+	}
+
+	if err := m.UpdateNodeReputations(reputations); err != nil {
+		log.Errorf("UpdateNodeReputations err:%s", err.Error())
+	}
+}
+
+// calculateReputation calculates the reputation (0 ~ 100) of the node from the validation success rate, timeouts,
+// the measured bandwidth against the declared bandwidth, the online duration and the quits
+func calculateReputation(f *types.ReputationFactors) float64 {
+	validation, timeout := neutralFactor, 1.0
+	if f.Validations > 0 {
+		validation = float64(f.Succeeded) / float64(f.Validations)
+		timeout = 1 - float64(f.Timeouts)/float64(f.Validations)
+	}
+
+	bandwidth := neutralFactor
+	if f.BandwidthUp > 0 && f.Bandwidth > 0 {
+		bandwidth = math.Min(f.Bandwidth/f.BandwidthUp, 1)
+	}
+
+	uptime := neutralFactor
+	if f.RegisteredDuration > 0 {
+		uptime = math.Min(float64(f.OnlineDuration)/float64(f.RegisteredDuration), 1)
+	}
+
+	quit := math.Max(1-float64(f.QuitCount)/maxQuitCount, 0)
+
+	score := validationWeight*validation + timeoutWeight*timeout + bandwidthWeight*bandwidth + uptimeWeight*uptime + quitWeight*quit
+	return math.Round(score*10000) / 100
+}
@@ -0,0 +1,78 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+func TestCalculateReputation(t *testing.T) {
+	tests := []struct {
+		name    string
+		factors types.ReputationFactors
+		expect  float64
+	}{
+		{
+			name:    "no history",
+			factors: types.ReputationFactors{},
+			// 0.4*0.5 + 0.15*1 + 0.2*0.5 + 0.15*0.5 + 0.1*1, the unknown factors are neutral
+			expect: 62.5,
+		},
+		{
+			name: "perfect",
+			factors: types.ReputationFactors{
+				Validations: 10, Succeeded: 10, BandwidthUp: 100, Bandwidth: 100,
+				RegisteredDuration: 60, OnlineDuration: 60,
+			},
+			expect: 100,
+		},
+		{
+			name: "measured bandwidth above the declared bandwidth is capped",
+			factors: types.ReputationFactors{
+				Validations: 10, Succeeded: 10, BandwidthUp: 100, Bandwidth: 300,
+				RegisteredDuration: 60, OnlineDuration: 120,
+			},
+			expect: 100,
+		},
+		{
+			name: "failures and timeouts",
+			factors: types.ReputationFactors{
+				Validations: 10, Succeeded: 5, Timeouts: 5, BandwidthUp: 100, Bandwidth: 50,
+				RegisteredDuration: 60, OnlineDuration: 30,
+			},
+			// 0.4*0.5 + 0.15*0.5 + 0.2*0.5 + 0.15*0.5 + 0.1*1
+			expect: 55,
+		},
+		{
+			name: "quits",
+			factors: types.ReputationFactors{
+				Validations: 10, Succeeded: 10, BandwidthUp: 100, Bandwidth: 100,
+				RegisteredDuration: 60, OnlineDuration: 60, QuitCount: 2,
+			},
+			expect: 96,
+		},
+		{
+			name: "quit factor is not negative",
+			factors: types.ReputationFactors{
+				Validations: 10, Succeeded: 0, Timeouts: 10, BandwidthUp: 100,
+				RegisteredDuration: 60, QuitCount: maxQuitCount * 2,
+			},
+			// 0.2*0.5 for the bandwidth without the measured bandwidth
+			expect: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateReputation(&tt.factors); got != tt.expect {
+				t.Errorf("expect reputation %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestDefaultReputation(t *testing.T) {
+	if got := calculateReputation(&types.ReputationFactors{}); got != DefaultReputation {
+		t.Errorf("expect the reputation of a new node %v, got %v", float64(DefaultReputation), got)
+	}
+}
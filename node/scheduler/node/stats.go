@@ -0,0 +1,80 @@
+package node
+
+import (
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	statsFineResolution   = 5 * time.Minute     // The reports are averaged in 5 minutes buckets for the recent history
+	statsCoarseResolution = time.Hour           // The reports are averaged in hourly buckets for the long history
+	statsFineRetention    = 7 * 24 * time.Hour  // Retention of the 5 minutes buckets
+	statsCoarseRetention  = 90 * 24 * time.Hour // Retention of the hourly buckets
+	maxStatsPoints        = 2000                // Maximum number of the steps of a history query
+)
+
+// ReportNodeStats saves the resource usage report of the node, and updates the resource usage of the online node
+func (m *Manager) ReportNodeStats(nodeID string, stats *types.NodeStats) error {
+	stats.NodeID = nodeID
+	stats.Time = time.Now()
+
+	for _, resolution := range []time.Duration{statsFineResolution, statsCoarseResolution} {
+		if err := m.SaveNodeStats(stats, resolution); err != nil {
+			return err
+		}
+	}
+
+	if node := m.GetNode(nodeID); node != nil {
+		node.SetResourceUsage(stats.CPUUsage, stats.MemoryUsage, stats.DiskUsage)
+	}
+
+	return nil
+}
+
+// GetNodeStatsHistory returns the resource usage of the node in the time range averaged in every step,
+// the step is rounded up to the resolution of the stored stats
+func (m *Manager) GetNodeStatsHistory(nodeID string, from, to time.Time, step time.Duration) ([]*types.NodeStats, error) {
+	if !to.After(from) {
+		return nil, xerrors.Errorf("time range %s ~ %s is empty", from.String(), to.String())
+	}
+
+	resolution, step, err := statsHistoryStep(from, to, step, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return m.LoadNodeStats(nodeID, resolution, from, to, step)
+}
+
+// statsHistoryStep returns the resolution of the stored stats for the history query and the step rounded up to it,
+// the hourly stats are used for the large steps and the history older than the retention of the 5 minutes stats
+func statsHistoryStep(from, to time.Time, step time.Duration, now time.Time) (time.Duration, time.Duration, error) {
+	resolution := statsFineResolution
+	if step >= statsCoarseResolution || from.Before(now.Add(-statsFineRetention)) {
+		resolution = statsCoarseResolution
+	}
+
+	if step < resolution {
+		step = resolution
+	}
+	step = (step + resolution - 1) / resolution * resolution
+
+	if points := int(to.Sub(from) / step); points > maxStatsPoints {
+		return 0, 0, xerrors.Errorf("%d steps exceed the limit %d, please use a larger step", points, maxStatsPoints)
+	}
+
+	return resolution, step, nil
+}
+
+// cleanNodeStats removes the stats older than the retention of their resolution
+func (m *Manager) cleanNodeStats() {
+	if err := m.DeleteNodeStatsBefore(statsFineResolution, time.Now().Add(-statsFineRetention)); err != nil {
+		log.Errorf("DeleteNodeStatsBefore err:%s", err.Error())
+	}
+
+	if err := m.DeleteNodeStatsBefore(statsCoarseResolution, time.Now().Add(-statsCoarseRetention)); err != nil {
+		log.Errorf("DeleteNodeStatsBefore err:%s", err.Error())
+	}
+}
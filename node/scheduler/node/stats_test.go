@@ -0,0 +1,65 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsHistoryStep(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		from       time.Time
+		to         time.Time
+		step       time.Duration
+		resolution time.Duration
+		expect     time.Duration
+		err        bool
+	}{
+		{
+			name: "small step is raised to the fine resolution",
+			from: now.Add(-time.Hour), to: now, step: time.Minute,
+			resolution: statsFineResolution, expect: statsFineResolution,
+		},
+		{
+			name: "step is rounded up to the fine resolution",
+			from: now.Add(-time.Hour), to: now, step: 7 * time.Minute,
+			resolution: statsFineResolution, expect: 10 * time.Minute,
+		},
+		{
+			name: "large step uses the coarse resolution",
+			from: now.Add(-24 * time.Hour), to: now, step: 90 * time.Minute,
+			resolution: statsCoarseResolution, expect: 2 * time.Hour,
+		},
+		{
+			name: "history older than the fine retention uses the coarse resolution",
+			from: now.Add(-statsFineRetention - time.Hour), to: now, step: 10 * time.Minute,
+			resolution: statsCoarseResolution, expect: statsCoarseResolution,
+		},
+		{
+			name: "too many steps",
+			from: now.Add(-statsFineRetention + time.Hour), to: now, step: statsFineResolution,
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		resolution, step, err := statsHistoryStep(tt.from, tt.to, tt.step, now)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expect an error", tt.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if resolution != tt.resolution || step != tt.expect {
+			t.Errorf("%s: expect resolution %s step %s, got %s %s", tt.name, tt.resolution, tt.expect, resolution, step)
+		}
+	}
+}
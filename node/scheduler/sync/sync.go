@@ -3,29 +3,47 @@ package sync
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
 	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+	"github.com/filecoin-project/pubsub"
 	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
 )
 
 var log = logging.Logger("data-sync")
 
+const (
+	defaultSyncInterval    = 12 * time.Hour   // Used if AssetViewSyncInterval of the config is not set
+	defaultSyncConcurrency = 5                // Used if AssetViewSyncConcurrency of the config is not set
+	syncTimeout            = 60 * time.Second // Timeout of the sync requests to a node
+)
+
 // DataSync asset synchronization manager
 type DataSync struct {
 	nodeList    []string
 	lock        *sync.Mutex
 	waitChannel chan bool
 	nodeManager *node.Manager
+	config      dtypes.GetSchedulerConfigFunc
+	notify      *pubsub.PubSub
+
+	statuses   map[string]*types.NodeSyncStatus
+	statusLock sync.Mutex
 }
 
 // NewDataSync creates a new NewDataSync instance and starts the synchronization process.
-func NewDataSync(nodeManager *node.Manager) *DataSync {
+func NewDataSync(nodeManager *node.Manager, configFunc dtypes.GetSchedulerConfigFunc, p *pubsub.PubSub) *DataSync {
 	dataSync := &DataSync{
 		nodeList:    make([]string, 0),
 		lock:        &sync.Mutex{},
 		waitChannel: make(chan bool),
 		nodeManager: nodeManager,
+		config:      configFunc,
+		notify:      p,
+		statuses:    make(map[string]*types.NodeSyncStatus),
 	}
 
 	go dataSync.startSyncLoop()
@@ -33,19 +51,45 @@ func NewDataSync(nodeManager *node.Manager) *DataSync {
 	return dataSync
 }
 
+// Start starts the periodic sync of all online nodes, and removes the statuses of the offline nodes until the context is done
+func (ds *DataSync) Start(ctx context.Context) {
+	go ds.startSyncTicker(ctx)
+	go ds.subscribeNodeOffline(ctx)
+}
+
+// subscribeNodeOffline removes the sync status of the node when it goes offline
+func (ds *DataSync) subscribeNodeOffline(ctx context.Context) {
+	subOffline := ds.notify.Sub(types.EventNodeOffline.String())
+	defer ds.notify.Unsub(subOffline)
+
+	for {
+		select {
+		case u := <-subOffline:
+			if node, ok := u.(*node.Node); ok && node != nil {
+				ds.removeStatus(node.NodeID)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // AddNodeToList adds a nodeID to the nodeList.
 func (ds *DataSync) AddNodeToList(nodeID string) {
+	ds.addNodesToList([]string{nodeID})
+}
+
+// addNodesToList adds the nodes which are not in the nodeList.
+func (ds *DataSync) addNodesToList(nodeIDs []string) {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	for _, id := range ds.nodeList {
-		if id == nodeID {
-			return
+	for _, nodeID := range nodeIDs {
+		if !contains(ds.nodeList, nodeID) {
+			ds.nodeList = append(ds.nodeList, nodeID)
 		}
 	}
 
-	ds.nodeList = append(ds.nodeList, nodeID)
-
 	ds.notifySyncLoop()
 }
 
@@ -57,15 +101,54 @@ func (ds *DataSync) startSyncLoop() {
 	}
 }
 
-// syncData processes the nodeList to perform data synchronization.
+// adds all online nodes to the nodeList at the interval of the config.
+func (ds *DataSync) startSyncTicker(ctx context.Context) {
+	timer := time.NewTimer(ds.getSyncInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+
+		nodes := append(ds.nodeManager.GetAllCandidateNodes(), ds.nodeManager.GetAllEdgeNodes()...)
+		log.Infof("sync asset view of %d nodes", len(nodes))
+		ds.addNodesToList(nodes)
+
+		timer.Reset(ds.getSyncInterval())
+	}
+}
+
+// syncData processes the nodeList to perform data synchronization, at most the concurrency of the config nodes are synced at the same time.
 func (ds *DataSync) syncData() {
-	for len(ds.nodeList) > 0 {
+	sem := make(chan struct{}, ds.getSyncConcurrency())
+	wg := &sync.WaitGroup{}
+
+	for {
 		nodeID := ds.removeFirstNode()
-		err := ds.performDataSync(nodeID)
-		if err != nil {
-			log.Errorf("do data sync error:%s", err.Error())
+		if nodeID == "" {
+			break
 		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(nodeID string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			err := ds.performDataSync(nodeID)
+			if err != nil {
+				log.Errorf("do data sync error:%s", err.Error())
+			}
+		}(nodeID)
 	}
+
+	wg.Wait()
 }
 
 // notifies the startSyncLoop to process nodeList.
@@ -91,7 +174,12 @@ func (ds *DataSync) removeFirstNode() string {
 }
 
 // synchronizes data for the given nodeID.
-func (ds *DataSync) performDataSync(nodeID string) error {
+func (ds *DataSync) performDataSync(nodeID string) (err error) {
+	mismatchBuckets := make([]uint32, 0)
+	defer func() {
+		ds.updateStatus(nodeID, len(mismatchBuckets), err)
+	}()
+
 	node := ds.nodeManager.GetNode(nodeID)
 	if node == nil {
 		return xerrors.Errorf("could not get node %s data sync api", nodeID)
@@ -106,8 +194,8 @@ func (ds *DataSync) performDataSync(nodeID string) error {
 		return nil
 	}
 
-	ctx, cancle := context.WithCancel(context.Background())
-	defer cancle()
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
 
 	if ok, err := node.CompareTopHash(ctx, topChecksum); err != nil {
 		return xerrors.Errorf("compare top hash %w", err)
@@ -120,15 +208,114 @@ func (ds *DataSync) performDataSync(nodeID string) error {
 		return xerrors.Errorf("get hashes of buckets %w", err)
 	}
 
-	mismatchBuckets, err := node.CompareBucketHashes(ctx, checksums)
+	mismatchBuckets, err = node.CompareBucketHashes(ctx, checksums)
 	if err != nil {
 		return xerrors.Errorf("compare bucket hashes %w", err)
 	}
 
-	log.Warnf("mismatch buckets len:%d", len(mismatchBuckets))
+	// the node compares the assets of the buckets and submits the extra and lost assets asynchronously
+	log.Warnf("node %s mismatch buckets len:%d", nodeID, len(mismatchBuckets))
 	return nil
 }
 
+// SyncNode syncs the assets of the node with its asset view immediately and returns the extra and lost assets,
+// the result is applied by the node unless dryRun is true, the status is not updated by a dry run.
+func (ds *DataSync) SyncNode(ctx context.Context, nodeID string, dryRun bool) (result *types.SyncResult, err error) {
+	node := ds.nodeManager.GetNode(nodeID)
+	if node == nil {
+		return nil, xerrors.Errorf("node %s not online", nodeID)
+	}
+
+	if !dryRun {
+		defer func() {
+			mismatchBuckets := 0
+			if result != nil {
+				mismatchBuckets = result.MismatchBuckets
+			}
+			ds.updateStatus(nodeID, mismatchBuckets, err)
+		}()
+	}
+
+	checksums, err := ds.fetchBucketHashes(nodeID)
+	if err != nil {
+		return nil, xerrors.Errorf("get hashes of buckets %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+
+	result, err = node.SyncAssetView(ctx, checksums, dryRun)
+	if err != nil {
+		return nil, xerrors.Errorf("sync asset view %w", err)
+	}
+
+	return result, nil
+}
+
+// GetSyncStatus returns the status of the last sync of the node
+func (ds *DataSync) GetSyncStatus(nodeID string) (*types.NodeSyncStatus, error) {
+	ds.statusLock.Lock()
+	defer ds.statusLock.Unlock()
+
+	status, ok := ds.statuses[nodeID]
+	if !ok {
+		return nil, xerrors.Errorf("node %s has not synced the asset view", nodeID)
+	}
+
+	out := *status
+	return &out, nil
+}
+
+// updateStatus records the result of the sync of the node
+func (ds *DataSync) updateStatus(nodeID string, mismatchBuckets int, err error) {
+	status := &types.NodeSyncStatus{
+		NodeID:          nodeID,
+		LastSyncTime:    time.Now(),
+		State:           types.SyncStateConsistent,
+		MismatchBuckets: mismatchBuckets,
+	}
+
+	if err != nil {
+		status.State = types.SyncStateFailed
+		status.Error = err.Error()
+	} else if mismatchBuckets > 0 {
+		status.State = types.SyncStateMismatch
+	}
+
+	ds.statusLock.Lock()
+	defer ds.statusLock.Unlock()
+
+	ds.statuses[nodeID] = status
+}
+
+// removeStatus removes the sync status of the node
+func (ds *DataSync) removeStatus(nodeID string) {
+	ds.statusLock.Lock()
+	defer ds.statusLock.Unlock()
+
+	delete(ds.statuses, nodeID)
+}
+
+// getSyncInterval returns the interval of the sync of all online nodes
+func (ds *DataSync) getSyncInterval() time.Duration {
+	cfg, err := ds.config()
+	if err != nil || cfg.AssetViewSyncInterval <= 0 {
+		return defaultSyncInterval
+	}
+
+	return time.Duration(cfg.AssetViewSyncInterval)
+}
+
+// getSyncConcurrency returns the maximum number of the nodes which are synced at the same time
+func (ds *DataSync) getSyncConcurrency() int {
+	cfg, err := ds.config()
+	if err != nil || cfg.AssetViewSyncConcurrency <= 0 {
+		return defaultSyncConcurrency
+	}
+
+	return cfg.AssetViewSyncConcurrency
+}
+
 // retrieves the top hash for a nodeID.
 func (ds *DataSync) fetchTopHash(nodeID string) (string, error) {
 	return ds.nodeManager.LoadTopHash(nodeID)
@@ -138,3 +325,13 @@ func (ds *DataSync) fetchTopHash(nodeID string) (string, error) {
 func (ds *DataSync) fetchBucketHashes(nodeID string) (map[uint32]string, error) {
 	return ds.nodeManager.LoadBucketHashes(nodeID)
 }
+
+// contains checks if the node exists in the list
+func contains(nodeIDs []string, nodeID string) bool {
+	for _, id := range nodeIDs {
+		if id == nodeID {
+			return true
+		}
+	}
+	return false
+}
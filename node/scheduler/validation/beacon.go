@@ -0,0 +1,221 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// beaconTimeout is the timeout of the requests to the beacon
+	beaconTimeout = 10 * time.Second
+	// localBeaconPeriod is the period of the local beacon rounds
+	localBeaconPeriod = 30 * time.Second
+)
+
+// BeaconEntry is the randomness of a beacon round
+type BeaconEntry struct {
+	Round      uint64
+	Randomness []byte
+}
+
+// RandomnessBeacon is the source of the validation randomness, every round of the beacon has a public randomness
+type RandomnessBeacon interface {
+	// Latest returns the latest beacon entry
+	Latest(ctx context.Context) (*BeaconEntry, error)
+	// Entry returns the beacon entry of the round
+	Entry(ctx context.Context, round uint64) (*BeaconEntry, error)
+}
+
+// NewRandomnessBeacon returns a drand-style http beacon which verifies the entries with the hex chain public key,
+// or a local beacon with the secret if the url is empty, a random secret is used if the secret is empty as well
+func NewRandomnessBeacon(url, publicKey, secret string) (RandomnessBeacon, error) {
+	if url == "" {
+		if secret == "" {
+			log.Warn("randomness beacon secret is not set, use a random secret, the challenges of the rounds before a restart can not be re-derived")
+
+			buf := make([]byte, 32)
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			secret = string(buf)
+		}
+
+		return NewLocalBeacon(time.Unix(0, 0), localBeaconPeriod, []byte(secret)), nil
+	}
+
+	if publicKey == "" {
+		return nil, xerrors.New("the chain public key of the randomness beacon is required")
+	}
+
+	key, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return nil, xerrors.Errorf("decode beacon public key %w", err)
+	}
+
+	return NewHTTPBeacon(url, key)
+}
+
+// DeriveSeed derives the validation seed from the beacon entry and the scheduler server id,
+// anyone can re-derive the seed and the expected blocks from the public beacon
+func DeriveSeed(entry *BeaconEntry, serverID string) int64 {
+	round := make([]byte, 8)
+	binary.BigEndian.PutUint64(round, entry.Round)
+
+	h := sha256.New()
+	h.Write(entry.Randomness)
+	h.Write(round)
+	h.Write([]byte(serverID))
+	sum := h.Sum(nil)
+
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+// DeriveNodeSeed derives the seed of the storage proof of the node from the beacon entry and the scheduler server id,
+// the seed is mixed with the node id, so each node is challenged with different blocks
+func DeriveNodeSeed(entry *BeaconEntry, serverID, nodeID string) int64 {
+	seed := make([]byte, 8)
+	binary.BigEndian.PutUint64(seed, uint64(DeriveSeed(entry, serverID)))
+
+	h := sha256.New()
+	h.Write(seed)
+	h.Write([]byte(nodeID))
+	sum := h.Sum(nil)
+
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+// HTTPBeacon fetches the randomness from a drand http api, the entries are verified with the chain public key
+type HTTPBeacon struct {
+	url      string
+	client   *http.Client
+	verifier *beaconVerifier
+}
+
+// NewHTTPBeacon returns a beacon of the drand http api, url is the chain endpoint, e.g. https://api.drand.sh,
+// publicKey is the compressed public key of the chain
+func NewHTTPBeacon(url string, publicKey []byte) (*HTTPBeacon, error) {
+	verifier, err := newBeaconVerifier(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPBeacon{url: strings.TrimSuffix(url, "/"), client: &http.Client{Timeout: beaconTimeout}, verifier: verifier}, nil
+}
+
+// drandEntry is the response of the drand http api
+type drandEntry struct {
+	Round             uint64 `json:"round"`
+	Randomness        string `json:"randomness"`
+	Signature         string `json:"signature"`
+	PreviousSignature string `json:"previous_signature,omitempty"`
+}
+
+// Latest returns the latest beacon entry
+func (b *HTTPBeacon) Latest(ctx context.Context) (*BeaconEntry, error) {
+	return b.fetch(ctx, "latest")
+}
+
+// Entry returns the beacon entry of the round
+func (b *HTTPBeacon) Entry(ctx context.Context, round uint64) (*BeaconEntry, error) {
+	entry, err := b.fetch(ctx, fmt.Sprintf("%d", round))
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Round != round {
+		return nil, xerrors.Errorf("beacon returns round %d, expect %d", entry.Round, round)
+	}
+	return entry, nil
+}
+
+// fetch fetches the beacon entry from the public api
+func (b *HTTPBeacon) fetch(ctx context.Context, round string) (*BeaconEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/public/%s", b.url, round), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close() //nolint:errcheck // ignore error
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("beacon status code %d", rsp.StatusCode)
+	}
+
+	var de drandEntry
+	if err := json.NewDecoder(rsp.Body).Decode(&de); err != nil {
+		return nil, err
+	}
+
+	randomness, err := hex.DecodeString(de.Randomness)
+	if err != nil {
+		return nil, xerrors.Errorf("decode randomness %w", err)
+	}
+
+	// the drand randomness is the hash of the round signature
+	signature, err := hex.DecodeString(de.Signature)
+	if err != nil {
+		return nil, xerrors.Errorf("decode signature %w", err)
+	}
+
+	sum := sha256.Sum256(signature)
+	if !bytes.Equal(sum[:], randomness) {
+		return nil, xerrors.Errorf("round %d randomness does not match the signature", de.Round)
+	}
+
+	previous, err := hex.DecodeString(de.PreviousSignature)
+	if err != nil {
+		return nil, xerrors.Errorf("decode previous signature %w", err)
+	}
+
+	// the relay can not forge the signature without the private key of the chain
+	if err := b.verifier.verify(de.Round, signature, previous); err != nil {
+		return nil, xerrors.Errorf("round %d %w", de.Round, err)
+	}
+
+	return &BeaconEntry{Round: de.Round, Randomness: randomness}, nil
+}
+
+// LocalBeacon is a deterministic beacon, the randomness of a round is derived from the secret
+type LocalBeacon struct {
+	genesis time.Time
+	period  time.Duration
+	secret  []byte
+}
+
+// NewLocalBeacon returns a local beacon, the round increases every period since genesis
+func NewLocalBeacon(genesis time.Time, period time.Duration, secret []byte) *LocalBeacon {
+	return &LocalBeacon{genesis: genesis, period: period, secret: secret}
+}
+
+// Latest returns the entry of the current round
+func (b *LocalBeacon) Latest(ctx context.Context) (*BeaconEntry, error) {
+	round := uint64(time.Since(b.genesis)/b.period) + 1
+	return b.Entry(ctx, round)
+}
+
+// Entry returns the beacon entry of the round
+func (b *LocalBeacon) Entry(ctx context.Context, round uint64) (*BeaconEntry, error) {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, round)
+
+	h := sha256.New()
+	h.Write(b.secret)
+	h.Write(buf)
+
+	return &BeaconEntry{Round: round, Randomness: h.Sum(nil)}, nil
+}
@@ -0,0 +1,90 @@
+package validation
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// g2SignatureDST is the hash to curve domain of the drand chains which sign on G2, e.g. the default chain
+	g2SignatureDST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_"
+	// g1SignatureDST is the hash to curve domain of the drand chains which sign on G1, e.g. quicknet
+	g1SignatureDST = "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_"
+)
+
+// beaconVerifier verifies the BLS signatures of the drand rounds, the scheme is chosen by the public key,
+// a public key on G1 signs on G2 and a public key on G2 signs on G1
+type beaconVerifier struct {
+	g1Key *bls.G1
+	g2Key *bls.G2
+}
+
+// newBeaconVerifier returns a verifier of the compressed chain public key
+func newBeaconVerifier(publicKey []byte) (*beaconVerifier, error) {
+	switch len(publicKey) {
+	case bls.G1SizeCompressed:
+		key := &bls.G1{}
+		if err := key.SetBytes(publicKey); err != nil {
+			return nil, xerrors.Errorf("beacon public key %w", err)
+		}
+		return &beaconVerifier{g1Key: key}, nil
+	case bls.G2SizeCompressed:
+		key := &bls.G2{}
+		if err := key.SetBytes(publicKey); err != nil {
+			return nil, xerrors.Errorf("beacon public key %w", err)
+		}
+		return &beaconVerifier{g2Key: key}, nil
+	}
+
+	return nil, xerrors.Errorf("beacon public key length %d is invalid", len(publicKey))
+}
+
+// beaconMessage returns the message signed in the round, the chained rounds sign the previous signature as well
+func beaconMessage(round uint64, previous []byte) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, round)
+
+	h := sha256.New()
+	h.Write(previous)
+	h.Write(buf)
+	return h.Sum(nil)
+}
+
+// verify verifies the signature of the round
+func (v *beaconVerifier) verify(round uint64, signature, previous []byte) error {
+	msg := beaconMessage(round, previous)
+
+	if v.g1Key != nil {
+		sig := &bls.G2{}
+		if err := sig.SetBytes(signature); err != nil {
+			return xerrors.Errorf("signature %w", err)
+		}
+
+		h := &bls.G2{}
+		h.Hash(msg, []byte(g2SignatureDST))
+
+		// e(g1, sig) == e(pk, H(m)), the pairing modifies the G1 points, so the key is copied
+		key := *v.g1Key
+		if !bls.ProdPairFrac([]*bls.G1{bls.G1Generator(), &key}, []*bls.G2{sig, h}, []int{1, -1}).IsIdentity() {
+			return xerrors.New("signature is invalid")
+		}
+		return nil
+	}
+
+	sig := &bls.G1{}
+	if err := sig.SetBytes(signature); err != nil {
+		return xerrors.Errorf("signature %w", err)
+	}
+
+	h := &bls.G1{}
+	h.Hash(msg, []byte(g1SignatureDST))
+
+	// e(sig, g2) == e(H(m), pk)
+	if !bls.ProdPairFrac([]*bls.G1{sig, h}, []*bls.G2{bls.G2Generator(), v.g2Key}, []int{1, -1}).IsIdentity() {
+		return xerrors.New("signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+func TestLocalBeacon(t *testing.T) {
+	beacon := NewLocalBeacon(time.Now().Add(-time.Minute), 10*time.Second, []byte("secret"))
+
+	latest, err := beacon.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Round != 7 {
+		t.Errorf("expect round 7, got %d", latest.Round)
+	}
+
+	entry, err := beacon.Entry(context.Background(), latest.Round)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if DeriveSeed(entry, "server") != DeriveSeed(latest, "server") {
+		t.Errorf("seed of the same round is not deterministic")
+	}
+
+	if DeriveSeed(entry, "server") == DeriveSeed(entry, "other") {
+		t.Errorf("seed of different servers should be different")
+	}
+}
+
+// testChain signs the drand rounds with a random key, the signatures are on G2 if onG2 is true
+type testChain struct {
+	sk   *bls.Scalar
+	onG2 bool
+}
+
+func newTestChain(t *testing.T, onG2 bool) *testChain {
+	sk := &bls.Scalar{}
+	if err := sk.Random(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	return &testChain{sk: sk, onG2: onG2}
+}
+
+// publicKey returns the compressed public key of the chain
+func (c *testChain) publicKey() []byte {
+	if c.onG2 {
+		pk := &bls.G1{}
+		pk.ScalarMult(c.sk, bls.G1Generator())
+		return pk.BytesCompressed()
+	}
+
+	pk := &bls.G2{}
+	pk.ScalarMult(c.sk, bls.G2Generator())
+	return pk.BytesCompressed()
+}
+
+// sign returns the compressed signature of the round
+func (c *testChain) sign(round uint64, previous []byte) []byte {
+	msg := beaconMessage(round, previous)
+	if c.onG2 {
+		h := &bls.G2{}
+		h.Hash(msg, []byte(g2SignatureDST))
+		h.ScalarMult(c.sk, h)
+		return h.BytesCompressed()
+	}
+
+	h := &bls.G1{}
+	h.Hash(msg, []byte(g1SignatureDST))
+	h.ScalarMult(c.sk, h)
+	return h.BytesCompressed()
+}
+
+// entry returns the json of the round in the drand http api
+func (c *testChain) entry(round uint64, previous []byte) string {
+	signature := c.sign(round, previous)
+	randomness := sha256.Sum256(signature)
+
+	return fmt.Sprintf(`{"round":%d,"randomness":"%s","signature":"%s","previous_signature":"%s"}`,
+		round, hex.EncodeToString(randomness[:]), hex.EncodeToString(signature), hex.EncodeToString(previous))
+}
+
+func TestHTTPBeacon(t *testing.T) {
+	for _, onG2 := range []bool{true, false} {
+		chain := newTestChain(t, onG2)
+		forger := newTestChain(t, onG2)
+
+		var previous []byte
+		if onG2 {
+			previous = chain.sign(99, nil)
+		}
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/public/latest", "/public/100":
+				fmt.Fprint(w, chain.entry(100, previous))
+			case "/public/101":
+				// the signature does not match the randomness
+				fmt.Fprintf(w, `{"round":101,"randomness":"%s","signature":"00"}`, hex.EncodeToString(make([]byte, 32)))
+			case "/public/102":
+				// the entry is signed by another key
+				fmt.Fprint(w, forger.entry(102, previous))
+			case "/public/103":
+				// the signature of another round
+				fmt.Fprint(w, strings.Replace(chain.entry(100, previous), `"round":100`, `"round":103`, 1))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+
+		beacon, err := NewHTTPBeacon(srv.URL+"/", chain.publicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		latest, err := beacon.Latest(context.Background())
+		if err != nil {
+			t.Fatalf("g2 %v latest: %s", onG2, err.Error())
+		}
+
+		randomness := sha256.Sum256(chain.sign(100, previous))
+		if latest.Round != 100 || !bytes.Equal(latest.Randomness, randomness[:]) {
+			t.Errorf("unexpected entry %d %x", latest.Round, latest.Randomness)
+		}
+
+		if _, err := beacon.Entry(context.Background(), 100); err != nil {
+			t.Errorf("entry 100: %s", err.Error())
+		}
+
+		for _, round := range []uint64{101, 102, 103, 104} {
+			if _, err := beacon.Entry(context.Background(), round); err == nil {
+				t.Errorf("g2 %v expect error of round %d", onG2, round)
+			}
+		}
+
+		srv.Close()
+	}
+}
+
+func TestNewRandomnessBeacon(t *testing.T) {
+	if _, err := NewRandomnessBeacon("https://api.drand.sh", "", ""); err == nil {
+		t.Error("expect error without the chain public key")
+	}
+
+	if _, err := NewRandomnessBeacon("https://api.drand.sh", "00", ""); err == nil {
+		t.Error("expect error of the invalid chain public key")
+	}
+
+	// the local beacons without a secret are not predictable
+	b1, err := NewRandomnessBeacon("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := NewRandomnessBeacon("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e1, _ := b1.Entry(context.Background(), 1)
+	e2, _ := b2.Entry(context.Background(), 1)
+	if bytes.Equal(e1.Randomness, e2.Randomness) {
+		t.Error("expect different randomness of the random secrets")
+	}
+}
@@ -1,14 +1,36 @@
 package validation
 
 import (
+	"database/sql"
+	"encoding/json"
 	"math"
 	"math/rand"
 	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"golang.org/x/xerrors"
 )
 
 var (
 	firstElectionInterval = 5 * time.Minute    // Time of the first election
 	electionCycle         = 5 * 24 * time.Hour // Length of the election cycle
+	minValidatorTenure    = 24 * time.Hour     // Validators are not replaced before the tenure, unless they are no longer eligible
+	minElectionInterval   = 10 * time.Minute   // Manual elections can not be triggered more frequently
+)
+
+const (
+	minValidatorReputation = 40.0   // Candidates with a lower reputation can not be validators
+	spreadDistance         = 500.0  // Km, validators closer than this reduce the weight of each other
+	minElectionWeight      = 0.0001 // Every eligible candidate has a chance to be elected
+	earthRadius            = 6371.0 // Km
+)
+
+// The reasons of the elections
+const (
+	electionReasonFirst   = "first"
+	electionReasonCycle   = "cycle"
+	electionReasonManual  = "manual"
+	electionReasonPenalty = "penalty"
 )
 
 // triggers the election process at a regular interval.
@@ -20,16 +42,18 @@ func (m *Manager) startElectionTicker() {
 	}
 
 	expiration := electionCycle
+	reason := electionReasonCycle
 	if len(validators) <= 0 {
 		expiration = firstElectionInterval
+		reason = electionReasonFirst
 	}
 
 	ticker := time.NewTicker(expiration)
 	defer ticker.Stop()
 
-	doElect := func() {
+	doElect := func(reason string) {
 		ticker.Reset(electionCycle)
-		err := m.elect()
+		err := m.elect(reason)
 		if err != nil {
 			log.Errorf("elect err:%s", err.Error())
 		}
@@ -38,27 +62,94 @@ func (m *Manager) startElectionTicker() {
 	for {
 		select {
 		case <-ticker.C:
-			doElect()
-		case <-m.updateCh:
-			doElect()
+			doElect(reason)
+			reason = electionReasonCycle
+		case r := <-m.updateCh:
+			doElect(r)
 		}
 	}
 }
 
-// elect triggers an election and updates the list of validators.
-func (m *Manager) elect() error {
-	log.Debugln("start elect ")
-	validators := m.electValidators()
+// elect triggers an election, updates the list of validators and saves the election record.
+func (m *Manager) elect(reason string) error {
+	log.Debugf("start elect, reason: %s", reason)
+
+	m.electionLock.Lock()
+	m.lastElectionTime = time.Now()
+	m.electionLock.Unlock()
+
+	seed := time.Now().UnixNano()
+	validators, candidates := m.electValidators(seed)
 
 	m.ResetValidatorGroup(validators)
 
-	return m.nodeMgr.UpdateValidators(validators, m.nodeMgr.ServerID)
+	if err := m.nodeMgr.UpdateValidators(validators, m.nodeMgr.ServerID); err != nil {
+		return err
+	}
+
+	return m.saveElectionRecord(reason, seed, candidates, validators)
+}
+
+// saveElectionRecord saves the inputs and the winners of the election for audit
+func (m *Manager) saveElectionRecord(reason string, seed int64, candidates []*types.ElectionCandidate, validators []string) error {
+	cBuf, err := json.Marshal(candidates)
+	if err != nil {
+		return err
+	}
+
+	vBuf, err := json.Marshal(validators)
+	if err != nil {
+		return err
+	}
+
+	record := &types.ElectionRecord{
+		SchedulerID: string(m.nodeMgr.ServerID),
+		Reason:      reason,
+		Seed:        seed,
+		Candidates:  string(cBuf),
+		Validators:  string(vBuf),
+	}
+
+	return m.nodeMgr.SaveElectionRecord(record)
 }
 
-// StartElection triggers an election manually.
-func (m *Manager) StartElection() {
-	// TODO need to add restrictions to disallow frequent calls?
-	m.updateCh <- struct{}{}
+// restoreElectionTime restores the time of the last election from the election records,
+// so the rate limit of the manual elections is kept after the scheduler restarts
+func (m *Manager) restoreElectionTime() {
+	last, err := m.nodeMgr.LoadLatestElectionTime(m.nodeMgr.ServerID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Errorf("LoadLatestElectionTime err:%s", err.Error())
+		}
+		return
+	}
+
+	m.electionLock.Lock()
+	m.lastElectionTime = last
+	m.electionLock.Unlock()
+}
+
+// StartElection triggers an election manually, it returns an error if the last election is too recent.
+func (m *Manager) StartElection() error {
+	m.electionLock.Lock()
+	last := m.lastElectionTime
+	m.electionLock.Unlock()
+
+	if wait := minElectionInterval - time.Since(last); wait > 0 {
+		return xerrors.Errorf("the last election was at %s, try again in %s", last.Format("2006-01-02 15:04:05"), wait.Truncate(time.Second))
+	}
+
+	select {
+	case m.updateCh <- electionReasonManual:
+		return nil
+	default:
+		return xerrors.New("an election is pending")
+	}
+}
+
+// GetElectionRecords returns the election records of the scheduler
+func (m *Manager) GetElectionRecords(cursor, count int) (*types.ListElectionRecordsRsp, error) {
+	return m.nodeMgr.LoadElectionRecords(m.nodeMgr.ServerID, cursor, count)
 }
 
 // returns the ratio of validators that should be elected, based on the scheduler configuration.
@@ -72,27 +163,143 @@ func (m *Manager) getValidatorRatio() float64 {
 	return cfg.ValidatorRatio
 }
 
-// performs the election process and returns the list of elected validators.
-func (m *Manager) electValidators() []string {
+// electValidators performs the election process and returns the list of elected validators and the eligible candidates.
+// The validators in their minimum tenure are retained, the other seats are drawn by the weight of
+// the bandwidth down and the reputation, the weight is reduced by the validators nearby to spread the validators.
+func (m *Manager) electValidators(seed int64) ([]string, []*types.ElectionCandidate) {
 	ratio := m.getValidatorRatio()
 
 	list := m.nodeMgr.GetAllCandidateNodes()
 
 	needValidatorCount := int(math.Ceil(float64(len(list)) * ratio))
 	if needValidatorCount <= 0 {
-		return nil
+		return nil, nil
+	}
+
+	// the candidates which return wrong reference blocks repeatedly or have a low reputation can not be validators
+	offenders, err := m.loadOffenders()
+	if err != nil {
+		log.Errorf("loadOffenders err:%s", err.Error())
+	}
+
+	electedTimes := make(map[string]time.Time)
+	infos, err := m.nodeMgr.LoadValidatorInfos(m.nodeMgr.ServerID)
+	if err != nil {
+		log.Errorf("LoadValidatorInfos err:%s", err.Error())
+	}
+	for _, info := range infos {
+		electedTimes[info.NodeID] = info.ElectedTime
+	}
+
+	candidates := make([]*types.ElectionCandidate, 0, len(list))
+	maxBandwidth := 0.0
+	for _, nodeID := range list {
+		if _, ok := offenders[nodeID]; ok {
+			continue
+		}
+
+		node := m.nodeMgr.GetCandidateNode(nodeID)
+		if node == nil || !node.IsSchedulable() || node.GetReputation() < minValidatorReputation {
+			continue
+		}
+
+		c := &types.ElectionCandidate{
+			NodeID:        nodeID,
+			BandwidthDown: node.BandwidthDown,
+			Reputation:    node.GetReputation(),
+			Latitude:      node.Latitude,
+			Longitude:     node.Longitude,
+		}
+
+		if electedTime, ok := electedTimes[nodeID]; ok && time.Since(electedTime) < minValidatorTenure {
+			c.Retained = true
+		}
+
+		maxBandwidth = math.Max(maxBandwidth, node.BandwidthDown)
+		candidates = append(candidates, c)
+	}
+
+	for _, c := range candidates {
+		bandwidth := 1.0
+		if maxBandwidth > 0 {
+			bandwidth = c.BandwidthDown / maxBandwidth
+		}
+		c.Weight = math.Max(bandwidth*c.Reputation/100, minElectionWeight)
+	}
+
+	if needValidatorCount > len(candidates) {
+		needValidatorCount = len(candidates)
+	}
+
+	validators := make([]*types.ElectionCandidate, 0, needValidatorCount)
+	pool := make([]*types.ElectionCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Retained && len(validators) < needValidatorCount {
+			validators = append(validators, c)
+			continue
+		}
+		c.Retained = false
+		pool = append(pool, c)
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	for len(validators) < needValidatorCount && len(pool) > 0 {
+		i := drawCandidate(r, pool, validators)
+		validators = append(validators, pool[i])
+		pool = append(pool[:i], pool[i+1:]...)
+	}
+
+	out := make([]string, 0, len(validators))
+	for _, v := range validators {
+		out = append(out, v.NodeID)
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(list), func(i, j int) {
-		list[i], list[j] = list[j], list[i]
-	})
+	return out, candidates
+}
 
-	if needValidatorCount > len(list) {
-		needValidatorCount = len(list)
+// drawCandidate draws a candidate from the pool by the weight, reduced by the elected validators nearby
+func drawCandidate(r *rand.Rand, pool, elected []*types.ElectionCandidate) int {
+	weights := make([]float64, len(pool))
+	total := 0.0
+	for i, c := range pool {
+		nearby := 0
+		for _, v := range elected {
+			if isNearby(c, v) {
+				nearby++
+			}
+		}
+
+		weights[i] = c.Weight / float64(1+nearby)
+		total += weights[i]
 	}
 
-	list = list[:needValidatorCount]
+	n := r.Float64() * total
+	for i, w := range weights {
+		n -= w
+		if n < 0 {
+			return i
+		}
+	}
+
+	return len(pool) - 1
+}
+
+// isNearby returns whether the two candidates are within the spread distance, the candidates without location are not nearby
+func isNearby(a, b *types.ElectionCandidate) bool {
+	if (a.Latitude == 0 && a.Longitude == 0) || (b.Latitude == 0 && b.Longitude == 0) {
+		return false
+	}
+
+	return distance(a.Latitude, a.Longitude, b.Latitude, b.Longitude) < spreadDistance
+}
+
+// distance returns the great-circle distance in km between the two locations
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
 
-	return list
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
 }
@@ -3,6 +3,7 @@ package validation
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/Filecoin-Titan/titan/node/modules/dtypes"
@@ -58,22 +59,34 @@ type Manager struct {
 	validatableGroups  []*ValidatableGroup // Each VWindow has a ValidatableGroup
 	unpairedGroup      *ValidatableGroup   // Save unpaired Validatable nodes
 
-	seed       int64
-	curRoundID string
-	close      chan struct{}
-	config     dtypes.GetSchedulerConfigFunc
+	roundLock   sync.RWMutex
+	seed        int64
+	beaconRound uint64 // The beacon round of the current seed
+	curRoundID  string
+	curPairings roundPairings // The validatable nodes of each validator in the current round
+	beacon      RandomnessBeacon
+	close       chan struct{}
+	config      dtypes.GetSchedulerConfigFunc
 
-	updateCh chan struct{}
+	updateCh chan string // The reason of the election triggered before the cycle
+
+	electionLock     sync.Mutex
+	lastElectionTime time.Time
+
+	scheduleLock sync.Mutex
+	schedules    map[string]*nodeSchedule // The validation schedules of the nodes
 }
 
 // NewManager return new node manager instance
-func NewManager(nodeMgr *node.Manager, configFunc dtypes.GetSchedulerConfigFunc, p *pubsub.PubSub) *Manager {
+func NewManager(nodeMgr *node.Manager, configFunc dtypes.GetSchedulerConfigFunc, p *pubsub.PubSub, beacon RandomnessBeacon) *Manager {
 	nodeManager := &Manager{
 		nodeMgr:       nodeMgr,
+		beacon:        beacon,
 		config:        configFunc,
 		close:         make(chan struct{}),
 		unpairedGroup: newValidatableGroup(),
-		updateCh:      make(chan struct{}, 1),
+		updateCh:      make(chan string, 1),
+		schedules:     make(map[string]*nodeSchedule),
 		notify:        p,
 	}
 
@@ -82,7 +95,11 @@ func NewManager(nodeMgr *node.Manager, configFunc dtypes.GetSchedulerConfigFunc,
 
 // Start start validate and elect task
 func (m *Manager) Start(ctx context.Context) {
+	m.restoreRound()
+	m.restoreElectionTime()
+
 	go m.startValidationTicker(ctx)
+	go m.startStorageProofTicker()
 	go m.startElectionTicker()
 
 	m.subscribe()
@@ -131,13 +148,16 @@ func (m *Manager) onNodeStateChange(node *node.Node, isOnline bool) {
 	if isOnline {
 		if isV {
 			m.addValidator(nodeID, node.BandwidthDown)
-		} else {
+		} else if node.Type == types.NodeEdge {
+			// the candidates are validated in the candidate track, see pairCandidates
 			m.addValidatableNode(nodeID, node.BandwidthDown)
 		}
 
 		return
 	}
 
+	m.removeSchedule(nodeID)
+
 	if isV {
 		m.removeValidator(nodeID)
 	} else {
@@ -291,6 +291,68 @@ func (m *Manager) PairValidatorsAndValidatableNodes() []*VWindow {
 	return m.vWindows
 }
 
+// pairCandidates pairs every online candidate with a validator other than itself.
+// The candidates are validated every round, because their replicas are the reference blocks of the validations.
+func (m *Manager) pairCandidates() []*VWindow {
+	validators := m.getValidators()
+	if len(validators) == 0 {
+		return nil
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	candidates := m.nodeMgr.GetAllCandidateNodes()
+	r.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	exclude := m.getValidationExcludeSelector()
+	windows := make(map[string]*VWindow)
+	out := make([]*VWindow, 0)
+	offset := r.Intn(len(validators))
+	for i, nodeID := range candidates {
+		node := m.nodeMgr.GetCandidateNode(nodeID)
+		if node == nil || !node.IsSchedulable() || isValidationExcluded(node.GetLabels(), exclude) {
+			continue
+		}
+
+		vID := validators[(offset+i)%len(validators)]
+		if vID == nodeID {
+			if len(validators) == 1 {
+				continue
+			}
+			vID = validators[(offset+i+1)%len(validators)]
+		}
+
+		w, ok := windows[vID]
+		if !ok {
+			w = newVWindow(vID)
+			windows[vID] = w
+			out = append(out, w)
+		}
+		w.ValidatableNodes[nodeID] = node.BandwidthUp
+	}
+
+	return out
+}
+
+// getValidators returns the validators which have validator windows
+func (m *Manager) getValidators() []string {
+	m.validationPairLock.RLock()
+	defer m.validationPairLock.RUnlock()
+
+	validators := make([]string, 0)
+	exist := make(map[string]struct{})
+	for _, v := range m.vWindows {
+		if _, ok := exist[v.NodeID]; ok {
+			continue
+		}
+		exist[v.NodeID] = struct{}{}
+		validators = append(validators, v.NodeID)
+	}
+
+	return validators
+}
+
 func (m *Manager) getValidatorBaseBwDn() float64 {
 	cfg, err := m.config()
 	if err != nil {
@@ -0,0 +1,146 @@
+package validation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"golang.org/x/xerrors"
+)
+
+// roundPairings are the validatable nodes of each validator in a round
+type roundPairings map[string][]string
+
+// isPaired checks whether the node is validated by the validator in the round
+func (p roundPairings) isPaired(validatorID, nodeID string) bool {
+	for _, id := range p[validatorID] {
+		if id == nodeID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodePairings parses the pairings persisted with the round
+func decodePairings(s string) (roundPairings, error) {
+	pairings := make(roundPairings)
+	if s == "" {
+		return pairings, nil
+	}
+
+	if err := json.Unmarshal([]byte(s), &pairings); err != nil {
+		return nil, xerrors.Errorf("decode pairings: %w", err)
+	}
+
+	return pairings, nil
+}
+
+// encodePairings returns the json of the validatable nodes of each validator,
+// a validator may have several windows, the nodes of all its windows are paired with it
+func encodePairings(vrs []*VWindow) (string, error) {
+	pairings := make(roundPairings, len(vrs))
+	for _, vr := range vrs {
+		for nodeID := range vr.ValidatableNodes {
+			pairings[vr.NodeID] = append(pairings[vr.NodeID], nodeID)
+		}
+	}
+
+	buf, err := json.Marshal(pairings)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// saveRound persists the state of the round, so the results can be handled after the scheduler restarts
+func (m *Manager) saveRound(round *types.ValidationRoundInfo, vrs []*VWindow) error {
+	pairings, err := encodePairings(vrs)
+	if err != nil {
+		return err
+	}
+
+	round.Pairings = pairings
+	return m.nodeMgr.SaveValidationRound(round)
+}
+
+// restoreRound resumes the latest round if it is still in the round interval, the other unfinished rounds are timed out
+func (m *Manager) restoreRound() {
+	rounds, err := m.nodeMgr.LoadUnfinishedValidationRounds(m.nodeMgr.ServerID)
+	if err != nil {
+		log.Errorf("LoadUnfinishedValidationRounds err:%s", err.Error())
+		return
+	}
+
+	for i, round := range rounds {
+		if i == 0 && time.Since(round.StartTime) < m.getRoundInterval() {
+			m.setCurrentRound(round)
+			log.Infof("resume validation round %s started at %s", round.RoundID, round.StartTime)
+			continue
+		}
+
+		if err := m.nodeMgr.EndValidationRound(round.RoundID); err != nil {
+			log.Errorf("EndValidationRound %s err:%s", round.RoundID, err.Error())
+		}
+	}
+}
+
+// setCurrentRound sets the round which the new results belong to, the pairings are restored from the round
+func (m *Manager) setCurrentRound(round *types.ValidationRoundInfo) {
+	pairings, err := decodePairings(round.Pairings)
+	if err != nil {
+		log.Errorf("round %s err:%s", round.RoundID, err.Error())
+	}
+
+	m.roundLock.Lock()
+	defer m.roundLock.Unlock()
+
+	m.curRoundID = round.RoundID
+	m.seed = round.Seed
+	m.beaconRound = round.BeaconRound
+	m.curPairings = pairings
+}
+
+// currentRoundID returns the id of the current round
+func (m *Manager) currentRoundID() string {
+	m.roundLock.RLock()
+	defer m.roundLock.RUnlock()
+
+	return m.curRoundID
+}
+
+// getRoundSeed returns the seed of the round if the node is validated by the validator in the round,
+// the results of an ended round are not accepted
+func (m *Manager) getRoundSeed(roundID, validatorID, nodeID string) (int64, error) {
+	m.roundLock.RLock()
+	curRoundID, seed, pairings := m.curRoundID, m.seed, m.curPairings
+	m.roundLock.RUnlock()
+
+	if roundID != curRoundID {
+		round, err := m.nodeMgr.LoadValidationRound(roundID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return 0, xerrors.Errorf("round %s not found", roundID)
+			}
+			return 0, err
+		}
+
+		if round.Ended {
+			return 0, xerrors.Errorf("round %s is ended", roundID)
+		}
+
+		seed = round.Seed
+		pairings, err = decodePairings(round.Pairings)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if !pairings.isPaired(validatorID, nodeID) {
+		return 0, xerrors.Errorf("node %s is not validated by %s in round %s", nodeID, validatorID, roundID)
+	}
+
+	return seed, nil
+}
@@ -0,0 +1,72 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+)
+
+func TestCurrentRoundPairings(t *testing.T) {
+	m := &Manager{}
+
+	vr := newVWindow("validator")
+	vr.ValidatableNodes["node"] = 1
+	round := &types.ValidationRoundInfo{RoundID: "round", Seed: 7}
+
+	pairings, err := encodePairings([]*VWindow{vr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	round.Pairings = pairings
+
+	// the round is restored with its pairings
+	m.setCurrentRound(round)
+
+	seed, err := m.getRoundSeed("round", "validator", "node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed != 7 {
+		t.Errorf("expect seed 7, got %d", seed)
+	}
+
+	if _, err := m.getRoundSeed("round", "other", "node"); err == nil {
+		t.Error("expect an error for the node not paired with the validator")
+	}
+	if _, err := m.getRoundSeed("round", "validator", "other"); err == nil {
+		t.Error("expect an error for the node not in the round")
+	}
+}
+
+func TestPairingsOfSeveralWindows(t *testing.T) {
+	m := &Manager{}
+
+	// the validator has two bandwidth windows and a candidate window
+	first := newVWindow("validator")
+	first.ValidatableNodes["edge1"] = 1
+	second := newVWindow("validator")
+	second.ValidatableNodes["edge2"] = 1
+	candidate := newVWindow("validator")
+	candidate.ValidatableNodes["candidate"] = 1
+	other := newVWindow("other")
+	other.ValidatableNodes["edge3"] = 1
+
+	pairings, err := encodePairings([]*VWindow{first, second, candidate, other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.setCurrentRound(&types.ValidationRoundInfo{RoundID: "round", Seed: 7, Pairings: pairings})
+
+	for _, nodeID := range []string{"edge1", "edge2", "candidate"} {
+		if _, err := m.getRoundSeed("round", "validator", nodeID); err != nil {
+			t.Errorf("expect %s paired with the validator, got %s", nodeID, err.Error())
+		}
+	}
+
+	if _, err := m.getRoundSeed("round", "validator", "edge3"); err == nil {
+		t.Error("expect an error for the node paired with the other validator")
+	}
+	if _, err := m.getRoundSeed("round", "other", "edge3"); err != nil {
+		t.Errorf("expect edge3 paired with the other validator, got %s", err.Error())
+	}
+}
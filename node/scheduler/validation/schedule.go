@@ -0,0 +1,234 @@
+package validation
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultValidationInterval   = 30 * time.Minute // Used if ValidationInterval of the config is not set
+	defaultValidationDuration   = 10               // Used if ValidationDuration of the config is not set (Unit:Second)
+	defaultStorageProofInterval = 5 * time.Minute  // Used if StorageProofInterval of the config is not set
+
+	suspiciousIntervalRatio = 0.25 // Suspicious nodes are validated 4 times as often, it is also the interval of the rounds
+	trustedIntervalRatio    = 2    // Trusted nodes are validated half as often
+	suspiciousReputation    = 30.0 // Nodes with a lower reputation are suspicious
+	trustedReputation       = 80.0 // Nodes with a higher reputation and no recent failure are trusted
+)
+
+// nodeSchedule is the validation schedule of a node
+type nodeSchedule struct {
+	next     time.Time // The node is not validated before
+	failures int       // The failures since the last success
+}
+
+// getValidationInterval returns the interval of the bandwidth validation of a node with a normal reputation
+func (m *Manager) getValidationInterval() time.Duration {
+	cfg, err := m.config()
+	if err != nil || cfg.ValidationInterval <= 0 {
+		return defaultValidationInterval
+	}
+
+	return time.Duration(cfg.ValidationInterval)
+}
+
+// getValidationDuration returns the duration of a bandwidth validation (Unit:Second)
+func (m *Manager) getValidationDuration() int {
+	cfg, err := m.config()
+	if err != nil || cfg.ValidationDuration <= 0 {
+		return defaultValidationDuration
+	}
+
+	return cfg.ValidationDuration
+}
+
+// getStorageProofInterval returns the interval of the storage proofs
+func (m *Manager) getStorageProofInterval() time.Duration {
+	cfg, err := m.config()
+	if err != nil || cfg.StorageProofInterval <= 0 {
+		return defaultStorageProofInterval
+	}
+
+	return time.Duration(cfg.StorageProofInterval)
+}
+
+// getValidationExcludeSelector returns the label selector of the nodes which are not validated, it is nil if no node is excluded
+func (m *Manager) getValidationExcludeSelector() types.LabelSelector {
+	cfg, err := m.config()
+	if err != nil || cfg.ValidationExcludeSelector == "" {
+		return nil
+	}
+
+	selector, err := types.ParseLabelSelector(cfg.ValidationExcludeSelector)
+	if err != nil {
+		log.Errorf("parse validation exclude selector err:%s", err.Error())
+		return nil
+	}
+
+	return selector
+}
+
+// isValidationExcluded returns whether the node with the labels is excluded from the validations by the selector
+func isValidationExcluded(labels map[string]string, exclude types.LabelSelector) bool {
+	return len(exclude) > 0 && exclude.Matches(labels)
+}
+
+// getRoundInterval returns the interval of the validation rounds, it is the interval of the suspicious nodes
+func (m *Manager) getRoundInterval() time.Duration {
+	return time.Duration(float64(m.getValidationInterval()) * suspiciousIntervalRatio)
+}
+
+// isValidationDue returns whether the node should be validated in the round
+func (m *Manager) isValidationDue(nodeID string, now time.Time) bool {
+	m.scheduleLock.Lock()
+	defer m.scheduleLock.Unlock()
+
+	s, ok := m.schedules[nodeID]
+	return !ok || !now.Before(s.next)
+}
+
+// scheduleNextValidation schedules the next validation of the node by its reputation and recent failures
+func (m *Manager) scheduleNextValidation(nodeID string, now time.Time) {
+	m.scheduleLock.Lock()
+	defer m.scheduleLock.Unlock()
+
+	s, ok := m.schedules[nodeID]
+	if !ok {
+		s = &nodeSchedule{}
+		m.schedules[nodeID] = s
+	}
+
+	reputation := float64(0)
+	if node := m.nodeMgr.GetNode(nodeID); node != nil {
+		reputation = node.GetReputation()
+	}
+
+	// the rounds are not aligned to the node, the next validation is a little earlier to be in time for the round
+	s.next = now.Add(m.nodeValidationInterval(reputation, s.failures) - m.getRoundInterval()/2)
+}
+
+// nodeValidationInterval returns the interval of the validations of a node with the reputation and the failures since the last success
+func (m *Manager) nodeValidationInterval(reputation float64, failures int) time.Duration {
+	interval := float64(m.getValidationInterval())
+	switch {
+	case failures > 0 || reputation < suspiciousReputation:
+		interval *= suspiciousIntervalRatio
+	case reputation >= trustedReputation:
+		interval *= trustedIntervalRatio
+	}
+
+	return time.Duration(interval)
+}
+
+// updateSchedule validates the node in the next round if it fails the validation
+func (m *Manager) updateSchedule(nodeID string, status types.ValidationStatus) {
+	m.scheduleLock.Lock()
+	defer m.scheduleLock.Unlock()
+
+	s, ok := m.schedules[nodeID]
+	if !ok {
+		return
+	}
+
+	switch status {
+	case types.ValidationStatusSuccess:
+		s.failures = 0
+	case types.ValidationStatusValidateFail, types.ValidationStatusNodeTimeOut:
+		s.failures++
+		s.next = time.Now()
+	}
+}
+
+// removeSchedule removes the schedule of the offline node
+func (m *Manager) removeSchedule(nodeID string) {
+	m.scheduleLock.Lock()
+	defer m.scheduleLock.Unlock()
+
+	delete(m.schedules, nodeID)
+}
+
+// ValidateNode validates the node by a validator immediately, it returns the id of the round
+func (m *Manager) ValidateNode(ctx context.Context, nodeID string) (string, error) {
+	node := m.nodeMgr.GetNode(nodeID)
+	if node == nil {
+		return "", xerrors.Errorf("node %s not online", nodeID)
+	}
+
+	if !node.IsSchedulable() {
+		return "", xerrors.Errorf("node %s is %s", nodeID, node.GetState().String())
+	}
+
+	if isValidationExcluded(node.GetLabels(), m.getValidationExcludeSelector()) {
+		return "", xerrors.Errorf("node %s is excluded from the validations by its labels", nodeID)
+	}
+
+	validators := make([]string, 0)
+	for _, vID := range m.getValidators() {
+		if vID != nodeID && m.nodeMgr.GetCandidateNode(vID) != nil {
+			validators = append(validators, vID)
+		}
+	}
+
+	if len(validators) == 0 {
+		return "", xerrors.New("no validator is online")
+	}
+
+	round, err := m.newRound(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	vr := newVWindow(validators[rand.Intn(len(validators))])
+	vr.ValidatableNodes[nodeID] = node.BandwidthUp
+	vrs := []*VWindow{vr}
+
+	vReqs, dbInfos := m.getValidationDetails(round, vrs, false)
+	if len(vReqs) == 0 {
+		return "", xerrors.Errorf("node %s has no replica to validate", nodeID)
+	}
+
+	if err := m.saveRound(round, vrs); err != nil {
+		return "", err
+	}
+
+	if err := m.nodeMgr.SaveValidationResultInfos(dbInfos); err != nil {
+		return "", err
+	}
+
+	// the round is ended as a regular round, the results which are not received are timed out
+	time.AfterFunc(m.getRoundInterval(), func() {
+		if err := m.nodeMgr.EndValidationRound(round.RoundID); err != nil {
+			log.Errorf("EndValidationRound %s err:%s", round.RoundID, err.Error())
+		}
+	})
+
+	for nID, req := range vReqs {
+		go m.sendValidateReqToNodes(nID, req)
+	}
+
+	return round.RoundID, nil
+}
+
+// newRound returns a new round, the seed is derived from the latest beacon entry
+func (m *Manager) newRound(ctx context.Context) (*types.ValidationRoundInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, beaconTimeout)
+	defer cancel()
+
+	entry, err := m.beacon.Latest(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("get beacon entry err:%s", err.Error())
+	}
+
+	return &types.ValidationRoundInfo{
+		RoundID:     uuid.NewString(),
+		SchedulerID: string(m.nodeMgr.ServerID),
+		Seed:        DeriveSeed(entry, string(m.nodeMgr.ServerID)),
+		BeaconRound: entry.Round,
+		StartTime:   time.Now(),
+	}, nil
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/config"
+	"github.com/Filecoin-Titan/titan/node/scheduler/node"
+)
+
+func newScheduleManager() *Manager {
+	return &Manager{
+		nodeMgr:   &node.Manager{},
+		schedules: make(map[string]*nodeSchedule),
+		config: func() (config.SchedulerCfg, error) {
+			return config.SchedulerCfg{ValidationInterval: config.Duration(40 * time.Minute)}, nil
+		},
+	}
+}
+
+func TestNodeValidationInterval(t *testing.T) {
+	m := newScheduleManager()
+
+	tests := []struct {
+		name       string
+		reputation float64
+		failures   int
+		expect     time.Duration
+	}{
+		{"suspicious", suspiciousReputation - 1, 0, 10 * time.Minute},
+		{"normal", suspiciousReputation, 0, 40 * time.Minute},
+		{"trusted", trustedReputation, 0, 80 * time.Minute},
+		{"trusted with failures", trustedReputation, 1, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := m.nodeValidationInterval(tt.reputation, tt.failures); got != tt.expect {
+			t.Errorf("%s: expect %s, got %s", tt.name, tt.expect, got)
+		}
+	}
+
+	if got := m.getRoundInterval(); got != 10*time.Minute {
+		t.Errorf("expect round interval 10m, got %s", got)
+	}
+}
+
+func TestScheduleNextValidation(t *testing.T) {
+	m := newScheduleManager()
+	now := time.Now()
+
+	if !m.isValidationDue("node", now) {
+		t.Error("expect a node without schedule to be due")
+	}
+
+	// the offline node has no reputation, it is validated as a suspicious node, half a round earlier
+	m.scheduleNextValidation("node", now)
+	if next := m.schedules["node"].next; !next.Equal(now.Add(5 * time.Minute)) {
+		t.Errorf("expect next validation at %s, got %s", now.Add(5*time.Minute), next)
+	}
+	if m.isValidationDue("node", now) {
+		t.Error("expect the node not due before the next validation")
+	}
+	if !m.isValidationDue("node", now.Add(5*time.Minute)) {
+		t.Error("expect the node due at the next validation")
+	}
+
+	// a failed node is validated in the next round
+	m.updateSchedule("node", types.ValidationStatusNodeTimeOut)
+	if s := m.schedules["node"]; s.failures != 1 || !m.isValidationDue("node", time.Now()) {
+		t.Errorf("expect the failed node due, failures %d", s.failures)
+	}
+
+	m.updateSchedule("node", types.ValidationStatusSuccess)
+	if s := m.schedules["node"]; s.failures != 0 {
+		t.Errorf("expect the failures reset, got %d", s.failures)
+	}
+
+	m.removeSchedule("node")
+	if _, ok := m.schedules["node"]; ok {
+		t.Error("expect the schedule removed")
+	}
+}
@@ -0,0 +1,262 @@
+package validation
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/Filecoin-Titan/titan/node/cidutil"
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/google/uuid"
+)
+
+const (
+	storageProofBlocks      = 10               // Number of the random blocks of a storage proof
+	storageProofNonceSize   = 16               // Size of the nonce which is appended to the block data
+	storageProofConcurrency = 20               // Number of the nodes which are challenged at the same time
+	storageProofTimeout     = 30 * time.Second // Timeout of the proof of a node
+)
+
+// startStorageProofTicker challenges the online nodes to prove their storage periodically,
+// the storage proofs are cheaper than the bandwidth validations, so they run more often
+func (m *Manager) startStorageProofTicker() {
+	timer := time.NewTimer(m.getStorageProofInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			timer.Reset(m.getStorageProofInterval())
+
+			if enable := m.isEnabled(); !enable {
+				continue
+			}
+
+			m.proveStorage()
+		case <-m.close:
+			return
+		}
+	}
+}
+
+// proveStorage challenges every online node with a random asset it stores, and saves the results
+func (m *Manager) proveStorage() {
+	ctx, cancel := context.WithTimeout(context.Background(), beaconTimeout)
+	defer cancel()
+
+	// the challenged blocks are derived from the beacon, so they can be re-derived from the saved beacon round
+	entry, err := m.beacon.Latest(ctx)
+	if err != nil {
+		log.Errorf("get beacon entry err:%s", err.Error())
+		return
+	}
+
+	roundID := uuid.NewString()
+	nodeIDs := append(m.nodeMgr.GetAllEdgeNodes(), m.nodeMgr.GetAllCandidateNodes()...)
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	infos := make([]*types.ValidationResultInfo, 0, len(nodeIDs))
+	limit := make(chan struct{}, storageProofConcurrency)
+
+	for _, nodeID := range nodeIDs {
+		limit <- struct{}{}
+		wg.Add(1)
+
+		go func(nodeID string) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+
+			info := m.proveNodeStorage(roundID, nodeID, entry)
+			if info == nil {
+				return
+			}
+
+			lock.Lock()
+			infos = append(infos, info)
+			lock.Unlock()
+		}(nodeID)
+	}
+	wg.Wait()
+
+	if len(infos) == 0 {
+		return
+	}
+
+	if err := m.nodeMgr.SaveStorageProofResults(infos); err != nil {
+		log.Errorf("SaveStorageProofResults err:%s", err.Error())
+		return
+	}
+
+	for _, info := range infos {
+		m.publishValidationResult(info.NodeID, info.Cid, info.Status, info.Type)
+
+		if info.Status == types.ValidationStatusCandidateBlockErr && info.CandidateID != "" {
+			m.penalizeCandidate(info.CandidateID)
+		}
+	}
+}
+
+// proveNodeStorage challenges the node with a random asset and verifies the proof against a candidate,
+// it returns nil if the node has no replica
+func (m *Manager) proveNodeStorage(roundID, nodeID string, entry *BeaconEntry) *types.ValidationResultInfo {
+	cNode := m.nodeMgr.GetNode(nodeID)
+	if cNode == nil || !cNode.IsSchedulable() || isValidationExcluded(cNode.GetLabels(), m.getValidationExcludeSelector()) {
+		return nil
+	}
+
+	cid, err := m.getNodeValidationCID(nodeID)
+	if err != nil {
+		log.Debugf("%s getNodeValidationCID err:%s", nodeID, err.Error())
+		return nil
+	}
+
+	info := &types.ValidationResultInfo{
+		RoundID:     roundID,
+		NodeID:      nodeID,
+		Cid:         cid,
+		Type:        types.ValidationTypeStorage,
+		BeaconRound: entry.Round,
+		StartTime:   time.Now(),
+	}
+
+	nonce := make([]byte, storageProofNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		log.Errorf("generate nonce err:%s", err.Error())
+		return nil
+	}
+
+	challenge := newStorageChallenge(entry, string(m.nodeMgr.ServerID), nodeID, cid, nonce)
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageProofTimeout)
+	defer cancel()
+
+	proof, err := cNode.GetStorageProof(ctx, challenge)
+	if err != nil {
+		log.Errorf("%s GetStorageProof err:%s", nodeID, err.Error())
+		info.Status = types.ValidationStatusValidateFail
+		if isUnreachable(err) {
+			info.Status = types.ValidationStatusNodeTimeOut
+		}
+		return info
+	}
+	info.BlockNumber = int64(len(proof))
+
+	hash, err := cidutil.CIDToHash(cid)
+	if err != nil {
+		info.Status = types.ValidationStatusCIDToHashErr
+		return info
+	}
+
+	candidates, err := m.getReferenceCandidates(hash, nodeID)
+	if err != nil {
+		log.Errorf("getReferenceCandidates %s err:%s", hash, err.Error())
+		info.Status = types.ValidationStatusLoadDBErr
+		return info
+	}
+
+	// the reference of the first candidate is cross-checked with a second candidate if it does not match
+	var reference, crossCheck map[int]string
+	for _, candidate := range candidates {
+		r, err := candidate.GetStorageProof(ctx, challenge)
+		if err != nil {
+			log.Errorf("candidate %s GetStorageProof err:%s", candidate.NodeID, err.Error())
+			continue
+		}
+
+		if reference == nil {
+			info.CandidateID = candidate.NodeID
+			reference = r
+			if sameProof(proof, r) {
+				break
+			}
+			continue
+		}
+
+		crossCheck = r
+		break
+	}
+
+	if reference == nil {
+		info.Status = types.ValidationStatusGetValidatorBlockErr
+		return info
+	}
+
+	var attributed bool
+	info.Status, attributed = storageProofStatus(proof, reference, crossCheck)
+	if !attributed {
+		info.CandidateID = ""
+	}
+
+	return info
+}
+
+// newStorageChallenge returns the challenge of the storage proof of the node, the blocks are selected with the seed derived
+// from the beacon entry, so they can be re-derived from the beacon round of the result
+func newStorageChallenge(entry *BeaconEntry, serverID, nodeID, cid string, nonce []byte) *types.StorageChallenge {
+	return &types.StorageChallenge{
+		AssetCID: cid,
+		Seed:     DeriveNodeSeed(entry, serverID, nodeID),
+		Count:    storageProofBlocks,
+		Nonce:    nonce,
+	}
+}
+
+// storageProofStatus compares the proof of the node with the reference of the first candidate and the proof of a second candidate
+// which cross-checks the reference, crossCheck is empty if the reference matches or no second candidate responds.
+// It returns false if the node and both candidates disagree, the failure can not be attributed to the first candidate.
+func storageProofStatus(proof, reference, crossCheck map[int]string) (types.ValidationStatus, bool) {
+	if sameProof(proof, reference) {
+		return types.ValidationStatusSuccess, true
+	}
+
+	if len(crossCheck) == 0 {
+		return types.ValidationStatusValidateFail, true
+	}
+
+	if sameProof(proof, crossCheck) {
+		// the first candidate returns the wrong proof
+		return types.ValidationStatusCandidateBlockErr, true
+	}
+
+	if sameProof(reference, crossCheck) {
+		return types.ValidationStatusValidateFail, true
+	}
+
+	return types.ValidationStatusCandidateBlockErr, false
+}
+
+// isUnreachable checks whether the error is caused by the timeout or the transport, not by the node,
+// the errors returned by the node, e.g. the asset is not found, are failures of the proof
+func isUnreachable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	var clientErr *jsonrpc.ErrClient
+	var connErr *jsonrpc.RPCConnectionError
+	var netErr net.Error
+
+	return errors.As(err, &clientErr) || errors.As(err, &connErr) || errors.As(err, &netErr)
+}
+
+// sameProof checks whether the proofs are the same and not empty
+func sameProof(a, b map[int]string) bool {
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+
+	for index, hash := range a {
+		if b[index] != hash {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Filecoin-Titan/titan/api/types"
+	"github.com/filecoin-project/go-jsonrpc"
+	"golang.org/x/xerrors"
+)
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{"deadline", xerrors.Errorf("proof: %w", context.DeadlineExceeded), true},
+		{"client", &jsonrpc.ErrClient{}, true},
+		{"connection", &jsonrpc.RPCConnectionError{}, true},
+		{"network", &net.OpError{Op: "dial", Err: xerrors.New("connection refused")}, true},
+		{"asset not found", xerrors.New("asset not found"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isUnreachable(tt.err); got != tt.expect {
+			t.Errorf("%s: expect %v, got %v", tt.name, tt.expect, got)
+		}
+	}
+}
+
+func TestStorageProofStatus(t *testing.T) {
+	good := map[int]string{0: "a", 1: "b"}
+	bad := map[int]string{0: "a", 1: "c"}
+	other := map[int]string{0: "d", 1: "e"}
+
+	tests := []struct {
+		name       string
+		proof      map[int]string
+		reference  map[int]string
+		crossCheck map[int]string
+		status     types.ValidationStatus
+		attributed bool
+	}{
+		{"success", good, good, nil, types.ValidationStatusSuccess, true},
+		{"empty proof", map[int]string{}, map[int]string{}, nil, types.ValidationStatusValidateFail, true},
+		{"node wrong without cross check", bad, good, nil, types.ValidationStatusValidateFail, true},
+		{"first candidate wrong", good, bad, good, types.ValidationStatusCandidateBlockErr, true},
+		{"node wrong", bad, good, good, types.ValidationStatusValidateFail, true},
+		{"three-way disagreement", bad, good, other, types.ValidationStatusCandidateBlockErr, false},
+	}
+
+	for _, tt := range tests {
+		status, attributed := storageProofStatus(tt.proof, tt.reference, tt.crossCheck)
+		if status != tt.status || attributed != tt.attributed {
+			t.Errorf("%s: expect %d %v, got %d %v", tt.name, tt.status, tt.attributed, status, attributed)
+		}
+	}
+}
+
+func TestStorageChallengeReDerived(t *testing.T) {
+	ctx := context.Background()
+	beacon := NewLocalBeacon(time.Now().Add(-time.Minute), 10*time.Second, []byte("secret"))
+
+	entry, err := beacon.Latest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge := newStorageChallenge(entry, "server", "node", "cid", []byte("nonce"))
+	row := &types.ValidationResultInfo{NodeID: "node", Cid: "cid", Type: types.ValidationTypeStorage, BeaconRound: entry.Round}
+
+	// the challenged blocks are selected by the seed and the count, they are re-derived from the beacon round of the saved row
+	saved, err := beacon.Entry(ctx, row.BeaconRound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed, count := validationChallengeSeed(row, saved, "server")
+	if seed != challenge.Seed || count != challenge.Count {
+		t.Errorf("expect seed %d and %d blocks, got seed %d and %d blocks", challenge.Seed, challenge.Count, seed, count)
+	}
+
+	if other := newStorageChallenge(entry, "server", "other", "cid", []byte("nonce")); other.Seed == challenge.Seed {
+		t.Error("expect different seeds for different nodes")
+	}
+
+	// the bandwidth validations of the round share the seed of the round
+	bandwidth := &types.ValidationResultInfo{NodeID: "node", BlockNumber: 3, Type: types.ValidationTypeBandwidth, BeaconRound: entry.Round}
+	if seed, count := validationChallengeSeed(bandwidth, saved, "server"); seed != DeriveSeed(entry, "server") || count != 3 {
+		t.Errorf("expect the seed of the round and 3 blocks, got seed %d and %d blocks", seed, count)
+	}
+}
@@ -2,29 +2,34 @@ package validation
 
 import (
 	"context"
+	"database/sql"
+	"encoding/hex"
 	"math/rand"
 	"time"
 
 	"github.com/Filecoin-Titan/titan/api"
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/Filecoin-Titan/titan/node/cidutil"
-	"github.com/google/uuid"
+	"github.com/Filecoin-Titan/titan/node/scheduler/node"
 	"golang.org/x/xerrors"
 )
 
 const (
-	duration           = 10              // Validation duration per node (Unit:Second)
-	validationInterval = 5 * time.Minute // validation start-up time interval (Unit:minute)
+	maxCandidateBlockErrors = 3 // The number of wrong reference blocks in an election cycle before the candidate is de-elected
+
+	untrustedWindow = 24 * time.Hour // The candidates which fail the validation in the window do not provide reference blocks
 )
 
-// startValidationTicker starts the validation process.
+// startValidationTicker starts the validation process, a round starts every round interval and validates the nodes which are due.
 func (m *Manager) startValidationTicker(ctx context.Context) {
-	ticker := time.NewTicker(validationInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(m.getRoundInterval())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
+			timer.Reset(m.getRoundInterval())
+
 			if enable := m.isEnabled(); !enable {
 				continue
 			}
@@ -56,26 +61,39 @@ func (m *Manager) isEnabled() bool {
 
 // startValidate is a method of the Manager that starts a new validation round.
 func (m *Manager) startValidate() error {
-	if m.curRoundID != "" {
+	if prevRoundID := m.currentRoundID(); prevRoundID != "" {
 		// Set the timeout status of the previous verification
-		err := m.nodeMgr.UpdateValidationResultsTimeout(m.curRoundID)
+		err := m.nodeMgr.EndValidationRound(prevRoundID)
 		if err != nil {
-			log.Errorf("startNewRound:%s UpdateValidationResultsTimeout err:%s", m.curRoundID, err.Error())
+			log.Errorf("startNewRound:%s EndValidationRound err:%s", prevRoundID, err.Error())
 		}
+
+		// the results of the ended round are not accepted, the current round is set when a new round starts
+		m.setCurrentRound(&types.ValidationRoundInfo{})
 	}
 
-	roundID := uuid.NewString()
-	m.curRoundID = roundID
-	m.seed = time.Now().UnixNano() // TODO from filecoin
+	round, err := m.newRound(context.Background())
+	if err != nil {
+		return err
+	}
 
 	vrs := m.PairValidatorsAndValidatableNodes()
+	cvrs := m.pairCandidates()
+	vrs = append(append(make([]*VWindow, 0, len(vrs)+len(cvrs)), vrs...), cvrs...)
 
-	vReqs, dbInfos := m.getValidationDetails(vrs)
+	vReqs, dbInfos := m.getValidationDetails(round, vrs, true)
 	if len(vReqs) == 0 {
 		return nil
 	}
 
-	err := m.nodeMgr.SaveValidationResultInfos(dbInfos)
+	err = m.saveRound(round, vrs)
+	if err != nil {
+		return err
+	}
+
+	m.setCurrentRound(round)
+
+	err = m.nodeMgr.SaveValidationResultInfos(dbInfos)
 	if err != nil {
 		return err
 	}
@@ -101,10 +119,13 @@ func (m *Manager) sendValidateReqToNodes(nID string, req *api.ValidateReq) {
 	log.Errorf("%s validatable Node not found", nID)
 }
 
-// get validation details.
-func (m *Manager) getValidationDetails(vrs []*VWindow) (map[string]*api.ValidateReq, []*types.ValidationResultInfo) {
+// get validation details of the round, if onlyDue is true, the nodes which are not due are skipped.
+func (m *Manager) getValidationDetails(round *types.ValidationRoundInfo, vrs []*VWindow, onlyDue bool) (map[string]*api.ValidateReq, []*types.ValidationResultInfo) {
 	bReqs := make(map[string]*api.ValidateReq)
 	vrInfos := make([]*types.ValidationResultInfo, 0)
+	duration := m.getValidationDuration()
+	exclude := m.getValidationExcludeSelector()
+	now := time.Now()
 
 	for _, vr := range vrs {
 		vID := vr.NodeID
@@ -115,6 +136,15 @@ func (m *Manager) getValidationDetails(vrs []*VWindow) (map[string]*api.Validate
 		}
 
 		for nodeID := range vr.ValidatableNodes {
+			if onlyDue && !m.isValidationDue(nodeID, now) {
+				continue
+			}
+
+			// the nodes in maintenance or draining, or excluded by their labels are not validated
+			if node := m.nodeMgr.GetNode(nodeID); node == nil || !node.IsSchedulable() || isValidationExcluded(node.GetLabels(), exclude) {
+				continue
+			}
+
 			cid, err := m.getNodeValidationCID(nodeID)
 			if err != nil {
 				log.Errorf("%s getNodeValidationCID err:%s", nodeID, err.Error())
@@ -122,27 +152,57 @@ func (m *Manager) getValidationDetails(vrs []*VWindow) (map[string]*api.Validate
 			}
 
 			dbInfo := &types.ValidationResultInfo{
-				RoundID:     m.curRoundID,
+				RoundID:     round.RoundID,
 				NodeID:      nodeID,
 				ValidatorID: vID,
 				Status:      types.ValidationStatusCreate,
 				Cid:         cid,
+				BeaconRound: round.BeaconRound,
 			}
 			vrInfos = append(vrInfos, dbInfo)
 
 			req := &api.ValidateReq{
-				RandomSeed: m.seed,
-				Duration:   duration,
-				TCPSrvAddr: vNode.TCPAddr(),
+				RandomSeed:     round.Seed,
+				Duration:       duration,
+				TCPSrvAddr:     vNode.TCPAddr(),
+				TCPSrvTLS:      vNode.TCPTLS(),
+				TCPSrvCertHash: vNode.TCPCertHash(),
+				RoundID:        round.RoundID,
 			}
 
 			bReqs[nodeID] = req
+			m.scheduleNextValidation(nodeID, now)
 		}
 	}
 
 	return bReqs, vrInfos
 }
 
+// GetValidatableNodePublicKey returns the public key of the node which is validated by the validator in a running round
+func (m *Manager) GetValidatableNodePublicKey(validatorID, nodeID, roundID string) (string, error) {
+	if _, err := m.getRoundSeed(roundID, validatorID, nodeID); err != nil {
+		return "", err
+	}
+
+	vInfo, err := m.nodeMgr.LoadNodeValidationInfo(roundID, nodeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", xerrors.Errorf("node %s is not scheduled in round %s", nodeID, roundID)
+		}
+		return "", err
+	}
+
+	if vInfo.ValidatorID != validatorID {
+		return "", xerrors.Errorf("node %s is not validated by %s", nodeID, validatorID)
+	}
+
+	if vInfo.Status != types.ValidationStatusCreate {
+		return "", xerrors.Errorf("node %s has been validated, status %d", nodeID, vInfo.Status)
+	}
+
+	return m.nodeMgr.LoadNodePublicKey(nodeID)
+}
+
 // getNodeValidationCID retrieves a random validation CID from the node with the given ID.
 func (m *Manager) getNodeValidationCID(nodeID string) (string, error) {
 	count, err := m.nodeMgr.LoadNodeReplicaCount(nodeID)
@@ -180,10 +240,11 @@ func (m *Manager) getRandNum(max int, r *rand.Rand) int {
 }
 
 // updateResultInfo updates the validation result information for a given node.
-func (m *Manager) updateResultInfo(status types.ValidationStatus, vr *api.ValidationResult) error {
+func (m *Manager) updateResultInfo(status types.ValidationStatus, vr *api.ValidationResult, candidateID string) error {
 	resultInfo := &types.ValidationResultInfo{
-		RoundID:     m.curRoundID,
+		RoundID:     vr.RoundID,
 		NodeID:      vr.NodeID,
+		CandidateID: candidateID,
 		Status:      status,
 		BlockNumber: int64(len(vr.Cids)),
 		Bandwidth:   vr.Bandwidth,
@@ -193,17 +254,49 @@ func (m *Manager) updateResultInfo(status types.ValidationStatus, vr *api.Valida
 	return m.nodeMgr.UpdateValidationResultInfo(resultInfo)
 }
 
+// publishValidationResult notifies the subscribers of the validation result of the node
+func (m *Manager) publishValidationResult(nodeID, cid string, status types.ValidationStatus, t types.ValidationType) {
+	m.notify.Pub(&types.Event{
+		Topic:    types.EventValidationResult,
+		Time:     time.Now(),
+		NodeID:   nodeID,
+		AssetCID: cid,
+		State:    status.String(),
+		Message:  t.String(),
+	}, types.EventValidationResult.String())
+}
+
 // HandleResult handles the validation result for a given node.
 func (m *Manager) HandleResult(vr *api.ValidationResult) error {
 	log.Debugf("HandleResult roundID :%s , vr.Cids :%v", vr.RoundID, vr.Cids)
 
+	// the result may belong to a round started before the scheduler restarts
+	seed, err := m.getRoundSeed(vr.RoundID, vr.Validator, vr.NodeID)
+	if err != nil {
+		return xerrors.Errorf("handle result of node %s: %w", vr.NodeID, err)
+	}
+
 	var status types.ValidationStatus
+	var candidateID string // the candidate which provides the reference blocks
 	nodeID := vr.NodeID
 
 	defer func() {
-		err := m.updateResultInfo(status, vr)
+		m.updateSchedule(nodeID, status)
+
+		err := m.updateResultInfo(status, vr, candidateID)
 		if err != nil {
 			log.Errorf("updateResultInfo [%s] fail : %s", nodeID, err.Error())
+			return
+		}
+
+		m.publishValidationResult(nodeID, vr.CID, status, types.ValidationTypeBandwidth)
+
+		if status == types.ValidationStatusCandidateBlockErr && candidateID != "" {
+			m.penalizeCandidate(candidateID)
+		}
+
+		if status == types.ValidationStatusValidateFail && m.nodeMgr.GetCandidateNode(nodeID) != nil {
+			log.Warnf("candidate %s fails the validation of %s, its replicas are not used as reference in %s", nodeID, vr.CID, untrustedWindow)
 		}
 	}()
 
@@ -223,10 +316,10 @@ func (m *Manager) HandleResult(vr *api.ValidationResult) error {
 		return nil
 	}
 
-	vInfo, err := m.nodeMgr.LoadNodeValidationInfo(m.curRoundID, nodeID)
+	vInfo, err := m.nodeMgr.LoadNodeValidationInfo(vr.RoundID, nodeID)
 	if err != nil {
 		status = types.ValidationStatusLoadDBErr
-		log.Errorf("LoadNodeValidationCID %s , %s, err:%s", m.curRoundID, nodeID, err.Error())
+		log.Errorf("LoadNodeValidationCID %s , %s, err:%s", vr.RoundID, nodeID, err.Error())
 		return nil
 	}
 
@@ -242,16 +335,188 @@ func (m *Manager) HandleResult(vr *api.ValidationResult) error {
 		return nil
 	}
 
-	rows, err := m.nodeMgr.LoadReplicasByHash(hash, []types.ReplicaStatus{types.ReplicaStatusSucceeded})
+	cCidMap, candidateID, err := m.getCandidateBlocks(vInfo.Cid, hash, seed, cidCount, nodeID)
 	if err != nil {
 		status = types.ValidationStatusLoadDBErr
-		log.Errorf("LoadReplicasByHash %s , err:%s", hash, err.Error())
+		log.Errorf("getCandidateBlocks %s , err:%s", hash, err.Error())
 		return nil
 	}
-	defer rows.Close()
 
-	var cCidMap map[int]string
+	if len(cCidMap) <= 0 {
+		status = types.ValidationStatusGetValidatorBlockErr
+		log.Errorf("handleValidationResult candidate map is nil , %s", vr.CID)
+		return nil
+	}
+
+	record, err := m.nodeMgr.LoadAssetRecord(hash)
+	if err != nil {
+		status = types.ValidationStatusLoadDBErr
+		log.Errorf("handleValidationResult asset record %s , err:%s", vr.CID, err.Error())
+		return nil
+	}
+
+	totalBlocks := int(record.TotalBlocks)
+	if m.matchBlocks(vr.RoundID, seed, vr.Cids, cCidMap, totalBlocks) {
+		status = types.ValidationStatusSuccess
+		return nil
+	}
+
+	// the reference blocks may be wrong, cross-check them with another candidate
+	status, candidateID = m.crossCheckBlocks(vInfo.Cid, hash, seed, vr, cCidMap, candidateID, totalBlocks)
+	return nil
+}
+
+// matchBlocks checks whether the blocks sent by the node match the reference blocks of the candidate
+func (m *Manager) matchBlocks(roundID string, seed int64, cids []string, reference map[int]string, totalBlocks int) bool {
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < len(cids); i++ {
+		resultCid := cids[i]
+		randNum := m.getRandNum(totalBlocks, r)
+		vCid := reference[randNum]
+
+		if !m.compareCid(resultCid, vCid) {
+			log.Debugf("round [%s] block mismatch resultCid:%s, vCid:%s,randNum:%d,index:%d", roundID, resultCid, vCid, randNum, i)
+			return false
+		}
+	}
+
+	return true
+}
+
+// crossCheckBlocks gets the reference blocks from a second candidate to attribute the mismatch to the right party.
+// It returns the status of the validation and the candidate which is recorded with the result,
+// the candidate is empty if the failure can not be attributed.
+func (m *Manager) crossCheckBlocks(cid, hash string, seed int64, vr *api.ValidationResult, reference map[int]string, candidateID string, totalBlocks int) (types.ValidationStatus, string) {
+	nodeID := vr.NodeID
+	roundID := vr.RoundID
+
+	second, secondID, err := m.getCandidateBlocks(cid, hash, seed, len(vr.Cids), nodeID, candidateID)
+	if err != nil || len(second) == 0 {
+		// no other candidate to cross-check, the node is blamed as before
+		log.Errorf("round [%s] nodeID [%s] validate fail, no candidate to cross-check with %s", roundID, nodeID, candidateID)
+		return types.ValidationStatusValidateFail, candidateID
+	}
+
+	if m.sameBlocks(reference, second) {
+		log.Errorf("round [%s] nodeID [%s] validate fail, confirmed by candidates %s and %s", roundID, nodeID, candidateID, secondID)
+		return types.ValidationStatusValidateFail, candidateID
+	}
+
+	if m.matchBlocks(roundID, seed, vr.Cids, second, totalBlocks) {
+		log.Errorf("round [%s] candidate %s returns wrong blocks of %s, nodeID [%s] is confirmed by %s", roundID, candidateID, cid, nodeID, secondID)
+		return types.ValidationStatusCandidateBlockErr, candidateID
+	}
+
+	// the node and both candidates disagree, the failure can not be attributed
+	log.Errorf("round [%s] nodeID [%s] and candidates %s %s disagree on blocks of %s", roundID, nodeID, candidateID, secondID, cid)
+	return types.ValidationStatusCandidateBlockErr, ""
+}
+
+// sameBlocks checks whether the reference blocks of two candidates are the same
+func (m *Manager) sameBlocks(a, b map[int]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for index, cid := range a {
+		if !m.compareCid(cid, b[index]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// penalizeCandidate de-elects the validator which returns wrong reference blocks repeatedly
+func (m *Manager) penalizeCandidate(candidateID string) {
+	isV, err := m.nodeMgr.IsValidator(candidateID)
+	if err != nil {
+		log.Errorf("IsValidator err:%s", err.Error())
+		return
+	}
+
+	if !isV {
+		return
+	}
+
+	offenders, err := m.loadOffenders()
+	if err != nil {
+		log.Errorf("loadOffenders err:%s", err.Error())
+		return
+	}
+
+	if _, ok := offenders[candidateID]; !ok {
+		return
+	}
+
+	log.Warnf("validator %s returns wrong blocks repeatedly, trigger election", candidateID)
+	select {
+	case m.updateCh <- electionReasonPenalty:
+	default:
+	}
+}
+
+// loadOffenders loads the candidates which return wrong reference blocks repeatedly in the election cycle
+func (m *Manager) loadOffenders() (map[string]struct{}, error) {
+	nodeIDs, err := m.nodeMgr.LoadCandidateBlockOffenders(time.Now().Add(-electionCycle), maxCandidateBlockErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	offenders := make(map[string]struct{}, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		offenders[nodeID] = struct{}{}
+	}
+
+	return offenders, nil
+}
+
+// getCandidateBlocks gets the random blocks of the asset from a candidate which has the replica, except the excluded nodes.
+// It returns the blocks and the candidate id.
+func (m *Manager) getCandidateBlocks(cid, hash string, seed int64, count int, excludes ...string) (map[int]string, string, error) {
+	candidates, err := m.getReferenceCandidates(hash, excludes...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, node := range candidates {
+		cCidMap, err := node.GetBlocksOfAsset(context.Background(), cid, seed, count)
+		if err != nil {
+			log.Errorf("candidate %s GetBlocksOfAsset err:%s", node.NodeID, err.Error())
+			continue
+		}
+
+		return cCidMap, node.NodeID, nil
+	}
+
+	return nil, "", nil
+}
+
+// getReferenceCandidates returns the online candidates which have the replica of the asset, except the excluded nodes
+// and the candidates which fail the validation recently.
+func (m *Manager) getReferenceCandidates(hash string, excludes ...string) ([]*node.Node, error) {
+	excluded := make(map[string]struct{}, len(excludes))
+	for _, nodeID := range excludes {
+		excluded[nodeID] = struct{}{}
+	}
+
+	// the replicas of the candidates which fail the validation may be corrupted or missing
+	untrusted, err := m.nodeMgr.LoadValidationFailedNodes(m.nodeMgr.GetAllCandidateNodes(), time.Now().Add(-untrustedWindow))
+	if err != nil {
+		return nil, err
+	}
 
+	for _, nodeID := range untrusted {
+		excluded[nodeID] = struct{}{}
+	}
+
+	rows, err := m.nodeMgr.LoadReplicasByHash(hash, []types.ReplicaStatus{types.ReplicaStatusSucceeded})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*node.Node
 	for rows.Next() {
 		rInfo := &types.ReplicaInfo{}
 		err = rows.StructScan(rInfo)
@@ -260,56 +525,77 @@ func (m *Manager) HandleResult(vr *api.ValidationResult) error {
 			continue
 		}
 
-		cNodeID := rInfo.NodeID
-		if cNodeID == nodeID {
+		if _, ok := excluded[rInfo.NodeID]; ok {
 			continue
 		}
 
-		node := m.nodeMgr.GetCandidateNode(cNodeID)
+		node := m.nodeMgr.GetCandidateNode(rInfo.NodeID)
 		if node == nil {
 			continue
 		}
 
-		cCidMap, err = node.GetBlocksOfAsset(context.Background(), vInfo.Cid, m.seed, cidCount)
-		if err != nil {
-			log.Errorf("candidate %s GetBlocksOfAsset err:%s", cNodeID, err.Error())
-			continue
-		}
+		out = append(out, node)
+	}
 
-		break
+	return out, nil
+}
+
+// GetValidationChallenge re-derives the seed of the validation from the beacon, and the blocks which the node is expected to send
+func (m *Manager) GetValidationChallenge(ctx context.Context, roundID, nodeID string) (*types.ValidationChallenge, error) {
+	vInfo, err := m.nodeMgr.LoadNodeValidationInfo(roundID, nodeID)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(cCidMap) <= 0 {
-		status = types.ValidationStatusGetValidatorBlockErr
-		log.Errorf("handleValidationResult candidate map is nil , %s", vr.CID)
-		return nil
+	// the round 0 of the beacon is the latest round, the validations without a beacon round can not be re-derived
+	if vInfo.BeaconRound == 0 {
+		return nil, xerrors.Errorf("validation %s of node %s has no beacon round", roundID, nodeID)
 	}
 
-	record, err := m.nodeMgr.LoadAssetRecord(hash)
+	entry, err := m.beacon.Entry(ctx, vInfo.BeaconRound)
 	if err != nil {
-		status = types.ValidationStatusLoadDBErr
-		log.Errorf("handleValidationResult asset record %s , err:%s", vr.CID, err.Error())
-		return nil
+		return nil, xerrors.Errorf("get beacon round %d err:%s", vInfo.BeaconRound, err.Error())
 	}
 
-	r := rand.New(rand.NewSource(m.seed))
-	// do validate
-	for i := 0; i < cidCount; i++ {
-		resultCid := vr.Cids[i]
-		randNum := m.getRandNum(int(record.TotalBlocks), r)
-		vCid := cCidMap[randNum]
+	serverID := string(m.nodeMgr.ServerID)
+	seed, count := validationChallengeSeed(vInfo, entry, serverID)
+	challenge := &types.ValidationChallenge{
+		RoundID:     roundID,
+		NodeID:      nodeID,
+		ServerID:    serverID,
+		BeaconRound: entry.Round,
+		Randomness:  hex.EncodeToString(entry.Randomness),
+		Seed:        seed,
+		Cid:         vInfo.Cid,
+		BlockNumber: vInfo.BlockNumber,
+	}
 
-		// TODO Penalize the candidate if vCid error
+	if count <= 0 {
+		return challenge, nil
+	}
 
-		if !m.compareCid(resultCid, vCid) {
-			status = types.ValidationStatusValidateFail
-			log.Errorf("round [%d] and nodeID [%s], validator fail resultCid:%s, vCid:%s,randNum:%d,index:%d", m.curRoundID, nodeID, resultCid, vCid, randNum, i)
-			return nil
-		}
+	hash, err := cidutil.CIDToHash(vInfo.Cid)
+	if err != nil {
+		return nil, err
 	}
 
-	status = types.ValidationStatusSuccess
-	return nil
+	challenge.ExpectedCIDs, _, err = m.getCandidateBlocks(vInfo.Cid, hash, challenge.Seed, count, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// validationChallengeSeed returns the seed and the number of the blocks the node is challenged with in the validation,
+// the storage proofs challenge a fixed number of blocks with the seed of the node, the bandwidth validations
+// challenge the blocks the node sent with the seed of the round
+func validationChallengeSeed(vInfo *types.ValidationResultInfo, entry *BeaconEntry, serverID string) (int64, int) {
+	if vInfo.Type == types.ValidationTypeStorage {
+		return DeriveNodeSeed(entry, serverID, vInfo.NodeID), storageProofBlocks
+	}
+
+	return DeriveSeed(entry, serverID), int(vInfo.BlockNumber)
 }
 
 // compares two CID strings and returns true if they are equal, false otherwise
@@ -3,6 +3,10 @@ package scheduler
 import (
 	"context"
 	"crypto"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/Filecoin-Titan/titan/node/cidutil"
@@ -10,10 +14,188 @@ import (
 	"golang.org/x/xerrors"
 )
 
+const (
+	// maxProofsPerSubmit is the max number of proofs submitted at once
+	maxProofsPerSubmit = 1000
+	// speedTolerance is the tolerance of the declared download speed against the speed computed from the size and duration
+	speedTolerance = 2
+	// bandwidthTolerance is the tolerance of the download speed against the declared upload bandwidth of the node
+	bandwidthTolerance = 2
+	// sizeTolerance is the tolerance of the download size against the asset size
+	sizeTolerance = 2
+	// trafficTolerance is the tolerance of the proofs size against the traffic reported by the node
+	trafficTolerance = 1.1
+	// clockSkew is the max clock difference between the user and the scheduler
+	clockSkew = time.Minute
+)
+
+// SubmitUserProofsOfWork verifies the proofs of the user downloads and saves the accepted proofs
 func (s *Scheduler) SubmitUserProofsOfWork(ctx context.Context, proofs []*types.UserProofOfWork) error {
+	if len(proofs) > maxProofsPerSubmit {
+		return xerrors.Errorf("too many proofs %d, max %d", len(proofs), maxProofsPerSubmit)
+	}
+
+	rejected := make([]string, 0)
+	for _, proof := range proofs {
+		if proof == nil {
+			continue
+		}
+
+		if err := s.handleUserProofOfWork(proof); err != nil {
+			log.Warnf("reject proof of ticket %s: %s", proof.TicketID, err.Error())
+			rejected = append(rejected, fmt.Sprintf("%s: %s", proof.TicketID, err.Error()))
+		}
+	}
+
+	if len(rejected) > 0 {
+		return xerrors.Errorf("%d proofs rejected: %s", len(rejected), strings.Join(rejected, "; "))
+	}
+
 	return nil
 }
 
+// handleUserProofOfWork verifies a proof against the credentials issued by the scheduler and saves it
+func (s *Scheduler) handleUserProofOfWork(proof *types.UserProofOfWork) error {
+	credentials, err := s.NodeManager.LoadCredentials(proof.TicketID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return xerrors.New("ticket not found")
+		}
+		return err
+	}
+
+	if err := verifyUserProofOfWork(proof, credentials, time.Now()); err != nil {
+		return err
+	}
+
+	nodeInfo, err := s.NodeManager.LoadNodeInfo(credentials.NodeID)
+	if err != nil {
+		return xerrors.Errorf("load node %s info err:%s", credentials.NodeID, err.Error())
+	}
+
+	if nodeInfo.BandwidthUp > 0 && float64(proof.DownloadSpeed) > nodeInfo.BandwidthUp*bandwidthTolerance {
+		return xerrors.Errorf("download speed %d exceeds the node bandwidth %.0f", proof.DownloadSpeed, nodeInfo.BandwidthUp)
+	}
+
+	hash, err := cidutil.CIDToHash(credentials.AssetCID)
+	if err != nil {
+		return xerrors.Errorf("%s cid to hash err:%s", credentials.AssetCID, err.Error())
+	}
+
+	record, err := s.NodeManager.LoadAssetRecord(hash)
+	if err != nil {
+		return xerrors.Errorf("load asset %s record err:%s", credentials.AssetCID, err.Error())
+	}
+
+	if record.TotalSize > 0 && proof.DownloadSize > record.TotalSize*sizeTolerance {
+		return xerrors.Errorf("download size %d exceeds the asset size %d", proof.DownloadSize, record.TotalSize)
+	}
+
+	info := &types.ProofOfWorkInfo{
+		TicketID:      proof.TicketID,
+		ClientID:      proof.ClientID,
+		NodeID:        credentials.NodeID,
+		AssetCID:      credentials.AssetCID,
+		DownloadSpeed: proof.DownloadSpeed,
+		DownloadSize:  proof.DownloadSize,
+		StartTime:     time.UnixMilli(proof.StartTime),
+		EndTime:       time.UnixMilli(proof.EndTime),
+		Status:        types.ProofOfWorkStatusUnconfirmed,
+	}
+
+	saved, err := s.NodeManager.SaveProofOfWork(info)
+	if err != nil {
+		return err
+	}
+
+	if !saved {
+		return xerrors.New("download overlaps a submitted proof of the ticket")
+	}
+
+	return s.confirmProofsOfTicket(proof.TicketID)
+}
+
+// verifyUserProofOfWork checks the proof matches the credentials and the download is plausible
+func verifyUserProofOfWork(proof *types.UserProofOfWork, credentials *types.Credentials, now time.Time) error {
+	if credentials.ClientID != "" && proof.ClientID != credentials.ClientID {
+		return xerrors.Errorf("client %s not match the ticket", proof.ClientID)
+	}
+
+	if proof.NodeID != "" && proof.NodeID != credentials.NodeID {
+		return xerrors.Errorf("node %s not match the ticket", proof.NodeID)
+	}
+
+	if proof.AssetCID != "" && proof.AssetCID != credentials.AssetCID {
+		return xerrors.Errorf("asset %s not match the ticket", proof.AssetCID)
+	}
+
+	if proof.DownloadSize <= 0 || proof.DownloadSpeed <= 0 {
+		return xerrors.Errorf("invalid download size %d or speed %d", proof.DownloadSize, proof.DownloadSpeed)
+	}
+
+	startTime := time.UnixMilli(proof.StartTime)
+	endTime := time.UnixMilli(proof.EndTime)
+	if !endTime.After(startTime) {
+		return xerrors.New("end time must be after start time")
+	}
+
+	if endTime.After(now.Add(clockSkew)) {
+		return xerrors.New("end time is in the future")
+	}
+
+	if startTime.After(time.Unix(credentials.ValidTime, 0)) {
+		return xerrors.New("download started after the ticket expired")
+	}
+
+	// the declared speed must be consistent with the size and duration
+	speed := float64(proof.DownloadSize) / endTime.Sub(startTime).Seconds()
+	if float64(proof.DownloadSpeed) > speed*speedTolerance || float64(proof.DownloadSpeed)*speedTolerance < speed {
+		return xerrors.Errorf("download speed %d not match the size %d and duration %s", proof.DownloadSpeed, proof.DownloadSize, endTime.Sub(startTime))
+	}
+
+	return nil
+}
+
+// confirmProofsOfTicket confirms the proofs of the ticket which are covered by the traffic reported by the node
+func (s *Scheduler) confirmProofsOfTicket(ticketID string) error {
+	reported, err := s.NodeManager.LoadCredentialTraffic(ticketID)
+	if err != nil {
+		return err
+	}
+
+	proofs, err := s.NodeManager.LoadProofsOfTicket(ticketID)
+	if err != nil {
+		return err
+	}
+
+	limit := int64(float64(reported) * trafficTolerance)
+	var confirmed int64
+	for _, proof := range proofs {
+		if confirmed+proof.DownloadSize > limit {
+			break
+		}
+		confirmed += proof.DownloadSize
+
+		if proof.Status == types.ProofOfWorkStatusConfirmed {
+			continue
+		}
+
+		if err := s.NodeManager.UpdateProofOfWorkStatus(proof.ID, types.ProofOfWorkStatusConfirmed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetServedTrafficStats retrieves the traffic served by nodes, aggregated from the confirmed user proofs of work
+func (s *Scheduler) GetServedTrafficStats(ctx context.Context, req types.ListServedTrafficReq) (*types.ListServedTrafficRsp, error) {
+	startTime := time.Unix(req.StartTime, 0)
+	endTime := time.Unix(req.EndTime, 0)
+
+	return s.NodeManager.LoadServedTraffic(req.NodeID, startTime, endTime, req.Cursor, req.Count)
+}
+
 // GetEdgeDownloadInfos finds edge download information for a given CID
 func (s *Scheduler) GetEdgeDownloadInfos(ctx context.Context, cid string) (*types.EdgeDownloadInfoList, error) {
 	if cid == "" {
@@ -33,6 +215,7 @@ func (s *Scheduler) GetEdgeDownloadInfos(ctx context.Context, cid string) (*type
 
 	titanRsa := titanrsa.New(crypto.SHA256, crypto.SHA256.New())
 	infos := make([]*types.EdgeDownloadInfo, 0)
+	issued := make([]*types.Credentials, 0)
 
 	for rows.Next() {
 		rInfo := &types.ReplicaInfo{}
@@ -48,24 +231,36 @@ func (s *Scheduler) GetEdgeDownloadInfos(ctx context.Context, cid string) (*type
 
 		nodeID := rInfo.NodeID
 		eNode := s.NodeManager.GetEdgeNode(nodeID)
-		if eNode == nil {
+		if eNode == nil || eNode.IsQuarantined() {
 			continue
 		}
 
-		credentials, err := eNode.Credentials(cid, titanRsa, s.NodeManager.PrivateKey)
+		svc := eNode.NewCredentials(cid)
+		credentials, err := eNode.EncryptCredentials(svc, titanRsa, s.NodeManager.PrivateKey)
 		if err != nil {
 			continue
 		}
+		issued = append(issued, svc)
 
 		info := &types.EdgeDownloadInfo{
 			URL:         eNode.DownloadAddr(),
 			NodeID:      nodeID,
 			Credentials: credentials,
-			NatType:     eNode.NATType,
+			NatType:     eNode.GetNATType(),
 		}
 		infos = append(infos, info)
 	}
 
+	// the issued credentials are used to verify the user proofs of work
+	if len(issued) > 0 {
+		if err := s.NodeManager.SaveCredentials(issued); err != nil {
+			log.Errorf("save credentials err: %s", err.Error())
+		}
+	}
+
+	// the edges which are more likely to be reachable by the hole punch come first
+	s.NATManager.SortByPunchSuccessRate(infos)
+
 	ret := &types.EdgeDownloadInfoList{
 		Infos:        infos,
 		SchedulerURL: s.SchedulerCfg.ExternalURL,
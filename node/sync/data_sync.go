@@ -3,15 +3,23 @@ package sync
 import (
 	"context"
 
+	"github.com/Filecoin-Titan/titan/api"
+	"github.com/Filecoin-Titan/titan/api/types"
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
 )
 
 var log = logging.Logger("datasync")
 
+// maxAssetsOfRange is the maximum number of the local assets of a range whose assets are compared directly,
+// the larger ranges are compared by the hashes of their sub-ranges
+const maxAssetsOfRange = 64
+
 // DataSync represents a data synchronizer, which implements the Sync interface
 type DataSync struct {
 	Sync
+	scheduler api.Scheduler
 }
 
 // Sync defines the synchronization interface
@@ -20,15 +28,18 @@ type Sync interface {
 	GetTopHash(ctx context.Context) (string, error)
 	// GetBucketHashes returns local checksums of all buckets
 	GetBucketHashes(ctx context.Context) (map[uint32]string, error)
-	// GetAssetsOfBucket retrieves assets of a bucket either from local or remote storage
-	GetAssetsOfBucket(ctx context.Context, bucketNumber uint32, isRemote bool) ([]cid.Cid, error)
+	// GetAssetsOfBucket retrieves assets of a bucket from local storage
+	GetAssetsOfBucket(ctx context.Context, bucketNumber uint32) ([]cid.Cid, error)
+	// GetAssetRangeHashes returns local checksums of the sub-ranges of a range in a bucket
+	GetAssetRangeHashes(ctx context.Context, r types.AssetRange) (map[uint32]string, error)
+	// GetAssetsInRange retrieves assets of a range in a bucket from local storage
+	GetAssetsInRange(ctx context.Context, r types.AssetRange) ([]cid.Cid, error)
 	DeleteAsset(root cid.Cid) error
-	AddLostAsset(root cid.Cid) error
 }
 
-// NewDataSync creates a new instance of DataSync
-func NewDataSync(sync Sync) *DataSync {
-	return &DataSync{sync}
+// NewDataSync creates a new instance of DataSync, the assets of the buckets in the scheduler are retrieved from the scheduler
+func NewDataSync(sync Sync, scheduler api.Scheduler) *DataSync {
+	return &DataSync{Sync: sync, scheduler: scheduler}
 }
 
 // CompareTopHash compares the local top hash with the given hash
@@ -43,11 +54,43 @@ func (ds *DataSync) CompareTopHash(ctx context.Context, topHash string) (bool, e
 
 // CompareBucketHashes groups assets in a bucket and compares individual bucket checksums
 func (ds *DataSync) CompareBucketHashes(ctx context.Context, hashes map[uint32]string) ([]uint32, error) {
-	localHashes, err := ds.GetBucketHashes(ctx)
+	extraBuckets, lostBuckets, mismatchBuckets, err := ds.compareBucketHashes(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	// the context of the request is canceled when the request returns
+	go ds.doSync(context.Background(), extraBuckets, lostBuckets, mismatchBuckets)
+
+	return append(mismatchBuckets, lostBuckets...), nil
+}
+
+// SyncAssetView compares the bucket hashes and returns the extra and lost assets, they are applied asynchronously unless dryRun is true
+func (ds *DataSync) SyncAssetView(ctx context.Context, hashes map[uint32]string, dryRun bool) (*types.SyncResult, error) {
+	extraBuckets, lostBuckets, mismatchBuckets, err := ds.compareBucketHashes(ctx, hashes)
 	if err != nil {
 		return nil, err
 	}
 
+	result, err := ds.diff(ctx, extraBuckets, lostBuckets, mismatchBuckets)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun {
+		go ds.apply(context.Background(), result)
+	}
+
+	return result, nil
+}
+
+// compareBucketHashes compares the local bucket hashes with the given hashes, returning the extra, lost and mismatched buckets
+func (ds *DataSync) compareBucketHashes(ctx context.Context, hashes map[uint32]string) ([]uint32, []uint32, []uint32, error) {
+	localHashes, err := ds.GetBucketHashes(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	mismatchBuckets := make([]uint32, 0)
 	lostBuckets := make([]uint32, 0)
 
@@ -67,113 +110,218 @@ func (ds *DataSync) CompareBucketHashes(ctx context.Context, hashes map[uint32]s
 		extraBuckets = append(extraBuckets, k)
 	}
 
-	go ds.doSync(ctx, extraBuckets, lostBuckets, mismatchBuckets)
-
-	return append(mismatchBuckets, lostBuckets...), nil
+	return extraBuckets, lostBuckets, mismatchBuckets, nil
 }
 
 // doSync performs synchronization for extra, lost and mismatched buckets
 func (ds *DataSync) doSync(ctx context.Context, extraBuckets, lostBuckets, mismatchBuckets []uint32) {
-	if len(extraBuckets) > 0 {
-		if err := ds.removeExtraAsset(ctx, extraBuckets); err != nil {
-			log.Errorf("remove extra asset error:%s", err.Error())
-		}
+	result, err := ds.diff(ctx, extraBuckets, lostBuckets, mismatchBuckets)
+	if err != nil {
+		log.Errorf("diff buckets error:%s", err.Error())
+		return
+	}
+
+	ds.apply(ctx, result)
+}
+
+// diff returns the extra and lost assets of the extra, lost and mismatched buckets
+func (ds *DataSync) diff(ctx context.Context, extraBuckets, lostBuckets, mismatchBuckets []uint32) (*types.SyncResult, error) {
+	extraAssets, err := ds.getLocalAssets(ctx, extraBuckets)
+	if err != nil {
+		return nil, xerrors.Errorf("get extra assets %w", err)
+	}
+
+	lostAssets, err := ds.getRemoteAssets(ctx, lostBuckets)
+	if err != nil {
+		return nil, xerrors.Errorf("get lost assets %w", err)
 	}
 
-	if len(lostBuckets) > 0 {
-		if err := ds.addLostAsset(ctx, lostBuckets); err != nil {
-			log.Errorf("add lost asset error:%s", err.Error())
+	for _, bucketNumber := range mismatchBuckets {
+		extras, lost, err := ds.compareBuckets(ctx, bucketNumber)
+		if err != nil {
+			return nil, xerrors.Errorf("compare bucket %d %w", bucketNumber, err)
 		}
+
+		extraAssets = append(extraAssets, extras...)
+		lostAssets = append(lostAssets, lost...)
+	}
+
+	result := &types.SyncResult{
+		ExtraAssets:     make([]string, 0, len(extraAssets)),
+		LostAssets:      make([]string, 0, len(lostAssets)),
+		MismatchBuckets: len(mismatchBuckets) + len(lostBuckets),
+	}
+
+	for _, c := range extraAssets {
+		result.ExtraAssets = append(result.ExtraAssets, c.String())
+	}
+
+	for _, c := range lostAssets {
+		result.LostAssets = append(result.LostAssets, c.String())
+	}
+
+	return result, nil
+}
+
+// apply removes the extra assets and submits the extra and lost assets to the scheduler
+func (ds *DataSync) apply(ctx context.Context, result *types.SyncResult) {
+	if len(result.ExtraAssets) == 0 && len(result.LostAssets) == 0 {
+		return
 	}
 
-	if len(mismatchBuckets) > 0 {
-		if err := ds.repairMismatchAsset(ctx, mismatchBuckets); err != nil {
-			log.Errorf("repair mismatch asset error %s", err.Error())
+	for _, extra := range result.ExtraAssets {
+		c, err := cid.Decode(extra)
+		if err != nil {
+			log.Errorf("decode extra asset %s error:%s", extra, err.Error())
+			continue
+		}
+
+		if err := ds.DeleteAsset(c); err != nil {
+			log.Errorf("delete extra asset %s error:%s", extra, err.Error())
 		}
 	}
+
+	if err := ds.scheduler.SubmitSyncResult(ctx, result); err != nil {
+		log.Errorf("submit sync result error:%s", err.Error())
+	}
 }
 
-// removeExtraAsset removes any assets from the datastore that are not present in the specified buckets.
-func (ds *DataSync) removeExtraAsset(ctx context.Context, buckets []uint32) error {
+// getLocalAssets returns the assets of the buckets in the datastore
+func (ds *DataSync) getLocalAssets(ctx context.Context, buckets []uint32) ([]cid.Cid, error) {
 	cars := make([]cid.Cid, 0)
-	for _, bucketID := range buckets {
-		cs, err := ds.GetAssetsOfBucket(ctx, bucketID, false)
+	for _, bucketNumber := range buckets {
+		cs, err := ds.GetAssetsOfBucket(ctx, bucketNumber)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		cars = append(cars, cs...)
 	}
 
-	for _, car := range cars {
-		ds.DeleteAsset(car)
-	}
-	return nil
+	return cars, nil
 }
 
-// addLostAsset adds any assets to the datastore that are present in the specified buckets but not currently in the datastore.
-func (ds *DataSync) addLostAsset(ctx context.Context, buckets []uint32) error {
+// getRemoteAssets returns the assets of the buckets in the asset view of the scheduler
+func (ds *DataSync) getRemoteAssets(ctx context.Context, buckets []uint32) ([]cid.Cid, error) {
 	cars := make([]cid.Cid, 0)
-	for _, bucketID := range buckets {
-		cs, err := ds.GetAssetsOfBucket(ctx, bucketID, false)
+	for _, bucketNumber := range buckets {
+		cids, err := ds.scheduler.GetAssetListForBucket(ctx, bucketNumber)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		for _, c := range cids {
+			car, err := cid.Decode(c)
+			if err != nil {
+				return nil, err
+			}
+			cars = append(cars, car)
 		}
-		cars = append(cars, cs...)
 	}
 
-	for _, car := range cars {
-		ds.AddLostAsset(car)
+	return cars, nil
+}
+
+// compareBuckets compares the assets in the specified bucket in the datastore and in the scheduler, returning the extra and lost assets.
+// It descends the Merkle tree of the bucket into the mismatched ranges, the whole bucket is compared if the scheduler does not support the ranges.
+func (ds *DataSync) compareBuckets(ctx context.Context, bucketNumber uint32) ([]cid.Cid, []cid.Cid, error) {
+	extras, lost, err := ds.compareRange(ctx, types.AssetRange{Bucket: bucketNumber})
+	if err == nil {
+		return extras, lost, nil
 	}
-	return nil
+
+	log.Warnf("compare ranges of bucket %d error:%s, compare the whole bucket", bucketNumber, err.Error())
+
+	localAssets, err := ds.getLocalAssets(ctx, []uint32{bucketNumber})
+	if err != nil {
+		return nil, nil, err
+	}
+	remoteAssets, err := ds.getRemoteAssets(ctx, []uint32{bucketNumber})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	extras, lost = compareAssets(localAssets, remoteAssets)
+	return extras, lost, nil
 }
 
-// repairMismatchAsset reconciles the datastore with the specified buckets, removing any assets that are not present in the buckets
-// and adding any missing assets to the datastore.
-func (ds *DataSync) repairMismatchAsset(ctx context.Context, buckets []uint32) error {
-	extraCars := make([]cid.Cid, 0)
-	lostCars := make([]cid.Cid, 0)
-	for _, bucketID := range buckets {
-		extras, lost, err := ds.compareBuckets(ctx, bucketID)
+// compareRange compares the hashes of the sub-ranges of the range, and descends into the mismatched sub-ranges
+// until the sub-ranges are small enough to compare the assets, returning the extra and lost assets.
+func (ds *DataSync) compareRange(ctx context.Context, r types.AssetRange) ([]cid.Cid, []cid.Cid, error) {
+	remoteHashes, err := ds.scheduler.GetAssetRangeHashes(ctx, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localHashes, err := ds.GetAssetRangeHashes(ctx, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	extraAssets := make([]cid.Cid, 0)
+	lostAssets := make([]cid.Cid, 0)
+
+	for index, hash := range remoteHashes {
+		localHash, ok := localHashes[index]
+		delete(localHashes, index)
+		if ok && localHash == hash {
+			continue
+		}
+
+		sub := types.AssetRange{Bucket: r.Bucket, Level: r.Level + 1, Index: index}
+		localAssets, err := ds.GetAssetsInRange(ctx, sub)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
-		if len(extras) > 0 {
-			extraCars = append(extraCars, extras...)
+		var extras, lost []cid.Cid
+		if len(localAssets) > maxAssetsOfRange && sub.Level < types.AssetRangeMaxLevel {
+			extras, lost, err = ds.compareRange(ctx, sub)
+		} else {
+			extras, lost, err = ds.compareRangeAssets(ctx, sub, localAssets)
 		}
 
-		if len(lost) > 0 {
-			lostCars = append(lostCars, lost...)
+		if err != nil {
+			return nil, nil, err
 		}
 
+		extraAssets = append(extraAssets, extras...)
+		lostAssets = append(lostAssets, lost...)
 	}
 
-	for _, car := range extraCars {
-		ds.DeleteAsset(car)
+	// the sub-ranges which are not in the scheduler are extra
+	for index := range localHashes {
+		extras, err := ds.GetAssetsInRange(ctx, types.AssetRange{Bucket: r.Bucket, Level: r.Level + 1, Index: index})
+		if err != nil {
+			return nil, nil, err
+		}
+		extraAssets = append(extraAssets, extras...)
 	}
 
-	for _, car := range lostCars {
-		ds.AddLostAsset(car)
-	}
-	return nil
+	return extraAssets, lostAssets, nil
 }
 
-// compareBuckets compares the assets in the specified bucket in the datastore and in the remote storage, returning the extra and lost assets.
-func (ds *DataSync) compareBuckets(ctx context.Context, bucketID uint32) ([]cid.Cid, []cid.Cid, error) {
-	localAssets, err := ds.GetAssetsOfBucket(ctx, bucketID, false)
+// compareRangeAssets compares the local assets of the range with the assets of the range in the scheduler
+func (ds *DataSync) compareRangeAssets(ctx context.Context, r types.AssetRange, localAssets []cid.Cid) ([]cid.Cid, []cid.Cid, error) {
+	cids, err := ds.scheduler.GetAssetListForRange(ctx, r)
 	if err != nil {
 		return nil, nil, err
 	}
-	remoteAssets, err := ds.GetAssetsOfBucket(ctx, bucketID, true)
-	if err != nil {
-		return nil, nil, err
+
+	remoteAssets := make([]cid.Cid, 0, len(cids))
+	for _, c := range cids {
+		asset, err := cid.Decode(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		remoteAssets = append(remoteAssets, asset)
 	}
 
-	return ds.compareAssets(ctx, localAssets, remoteAssets)
+	extras, lost := compareAssets(localAssets, remoteAssets)
+	return extras, lost, nil
 }
 
-// compareAssets compares the local and remote assets and returns the extra and lost assets.
-func (ds *DataSync) compareAssets(ctx context.Context, localAssets []cid.Cid, remoteAssets []cid.Cid) ([]cid.Cid, []cid.Cid, error) {
+// compareAssets compares the local and remote assets by the multihash and returns the extra and lost assets.
+func compareAssets(localAssets []cid.Cid, remoteAssets []cid.Cid) ([]cid.Cid, []cid.Cid) {
 	localAssetMap := make(map[string]cid.Cid, 0)
 	for _, asset := range localAssets {
 		localAssetMap[asset.Hash().String()] = asset
@@ -192,5 +340,5 @@ func (ds *DataSync) compareAssets(ctx context.Context, localAssets []cid.Cid, re
 	for _, asset := range localAssetMap {
 		extraAssets = append(extraAssets, asset)
 	}
-	return extraAssets, lostAssets, nil
+	return extraAssets, lostAssets
 }
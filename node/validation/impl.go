@@ -2,6 +2,7 @@ package validation
 
 import (
 	"context"
+	"crypto/rsa"
 	"net"
 	"time"
 
@@ -18,6 +19,7 @@ var log = logging.Logger("validate")
 type Validation struct {
 	checker       Checker
 	device        *device.Device
+	privateKey    *rsa.PrivateKey
 	cancelChannel chan bool
 }
 
@@ -30,13 +32,13 @@ type Asset interface {
 }
 
 // NewValidation creates a new Validation instance
-func NewValidation(c Checker, device *device.Device) *Validation {
-	return &Validation{checker: c, device: device}
+func NewValidation(c Checker, device *device.Device, privateKey *rsa.PrivateKey) *Validation {
+	return &Validation{checker: c, device: device, privateKey: privateKey}
 }
 
 // ExecuteValidation performs the validation process
 func (v *Validation) ExecuteValidation(ctx context.Context, req *api.ValidateReq) error {
-	conn, err := newTCPClient(req.TCPSrvAddr)
+	conn, err := newTCPClient(req.TCPSrvAddr, req.TCPSrvTLS, req.TCPSrvCertHash)
 	if err != nil {
 		log.Errorf("new tcp client err:%v", err)
 		return err
@@ -55,7 +57,7 @@ func (v *Validation) StopValidation() {
 }
 
 // sendBlocks sends blocks over a TCP connection with rate limiting
-func (v *Validation) sendBlocks(conn *net.TCPConn, req *api.ValidateReq, speedRate int64) error {
+func (v *Validation) sendBlocks(conn net.Conn, req *api.ValidateReq, speedRate int64) error {
 	defer func() {
 		v.cancelChannel = nil
 		if err := conn.Close(); err != nil {
@@ -81,8 +83,8 @@ func (v *Validation) sendBlocks(conn *net.TCPConn, req *api.ValidateReq, speedRa
 		return err
 	}
 
-	if err := sendNodeID(conn, nodeID, limiter); err != nil {
-		return err
+	if err := sendAuth(conn, nodeID, req.RoundID, v.privateKey, limiter); err != nil {
+		return xerrors.Errorf("auth with validator error %w", err)
 	}
 
 	for {
@@ -2,24 +2,88 @@ package validation
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
+	"time"
 
 	"github.com/Filecoin-Titan/titan/api"
 	"github.com/Filecoin-Titan/titan/lib/limiter"
+	titanrsa "github.com/Filecoin-Titan/titan/node/rsa"
 	"golang.org/x/time/rate"
 )
 
-// establishTCPClient creates a new TCP client with a given address
-func newTCPClient(addr string) (*net.TCPConn, error) {
+const (
+	// handshakeTimeout is the timeout of the handshake with the validator
+	handshakeTimeout = 10 * time.Second
+	// maxChallengeLength is the max length of the challenge message
+	maxChallengeLength = 1024
+)
+
+// establishTCPClient creates a new TCP client with a given address, the connection is wrapped with tls if enableTLS is true,
+// the certificate of the validator must match certHash
+func newTCPClient(addr string, enableTLS bool, certHash string) (net.Conn, error) {
 	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
 
-	return net.DialTCP("tcp", nil, tcpAddr)
+	conn, err := net.DialTCP("tcp", nil, tcpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enableTLS {
+		return conn, nil
+	}
+
+	if certHash == "" {
+		conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("certificate hash of validator %s is empty", addr)
+	}
+
+	// the validator uses a self sign certificate, it is pinned by the hash which the scheduler hands out
+	return tls.Client(conn, &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true, //nolint:gosec // the certificate is verified by VerifyPeerCertificate
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyCertificateHash(rawCerts, certHash)
+		},
+	}), nil
+}
+
+// CertificateHash returns the fingerprint of the DER encoded certificate
+func CertificateHash(der []byte) string {
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:])
+}
+
+// verifyCertificateHash checks the leaf certificate of the peer matches the fingerprint
+func verifyCertificateHash(rawCerts [][]byte, certHash string) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("validator has no certificate")
+	}
+
+	if hash := CertificateHash(rawCerts[0]); hash != certHash {
+		return fmt.Errorf("validator certificate hash %s mismatch %s", hash, certHash)
+	}
+
+	return nil
+}
+
+// AuthContent returns the content signed by the node in the handshake, the round id and the validator nonce
+func AuthContent(roundID string, nonce []byte) []byte {
+	content := make([]byte, 0, len(roundID)+len(nonce))
+	content = append(content, []byte(roundID)...)
+	return append(content, nonce...)
 }
 
 // prepareDataPacket packs data along with its message type into a byte slice
@@ -52,7 +116,7 @@ func packData(data []byte, msgType api.TCPMsgType) ([]byte, error) {
 }
 
 // transmitData sends data along with its message type over a TCP connection with rate limiting
-func sendData(conn *net.TCPConn, data []byte, msgType api.TCPMsgType, rateLimiter *rate.Limiter) error {
+func sendData(conn net.Conn, data []byte, msgType api.TCPMsgType, rateLimiter *rate.Limiter) error {
 	buf, err := packData(data, msgType)
 	if err != nil {
 		return err
@@ -71,11 +135,63 @@ func sendData(conn *net.TCPConn, data []byte, msgType api.TCPMsgType, rateLimite
 	return nil
 }
 
-// transmitNodeID sends the node ID over a TCP connection with rate limiting
-func sendNodeID(conn *net.TCPConn, nodeID string, limiter *rate.Limiter) error {
+// readChallenge reads the nonce which the validator sends at the beginning of the connection
+func readChallenge(conn net.Conn) ([]byte, error) {
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lenBuf); err != nil {
+		return nil, err
+	}
+
+	var contentLen int32
+	if err := binary.Read(bytes.NewReader(lenBuf), binary.LittleEndian, &contentLen); err != nil {
+		return nil, err
+	}
+
+	if contentLen <= 1 || contentLen > maxChallengeLength {
+		return nil, fmt.Errorf("challenge len %d is invalid", contentLen)
+	}
+
+	buf := make([]byte, contentLen)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+
+	if api.TCPMsgType(buf[0]) != api.TCPMsgTypeChallenge {
+		return nil, fmt.Errorf("msg type %d is not challenge", buf[0])
+	}
+
+	return buf[1:], nil
+}
+
+// sendAuth replies the challenge of the validator with the signature of the round id and the nonce
+func sendAuth(conn net.Conn, nodeID, roundID string, privateKey *rsa.PrivateKey, limiter *rate.Limiter) error {
 	if len(nodeID) == 0 {
 		return fmt.Errorf("nodeID can not empty")
 	}
 
-	return sendData(conn, []byte(nodeID), api.TCPMsgTypeNodeID, limiter)
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return err
+	}
+
+	nonce, err := readChallenge(conn)
+	if err != nil {
+		return fmt.Errorf("read challenge error %w", err)
+	}
+
+	titanRsa := titanrsa.New(crypto.SHA256, crypto.SHA256.New())
+	sign, err := titanRsa.Sign(privateKey, AuthContent(roundID, nonce))
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(&api.ValidationAuth{NodeID: nodeID, RoundID: roundID, Sign: sign})
+	if err != nil {
+		return err
+	}
+
+	if err := sendData(conn, buf, api.TCPMsgTypeAuth, limiter); err != nil {
+		return err
+	}
+
+	return conn.SetDeadline(time.Time{})
 }
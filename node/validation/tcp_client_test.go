@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// listenTLS starts a tls server with a self sign certificate, it returns the address and the certificate hash
+func listenTLS(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{certDER}, PrivateKey: key}
+	listen, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listen.Close() }) //nolint:errcheck
+
+	go func() {
+		for {
+			conn, err := listen.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake() //nolint:errcheck
+				conn.Close()                 //nolint:errcheck
+			}()
+		}
+	}()
+
+	return listen.Addr().String(), CertificateHash(certDER)
+}
+
+func TestTCPClientPinsCertificate(t *testing.T) {
+	addr, certHash := listenTLS(t)
+
+	conn, err := newTCPClient(addr, true, certHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.(*tls.Conn).Handshake(); err != nil {
+		t.Errorf("expect handshake to succeed, got %v", err)
+	}
+	conn.Close() //nolint:errcheck
+
+	conn, err = newTCPClient(addr, true, CertificateHash([]byte("other")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.(*tls.Conn).Handshake(); err == nil {
+		t.Error("expect handshake to fail with a mismatched certificate")
+	}
+	conn.Close() //nolint:errcheck
+
+	if _, err := newTCPClient(addr, true, ""); err == nil {
+		t.Error("expect an error without the certificate hash")
+	}
+
+	conn, err = newTCPClient(addr, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := conn.(*net.TCPConn); !ok {
+		t.Error("expect a plain tcp connection without tls")
+	}
+	conn.Close() //nolint:errcheck
+}